@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -15,14 +16,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	cli, err := app.NewCLI(os.Stdout, os.Stderr, cwd)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "init switcher: %v\n", err)
-		os.Exit(1)
-	}
-
+	cli := app.NewCLI(os.Stdout, os.Stderr, cwd)
 	if err := cli.Run(context.Background(), os.Args[1:]); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		var exitErr *app.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		os.Exit(app.ExitCode(err))
 	}
 }
@@ -1,15 +1,32 @@
 package app
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
+	"github.com/mrtuuro/go-switcher/internal/install"
+	"github.com/mrtuuro/go-switcher/internal/notify"
+	"github.com/mrtuuro/go-switcher/internal/progress"
+	"github.com/mrtuuro/go-switcher/internal/releases"
 	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/tools"
 	"github.com/mrtuuro/go-switcher/internal/tui"
+	"github.com/mrtuuro/go-switcher/internal/versionutil"
 )
 
 type CLI struct {
@@ -17,33 +34,99 @@ type CLI struct {
 	stderr  io.Writer
 	cwd     string
 	service *Service
+	// newService, when set, builds the service at the start of each Run
+	// with the startup work the command needs.
+	newService func(ServiceOptions) (*Service, error)
+	// jsonOutput is set by the global --json flag.
+	jsonOutput bool
 }
 
-func NewCLI(stdout io.Writer, stderr io.Writer, cwd string) (*CLI, error) {
-	service, err := NewService()
-	if err != nil {
-		return nil, err
-	}
+// NewCLI returns a CLI that sets up its service in Run, once the command
+// and the startup work it needs are known.
+func NewCLI(stdout io.Writer, stderr io.Writer, cwd string) *CLI {
 	return &CLI{
-		stdout:  stdout,
-		stderr:  stderr,
-		cwd:     cwd,
-		service: service,
-	}, nil
+		stdout:     stdout,
+		stderr:     stderr,
+		cwd:        cwd,
+		newService: NewServiceWithOptions,
+	}
+}
+
+// readOnlyCommands never change the managed directories, so their service
+// skips the writability probe. exec is the one that matters most: it runs
+// for every go invocation through a shim.
+var readOnlyCommands = map[string]bool{
+	"current": true,
+	"list":    true,
+	"export":  true,
+	"history": true,
+	"doctor":  true,
+	"exec":    true,
+}
+
+// recoversExtractions lists the commands that repair extractions left
+// behind by a killed install before running. doctor only reports them.
+var recoversExtractions = map[string]bool{
+	"install": true,
+	"use":     true,
 }
 
 func (c *CLI) Run(ctx context.Context, args []string) error {
-	if len(args) == 0 {
+	// Global flags precede the command so they never collide with flags
+	// forwarded through exec.
+	c.jsonOutput = false
+	strict := false
+	for len(args) > 0 && (args[0] == "--strict-config" || args[0] == "--json") {
+		if args[0] == "--json" {
+			c.jsonOutput = true
+		} else {
+			strict = true
+		}
+		args = args[1:]
+	}
+
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
 		c.printUsage()
 		return nil
 	}
+	if c.jsonOutput {
+		switch args[0] {
+		case "current", "list", "use":
+		default:
+			return usageErrorf("--json is only supported by current, list and use")
+		}
+	}
+	if c.newService != nil {
+		service, err := c.newService(ServiceOptions{
+			SkipWriteProbe:     readOnlyCommands[args[0]],
+			RecoverExtractions: recoversExtractions[args[0]],
+		})
+		if err != nil {
+			return fmt.Errorf("init switcher: %w", err)
+		}
+		for _, note := range service.StartupNotes {
+			c.warnf("%s\n", note)
+		}
+		c.service = service
+	}
+	c.service.ConfigOptions = switcher.ConfigOptions{Strict: strict}
+	// exec runs for every go invocation through a shim, so it reads the
+	// config once for both the check and the version lookup, and skips the
+	// release cache setup in CheckConfig.
+	if args[0] == "exec" {
+		return c.runExec(ctx, args[1:])
+	}
+	warnings, err := c.service.CheckConfig()
+	for _, warning := range warnings {
+		c.warnf("%s\n", warning)
+	}
+	if err != nil {
+		return err
+	}
 
 	switch args[0] {
-	case "help", "--help", "-h":
-		c.printUsage()
-		return nil
 	case "current":
-		return c.runCurrent()
+		return c.runCurrent(args[1:])
 	case "list":
 		return c.runList(ctx, args[1:])
 	case "install":
@@ -52,45 +135,228 @@ func (c *CLI) Run(ctx context.Context, args []string) error {
 		return c.runUse(ctx, args[1:])
 	case "tools":
 		return c.runTools(ctx, args[1:])
-	case "exec":
-		return c.runExec(ctx, args[1:])
+	case "export":
+		return c.runExport(args[1:])
+	case "import":
+		return c.runImport(ctx, args[1:])
+	case "verify":
+		return c.runVerify(ctx, args[1:])
+	case "doctor":
+		return c.runDoctor(args[1:])
+	case "link":
+		return c.runLink(ctx, args[1:])
+	case "unlink":
+		return c.runUnlink(args[1:])
+	case "prune":
+		return c.runPrune(ctx, args[1:])
+	case "gc":
+		return c.runGC(args[1:])
+	case "uninstall", "remove":
+		return c.runUninstall(ctx, args[1:])
+	case "history":
+		return c.runHistory(args[1:])
+	case "config":
+		return c.runConfig(ctx, args[1:])
 	case "tui":
-		return tui.Run(ctx, c.service, c.cwd)
+		return c.runTUI(ctx, args[1:])
 	default:
-		return fmt.Errorf("unknown command %q", args[0])
+		return usageErrorf("unknown command %q", args[0])
 	}
 }
 
-func (c *CLI) runCurrent() error {
+func (c *CLI) runCurrent(args []string) error {
+	exitCode := false
+	quiet := false
+	for _, arg := range args {
+		switch arg {
+		case "--exit-code":
+			exitCode = true
+		case "--quiet", "-q":
+			quiet = true
+		default:
+			return usageErrorf("unknown flag %q", arg)
+		}
+	}
+
 	active, err := c.service.Current(c.cwd)
 	if err != nil {
 		if err == switcher.ErrNoActiveVersion {
-			c.println("no active Go version configured")
+			switch {
+			case quiet:
+			case c.jsonOutput:
+				if err := c.printJSON(nil); err != nil {
+					return err
+				}
+			default:
+				c.println("no active Go version configured")
+			}
+			if exitCode {
+				return &ExitError{Code: ExitCodeNoActiveVersion}
+			}
 			return nil
 		}
 		return err
 	}
 
+	if quiet {
+		return nil
+	}
+	override := goToolchainOverride(os.Getenv("GOTOOLCHAIN"), active.Version)
+	if c.jsonOutput {
+		entry := newActiveEntry(active)
+		entry.Note = override
+		return c.printJSON(entry)
+	}
 	c.printf("%s (%s)\n", active.Version, active.Scope)
-	c.printf("source: %s\n", active.Source)
+	if active.Alias != "" {
+		c.printf("alias: %s\n", active.Alias)
+	}
+	if active.Linked != "" {
+		c.printf("linked toolchain: %s\n", active.Linked)
+	}
+	c.printf("source: %s\n", active.SourceKind.Describe(active.Source))
+	if override != "" {
+		c.printf("note: %s\n", override)
+	}
 	return nil
 }
 
+// activeEntry is the JSON schema emitted by current --json and embedded in
+// use --json.
+type activeEntry struct {
+	Version    string `json:"version"`
+	Scope      string `json:"scope"`
+	Source     string `json:"source"`
+	SourceKind string `json:"source_kind"`
+	Alias      string `json:"alias,omitempty"`
+	Linked     string `json:"linked,omitempty"`
+	Note       string `json:"note,omitempty"`
+}
+
+func newActiveEntry(active switcher.ActiveVersion) activeEntry {
+	return activeEntry{
+		Version:    active.Version,
+		Scope:      string(active.Scope),
+		Source:     active.Source,
+		SourceKind: string(active.SourceKind),
+		Alias:      active.Alias,
+		Linked:     active.Linked,
+	}
+}
+
+// useEntry is the JSON schema emitted by use --json. Active is null when
+// no version is effective afterwards.
+type useEntry struct {
+	Version     string       `json:"version"`
+	Scope       string       `json:"scope"`
+	Unchanged   bool         `json:"unchanged,omitempty"`
+	Active      *activeEntry `json:"active"`
+	LintVersion string       `json:"lint_version,omitempty"`
+	LintSkipped bool         `json:"lint_skipped,omitempty"`
+}
+
+// listEntry is the stable JSON schema emitted by list --json.
+type listEntry struct {
+	Version    string `json:"version"`
+	Major      int    `json:"major"`
+	Minor      int    `json:"minor"`
+	Patch      int    `json:"patch"`
+	Prerelease string `json:"prerelease"`
+	Active     bool   `json:"active"`
+}
+
+// listFilter narrows list output to a minor line and/or its newest patches.
+type listFilter struct {
+	latestPerMinor bool
+	hasMinor       bool
+	major          int
+	minor          int
+}
+
+func (f listFilter) apply(versions []string) []string {
+	if f.hasMinor {
+		versions = versionutil.FilterMinor(versions, f.major, f.minor)
+	}
+	if f.latestPerMinor {
+		versions = latestPerMinor(versions)
+	}
+	return versions
+}
+
+// latestPerMinor keeps only the newest patch of each major.minor line, in
+// the order the lines first appear. Unparseable versions are dropped.
+func latestPerMinor(versions []string) []string {
+	groups := versionutil.GroupByMinor(versions)
+	latest := make([]string, 0, len(groups))
+	for _, v := range versions {
+		line, err := versionutil.MinorLine(v)
+		if err != nil {
+			continue
+		}
+		if group, ok := groups[line]; ok {
+			latest = append(latest, group[0])
+			delete(groups, line)
+		}
+	}
+	return latest
+}
+
 func (c *CLI) runList(ctx context.Context, args []string) error {
 	remote := false
-	for _, arg := range args {
-		if arg == "--remote" {
+	asJSON := c.jsonOutput
+	long := false
+	var fetchOpts releases.FetchOptions
+	filter := listFilter{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--remote":
 			remote = true
-			continue
+		case arg == "--refresh":
+			fetchOpts.Refresh = true
+		case arg == "--json":
+			asJSON = true
+		case arg == "--long" || arg == "-l":
+			long = true
+		case arg == "--latest-per-minor":
+			filter.latestPerMinor = true
+		case strings.HasPrefix(arg, "--minor="), arg == "--minor":
+			raw := strings.TrimPrefix(arg, "--minor=")
+			if arg == "--minor" {
+				if i+1 >= len(args) {
+					return usageErrorf("missing value for --minor")
+				}
+				raw = args[i+1]
+				i++
+			}
+			major, minor, err := versionutil.ParseMinorSelector(raw)
+			if err != nil {
+				return asUsageError(err)
+			}
+			filter.hasMinor = true
+			filter.major = major
+			filter.minor = minor
+		default:
+			return usageErrorf("unknown list argument %q", arg)
 		}
-		return fmt.Errorf("unknown list argument %q", arg)
+	}
+
+	if long && (remote || asJSON) {
+		return usageErrorf("--long cannot be combined with --remote or --json")
+	}
+	if fetchOpts.Refresh && !remote {
+		return usageErrorf("--refresh requires --remote")
+	}
+	if asJSON {
+		return c.printListJSON(ctx, remote, fetchOpts, filter)
 	}
 
 	if remote {
-		versions, err := c.service.ListRemote(ctx)
+		listing, err := c.service.ListRemoteListingWithOptions(ctx, fetchOpts)
 		if err != nil {
 			return err
 		}
+		versions := filter.apply(listing.Versions)
 		if len(versions) == 0 {
 			c.println("no remote versions found for this platform")
 			return nil
@@ -105,6 +371,13 @@ func (c *CLI) runList(ctx context.Context, args []string) error {
 	if err != nil {
 		return err
 	}
+	localVersions = filter.apply(localVersions)
+
+	if broken, brokenErr := c.service.ListBroken(); brokenErr == nil {
+		for _, entry := range broken {
+			c.warnf("ignoring broken toolchain %s at %s: %s\n", entry.Version, entry.Path, entry.Reason)
+		}
+	}
 
 	active, err := c.service.Current(c.cwd)
 	if err != nil && err != switcher.ErrNoActiveVersion {
@@ -116,6 +389,13 @@ func (c *CLI) runList(ctx context.Context, args []string) error {
 		return nil
 	}
 
+	if long {
+		if err != nil {
+			active = switcher.ActiveVersion{}
+		}
+		return c.printListLong(localVersions, active)
+	}
+
 	for _, version := range localVersions {
 		prefix := "  "
 		if err == nil && version == active.Version {
@@ -127,171 +407,1592 @@ func (c *CLI) runList(ctx context.Context, args []string) error {
 	return nil
 }
 
-func (c *CLI) runInstall(ctx context.Context, args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("usage: switcher install <go-version>")
+// printListLong prints installed toolchains as aligned columns. active is
+// the zero value when no version is active.
+func (c *CLI) printListLong(versions []string, active switcher.ActiveVersion) error {
+	details, err := c.service.InstalledVersionDetails(versions)
+	if err != nil {
+		return err
+	}
+
+	activeLint := ""
+	if active.Version != "" {
+		if statuses, err := c.service.ActiveToolVersions(c.cwd); err == nil {
+			activeLint = statuses["golangci-lint"].Version
+		}
+	}
+
+	writer := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "  VERSION\tSCOPE\tGOLANGCI-LINT\tSIZE")
+	for _, detail := range details {
+		marker, scope, lint := "  ", "-", detail.LintVersion
+		if detail.Version == active.Version {
+			marker, scope = "* ", string(active.Scope)
+			if activeLint != "" {
+				lint = activeLint
+			}
+		}
+		if lint == "" {
+			lint = "-"
+		}
+		_, _ = fmt.Fprintf(writer, "%s%s\t%s\t%s\t%s\n", marker, detail.Version, scope, lint, progress.FormatBytes(detail.Size))
+	}
+	return writer.Flush()
+}
+
+func (c *CLI) printListJSON(ctx context.Context, remote bool, fetchOpts releases.FetchOptions, filter listFilter) error {
+	if remote {
+		// Remote versions are never active, so they are printed as plain
+		// strings without resolving the active version.
+		listing, err := c.service.ListRemoteListingWithOptions(ctx, fetchOpts)
+		if err != nil {
+			return err
+		}
+		versions := filter.apply(listing.Versions)
+		if versions == nil {
+			versions = []string{}
+		}
+		return c.printJSON(versions)
 	}
 
-	version, err := c.service.Install(ctx, args[0])
+	versions, err := c.service.ListLocal()
 	if err != nil {
 		return err
 	}
+	versions = filter.apply(versions)
 
-	c.printf("installed %s\n", version)
-	pathHint, inPath, err := c.service.PathHint()
-	if err == nil && !inPath {
-		c.printf("add %s to PATH to use shims\n", pathHint)
+	activeVersion := ""
+	active, err := c.service.Current(c.cwd)
+	if err == nil {
+		activeVersion = active.Version
+	} else if err != switcher.ErrNoActiveVersion {
+		return err
 	}
-	return nil
+
+	entries := make([]listEntry, 0, len(versions))
+	for _, version := range versions {
+		major, minor, patch, err := versionutil.ParseGoVersion(version)
+		if err != nil {
+			return err
+		}
+		key, _ := versionutil.ParseVersionKey(version)
+		entries = append(entries, listEntry{
+			Version:    version,
+			Major:      major,
+			Minor:      minor,
+			Patch:      patch,
+			Prerelease: key.Prerelease(),
+			Active:     version == activeVersion,
+		})
+	}
+
+	return c.printJSON(entries)
 }
 
-func (c *CLI) runUse(ctx context.Context, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("usage: switcher use <go-version> [--scope global|local]")
+func (c *CLI) printJSON(value any) error {
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode json output: %w", err)
 	}
+	encoded = append(encoded, '\n')
+	_, err = c.stdout.Write(encoded)
+	return err
+}
 
+func (c *CLI) runInstall(ctx context.Context, args []string) error {
 	version := ""
-	scope := switcher.ScopeGlobal
+	quiet := false
+	noCache := false
+	verifyOnly := false
+	checkOnly := false
+	insecure := false
+	telemetry := ""
+	variant := ""
+	channel := ""
+	notifyDone := false
+	sha256 := ""
+	checksums := ""
+	var platforms []releases.Platform
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		switch {
-		case strings.HasPrefix(arg, "--scope="):
-			rawScope := strings.TrimPrefix(arg, "--scope=")
-			parsed, err := switcher.ParseScope(rawScope)
+		case arg == "--quiet" || arg == "-q":
+			quiet = true
+		case arg == "--notify":
+			notifyDone = true
+		case arg == "--no-cache":
+			noCache = true
+		case arg == "--verify-only":
+			verifyOnly = true
+		case arg == "--check-only":
+			checkOnly = true
+		case arg == "--insecure-skip-verify":
+			insecure = true
+		case strings.HasPrefix(arg, "--go-telemetry="):
+			telemetry = strings.TrimPrefix(arg, "--go-telemetry=")
+		case arg == "--go-telemetry":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for --go-telemetry")
+			}
+			telemetry = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--channel="):
+			channel = strings.TrimPrefix(arg, "--channel=")
+		case arg == "--channel":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for --channel")
+			}
+			channel = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--sha256="):
+			sha256 = strings.TrimPrefix(arg, "--sha256=")
+		case arg == "--sha256":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for --sha256")
+			}
+			sha256 = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--checksums="):
+			checksums = strings.TrimPrefix(arg, "--checksums=")
+		case arg == "--checksums":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for --checksums")
+			}
+			checksums = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--variant="):
+			variant = strings.TrimPrefix(arg, "--variant=")
+		case arg == "--variant":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for --variant")
+			}
+			variant = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--platform="):
+			parsed, err := parsePlatforms(strings.TrimPrefix(arg, "--platform="))
 			if err != nil {
-				return err
+				return asUsageError(err)
 			}
-			scope = parsed
-		case arg == "--scope":
+			platforms = append(platforms, parsed...)
+		case arg == "--platform":
 			if i+1 >= len(args) {
-				return fmt.Errorf("missing value for --scope")
+				return usageErrorf("missing value for --platform")
 			}
-			parsed, err := switcher.ParseScope(args[i+1])
+			parsed, err := parsePlatforms(args[i+1])
 			if err != nil {
-				return err
+				return asUsageError(err)
 			}
-			scope = parsed
+			platforms = append(platforms, parsed...)
 			i++
 		case strings.HasPrefix(arg, "-"):
-			return fmt.Errorf("unknown flag %q", arg)
+			return usageErrorf("unknown flag %q", arg)
 		default:
 			if version != "" {
-				return fmt.Errorf("multiple versions provided")
+				return usageErrorf("multiple versions provided")
 			}
 			version = arg
 		}
 	}
+	if len(platforms) > 0 && (noCache || verifyOnly || checkOnly || variant != "" || insecure || telemetry != "" || sha256 != "" || checksums != "") {
+		return usageErrorf("--no-cache, --verify-only, --check-only, --variant, --sha256, --checksums, --insecure-skip-verify and --go-telemetry cannot be combined with --platform")
+	}
+	if verifyOnly && checkOnly {
+		return usageErrorf("--verify-only and --check-only cannot be combined")
+	}
+	if sha256 != "" && checksums != "" {
+		return usageErrorf("--sha256 and --checksums cannot be combined")
+	}
+	if checkOnly && (sha256 != "" || checksums != "") {
+		return usageErrorf("--sha256 and --checksums cannot be combined with --check-only")
+	}
+	if (verifyOnly || checkOnly) && (noCache || telemetry != "" || notifyDone) {
+		return usageErrorf("--no-cache, --go-telemetry and --notify cannot be combined with --verify-only or --check-only")
+	}
 
+	insecure = insecure || httpclient.InsecureRequested()
+	// Warn before the first unverified request, which may be the channel
+	// lookup. Platform installs always verify their downloads.
+	if insecure && (channel != "" || len(platforms) == 0) {
+		c.warnInsecure()
+	}
+	version, err := c.resolveChannel(ctx, channel, version, insecure)
+	if err != nil {
+		return err
+	}
 	if version == "" {
-		return fmt.Errorf("missing go version")
+		version, err = c.projectVersion(ctx)
+		if err != nil {
+			return err
+		}
 	}
 
-	resolvedVersion, lintVersion, err := c.service.Use(ctx, version, scope, c.cwd)
+	reporter := c.progressReporter(quiet)
+	if len(platforms) > 0 {
+		err := c.runInstallPlatforms(ctx, version, platforms, reporter)
+		c.notifyCompletion(ctx, notifyDone, fmt.Sprintf("Installed %s for %d platforms", version, len(platforms)), "Install of "+version, err)
+		return err
+	}
+	if verifyOnly {
+		return c.runVerifyArchive(ctx, version, InstallOptions{Reporter: reporter, Variant: variant, InsecureSkipVerify: insecure, SHA256: sha256, Checksums: checksums})
+	}
+	if checkOnly {
+		return c.runCheckInstall(ctx, version, InstallOptions{Variant: variant, InsecureSkipVerify: insecure})
+	}
+	requested := version
+	result, err := c.service.InstallWithOptions(ctx, version, InstallOptions{Reporter: reporter, NoCache: noCache, Variant: variant, InsecureSkipVerify: insecure, Telemetry: telemetry, SHA256: sha256, Checksums: checksums})
+	c.notifyCompletion(ctx, notifyDone, "Installed "+result.Version, "Install of "+requested, err)
 	if err != nil {
 		return err
 	}
 
-	c.printf("configured Go version %s (%s)\n", resolvedVersion, scope)
-	active, activeErr := c.service.Current(c.cwd)
-	if activeErr == nil {
-		if active.Version == resolvedVersion && active.Scope == scope {
-			c.printf("effective active version is %s (%s)\n", active.Version, active.Scope)
-		} else {
-			c.printf("effective active version is %s (%s)\n", active.Version, active.Scope)
-			c.println("note: local scope overrides global in this directory")
-		}
-	}
-	c.printf("golangci-lint synced to %s\n", lintVersion)
-	pathHint, inPath, err := c.service.PathHint()
-	if err == nil && !inPath {
-		c.printf("add %s to PATH to use shims\n", pathHint)
+	if result.AlreadyInstalled {
+		c.printf("%s already installed\n", result.Version)
+	} else {
+		c.printf("installed %s\n", result.Version)
 	}
+	c.printPathHint()
 	return nil
 }
 
-func (c *CLI) runTools(ctx context.Context, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("usage: switcher tools sync [--scope global|local]")
+func (c *CLI) runVerifyArchive(ctx context.Context, version string, opts InstallOptions) error {
+	version, check, err := c.service.VerifyArchive(ctx, version, opts)
+	if err != nil {
+		return err
 	}
 
-	if args[0] != "sync" {
-		return fmt.Errorf("unknown tools command %q", args[0])
+	archive := check.Path
+	if check.Downloaded {
+		archive += " (downloaded)"
 	}
-
-	scopeOverride := ""
-	flags := args[1:]
-	for i := 0; i < len(flags); i++ {
-		arg := flags[i]
-		switch {
-		case strings.HasPrefix(arg, "--scope="):
-			scopeOverride = strings.TrimPrefix(arg, "--scope=")
-		case arg == "--scope":
-			if i+1 >= len(flags) {
-				return fmt.Errorf("missing value for --scope")
-			}
-			scopeOverride = flags[i+1]
-			i++
-		default:
-			return fmt.Errorf("unknown tools sync flag %q", arg)
+	expected := check.Expected
+	if expected == "" {
+		expected = "(not published)"
+	}
+	c.printf("version:  %s\n", version)
+	c.printf("archive:  %s\n", archive)
+	c.printf("expected: %s\n", expected)
+	c.printf("actual:   %s\n", check.Actual)
+	if !check.Match() {
+		c.printf("FAIL\n")
+		if check.Expected == "" {
+			return fmt.Errorf("no published checksum for %s", filepath.Base(check.Path))
 		}
+		return fmt.Errorf("%w for %s", install.ErrChecksumMismatch, filepath.Base(check.Path))
 	}
+	c.printf("PASS\n")
+	return nil
+}
 
-	goVersion, lintVersion, err := c.service.SyncTools(ctx, c.cwd, scopeOverride)
+// runCheckInstall reports the install state of version. Each state has its
+// own exit code: 0 installed, ExitCodeDownloadNeeded available, and
+// ExitCodeNotInstalled not available.
+func (c *CLI) runCheckInstall(ctx context.Context, version string, opts InstallOptions) error {
+	check, err := c.service.CheckInstall(ctx, version, opts)
 	if err != nil {
 		return err
 	}
 
-	c.printf("synced golangci-lint %s for %s\n", lintVersion, goVersion)
-	return nil
+	switch check.State {
+	case InstallCheckInstalled:
+		c.printf("%s: already installed\n", check.Version)
+		return nil
+	case InstallCheckAvailable:
+		if check.Cached {
+			c.printf("%s: available (archive already cached, no download needed)\n", check.Version)
+		} else {
+			c.printf("%s: available (will download %s)\n", check.Version, progress.FormatBytes(check.Size))
+		}
+		return &ExitError{Code: ExitCodeDownloadNeeded}
+	default:
+		c.printf("%s: not available for %s/%s: %s\n", check.Version, runtime.GOOS, runtime.GOARCH, check.Reason)
+		return &ExitError{Code: ExitCodeNotInstalled}
+	}
 }
 
-func (c *CLI) runExec(ctx context.Context, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("usage: switcher exec <tool> [args...]")
+// resolveChannel turns --channel into the concrete version it points to.
+// Without a channel, version is returned unchanged.
+func (c *CLI) resolveChannel(ctx context.Context, rawChannel string, version string, insecure bool) (string, error) {
+	if rawChannel == "" {
+		return version, nil
+	}
+	if version != "" {
+		return "", usageErrorf("pass either a go version or --channel, not both")
 	}
+	channel, err := releases.ParseChannel(rawChannel)
+	if err != nil {
+		return "", asUsageError(err)
+	}
+	resolved, err := c.service.ResolveChannel(ctx, channel, insecure)
+	if err != nil {
+		return "", err
+	}
+	c.statusf("%s channel resolves to %s\n", channel, resolved)
+	return resolved, nil
+}
 
-	tool := args[0]
-	binaryPath, activeVersion, err := c.service.ResolveBinaryForTool(c.cwd, tool)
+// resolveLatest resolves use --latest to the newest published version,
+// prereleases included, and --latest-stable to the newest stable one.
+func (c *CLI) resolveLatest(ctx context.Context, flag string, insecure bool) (string, error) {
+	channel := releases.ChannelStable
+	if flag == "--latest" {
+		channel = releases.ChannelRC
+	}
+	resolved, err := c.service.ResolveChannel(ctx, channel, insecure)
 	if err != nil {
-		return err
+		return "", err
 	}
+	c.statusf("%s resolves to %s\n", flag, resolved)
+	return resolved, nil
+}
 
-	cmd := exec.CommandContext(ctx, binaryPath, args[1:]...)
-	cmd.Env = os.Environ()
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// projectVersion is the version install and use fall back to without an
+// argument: whatever .switcher-version, go.work or go.mod around cwd names.
+func (c *CLI) projectVersion(ctx context.Context) (string, error) {
+	version, source, err := c.service.ProjectVersion(ctx, c.cwd)
+	if err != nil {
+		return "", err
+	}
+	c.statusf("%s requires %s\n", source, version)
+	return version, nil
+}
 
-	if runErr := cmd.Run(); runErr != nil {
-		return fmt.Errorf("run %s with %s: %w", tool, activeVersion, runErr)
+// warnInsecure is printed before every insecure request, even with --quiet.
+func (c *CLI) warnInsecure() {
+	if httpclient.StrictHostsEnabled() {
+		_, _ = fmt.Fprintf(c.stderr, "warning: --insecure-skip-verify ignored because %s is set\n", httpclient.StrictHostsEnv)
+		return
+	}
+	_, _ = fmt.Fprintln(c.stderr, "WARNING: TLS certificate verification is DISABLED for this command.")
+	_, _ = fmt.Fprintln(c.stderr, "WARNING: downloads can be intercepted; only use --insecure-skip-verify on a trusted network.")
+}
+
+func (c *CLI) runInstallPlatforms(ctx context.Context, version string, platforms []releases.Platform, reporter progress.Reporter) error {
+	normalized, results, err := c.service.InstallForPlatforms(ctx, version, platforms, reporter)
+	if err != nil {
+		return err
 	}
 
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			c.printf("%s: failed: %v\n", result.Platform, result.Err)
+			continue
+		}
+		c.printf("%s: installed %s at %s\n", result.Platform, normalized, result.Dir)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d platforms failed", failed, len(results))
+	}
 	return nil
 }
 
-func (c *CLI) printUsage() {
-	usage := `switcher - Go toolchain switcher
-
-Usage:
-  switcher current
-  switcher list [--remote]
-  switcher install <go-version>
-  switcher use <go-version> [--scope global|local]
-  switcher tools sync [--scope global|local]
-  switcher tui
+// progressReporter writes progress to stderr so stdout stays parseable.
+func (c *CLI) progressReporter(quiet bool) progress.Reporter {
+	if quiet {
+		return nil
+	}
+	return progress.WriterReporter(c.stderr)
+}
 
-Notes:
-  - local scope uses .switcher-version in the working tree
-  - local scope overrides global scope when both are set
-  - add ~/.switcher/bin to PATH to use go/gofmt/golangci-lint shims
-`
-	c.println(usage)
+// asUsageError marks an invalid flag value as a usage error.
+func asUsageError(err error) error {
+	return &UsageError{Message: err.Error()}
 }
 
-func (c *CLI) println(line string) {
-	_, _ = fmt.Fprintln(c.stdout, line)
+func parsePlatforms(raw string) ([]releases.Platform, error) {
+	var platforms []releases.Platform
+	seen := map[releases.Platform]struct{}{}
+	for _, part := range strings.Split(raw, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		platform, err := releases.ParsePlatform(part)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[platform]; ok {
+			continue
+		}
+		seen[platform] = struct{}{}
+		platforms = append(platforms, platform)
+	}
+	if len(platforms) == 0 {
+		return nil, usageErrorf("--platform requires at least one os/arch pair")
+	}
+	return platforms, nil
 }
 
-func (c *CLI) printf(format string, args ...any) {
-	_, _ = fmt.Fprintf(c.stdout, format, args...)
+func (c *CLI) runUse(ctx context.Context, args []string) error {
+	version := ""
+	scope := switcher.ScopeGlobal
+	quiet := false
+	channel := ""
+	latest := ""
+	historySteps := 0
+	notifyDone := false
+	opts := UseOptions{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--quiet" || arg == "-q":
+			quiet = true
+		case arg == "--latest" || arg == "--latest-stable":
+			if latest != "" && latest != arg {
+				return usageErrorf("--latest and --latest-stable cannot be combined")
+			}
+			latest = arg
+		case isHistoryStep(arg):
+			if version != "" || historySteps != 0 {
+				return usageErrorf("multiple versions provided")
+			}
+			historySteps = 1
+			if arg != "-" {
+				historySteps, _ = strconv.Atoi(arg[1:])
+			}
+		case arg == "--reresolve-tools":
+			opts.ReresolveTools = true
+		case arg == "--write-gitignore":
+			opts.WriteGitignore = true
+		case arg == "--promote-local":
+			opts.PromoteLocal = true
+		case arg == "--set-goroot":
+			opts.SetGoroot = true
+		case arg == "--record":
+			opts.Record = true
+		case arg == "--no-hook":
+			opts.NoHook = true
+		case arg == "--if-unset":
+			opts.IfUnset = true
+		case arg == "--strict":
+			opts.Strict = true
+		case arg == "--alias":
+			opts.Alias = true
+		case arg == "--notify":
+			notifyDone = true
+		case strings.HasPrefix(arg, "--lint="):
+			opts.LintVersion = strings.TrimPrefix(arg, "--lint=")
+		case arg == "--lint":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for --lint")
+			}
+			opts.LintVersion = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--channel="):
+			channel = strings.TrimPrefix(arg, "--channel=")
+		case arg == "--channel":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for --channel")
+			}
+			channel = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--scope="):
+			rawScope := strings.TrimPrefix(arg, "--scope=")
+			parsed, err := switcher.ParseScope(rawScope)
+			if err != nil {
+				return asUsageError(err)
+			}
+			scope = parsed
+		case arg == "--scope":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for --scope")
+			}
+			parsed, err := switcher.ParseScope(args[i+1])
+			if err != nil {
+				return asUsageError(err)
+			}
+			scope = parsed
+			i++
+		case strings.HasPrefix(arg, "-"):
+			return usageErrorf("unknown flag %q", arg)
+		default:
+			if version != "" || historySteps != 0 {
+				return usageErrorf("multiple versions provided")
+			}
+			version = arg
+		}
+	}
+
+	if latest != "" {
+		if version != "" || historySteps != 0 {
+			return usageErrorf("pass either a go version or %s, not both", latest)
+		}
+		if channel != "" {
+			return usageErrorf("%s cannot be combined with --channel", latest)
+		}
+	}
+	if historySteps != 0 {
+		if channel != "" {
+			return usageErrorf("-%d cannot be combined with --channel", historySteps)
+		}
+		previous, err := c.service.PreviousGlobal(historySteps)
+		if err != nil {
+			return err
+		}
+		version = previous
+	}
+	insecure := httpclient.InsecureRequested()
+	if insecure && (channel != "" || latest != "") {
+		c.warnInsecure()
+	}
+	version, err := c.resolveChannel(ctx, channel, version, insecure)
+	if err != nil {
+		return err
+	}
+	if latest != "" {
+		if version, err = c.resolveLatest(ctx, latest, insecure); err != nil {
+			return err
+		}
+	}
+	if version == "" {
+		version, err = c.projectVersion(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.LintVersion != "" && opts.LintVersion != tools.LintRecommended {
+		if _, err := tools.NormalizeLintVersion(opts.LintVersion); err != nil {
+			return asUsageError(err)
+		}
+	}
+
+	opts.Reporter = c.progressReporter(quiet)
+	result, err := c.service.UseWithOptions(ctx, version, scope, c.cwd, opts)
+	c.notifyCompletion(ctx, notifyDone, fmt.Sprintf("Switched to %s (%s)", result.Version, scope), "Switch to "+version, err)
+	if err != nil {
+		return err
+	}
+	resolvedVersion := result.Version
+	if c.jsonOutput {
+		return c.printUseJSON(result, scope)
+	}
+	if result.Unchanged {
+		c.printf("global Go version already set to %s; leaving it unchanged\n", resolvedVersion)
+		return nil
+	}
+
+	c.printf("configured Go version %s (%s)\n", resolvedVersion, scope)
+	active, activeErr := c.service.Current(c.cwd)
+	if activeErr == nil {
+		switch {
+		case active.Version == resolvedVersion && (active.Scope == scope || result.PromotedLocal != ""):
+			c.printf("effective active version is %s (%s)\n", active.Version, active.Scope)
+		case active.Version != resolvedVersion && scope == switcher.ScopeGlobal:
+			c.printf("effective active version is %s (%s)\n", active.Version, active.Scope)
+			c.println("note: local scope overrides global in this directory; pass --promote-local to update the local pin")
+		default:
+			c.printf("effective active version is %s (%s)\n", active.Version, active.Scope)
+			c.println("note: local scope overrides global in this directory")
+		}
+	}
+	if result.LintSkipped {
+		c.println("golangci-lint sync skipped")
+	} else {
+		c.printf("golangci-lint synced to %s\n", result.LintVersion)
+	}
+	if result.GorootSet != "" {
+		c.printf("persisted GOROOT=%s with go env -w\n", result.GorootSet)
+	}
+	if result.GorootCleared {
+		c.println("cleared GOROOT persisted by an earlier --set-goroot")
+	}
+	if result.PromotedLocal != "" {
+		c.printf("updated local pin %s to %s\n", result.PromotedLocal, resolvedVersion)
+	}
+	if result.GitignoreUpdated != "" {
+		c.printf("added %s to %s\n", switcher.LocalVersionFile, result.GitignoreUpdated)
+	}
+	for _, warning := range result.Warnings {
+		c.warnf("%s\n", warning)
+	}
+	c.printPathHint()
+	return nil
+}
+
+// printUseJSON reports a finished use as a useEntry. Warnings still go to
+// stderr.
+func (c *CLI) printUseJSON(result UseResult, scope switcher.Scope) error {
+	entry := useEntry{Version: result.Version, Scope: string(scope), Unchanged: result.Unchanged}
+	if !result.Unchanged {
+		entry.LintVersion = result.LintVersion
+		entry.LintSkipped = result.LintSkipped
+	}
+	active, err := c.service.Current(c.cwd)
+	if err == nil {
+		activeEntry := newActiveEntry(active)
+		entry.Active = &activeEntry
+	} else if err != switcher.ErrNoActiveVersion {
+		return err
+	}
+	for _, warning := range result.Warnings {
+		c.warnf("%s\n", warning)
+	}
+	return c.printJSON(entry)
+}
+
+func (c *CLI) runTools(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("usage: switcher tools sync [--scope global|local] [--all-installed [--dry-run]] | switcher tools pin <lint-version>|recommended [--go <go-version>]")
+	}
+
+	switch args[0] {
+	case "sync":
+	case "pin":
+		return c.runToolsPin(args[1:])
+	default:
+		return usageErrorf("unknown tools command %q", args[0])
+	}
+
+	scopeOverride := ""
+	allInstalled := false
+	dryRun := false
+	flags := args[1:]
+	for i := 0; i < len(flags); i++ {
+		arg := flags[i]
+		switch {
+		case strings.HasPrefix(arg, "--scope="):
+			scopeOverride = strings.TrimPrefix(arg, "--scope=")
+		case arg == "--scope":
+			if i+1 >= len(flags) {
+				return usageErrorf("missing value for --scope")
+			}
+			scopeOverride = flags[i+1]
+			i++
+		case arg == "--all-installed":
+			allInstalled = true
+		case arg == "--dry-run":
+			dryRun = true
+		default:
+			return usageErrorf("unknown tools sync flag %q", arg)
+		}
+	}
+
+	if allInstalled {
+		if scopeOverride != "" {
+			return usageErrorf("--all-installed cannot be combined with --scope")
+		}
+		return c.runToolsSyncAll(ctx, dryRun)
+	}
+	if dryRun {
+		return usageErrorf("--dry-run requires --all-installed")
+	}
+
+	goVersion, lintVersion, err := c.service.SyncTools(ctx, c.cwd, scopeOverride)
+	if err != nil {
+		return err
+	}
+
+	c.printf("synced golangci-lint %s for %s\n", lintVersion, goVersion)
+	return nil
+}
+
+func (c *CLI) runToolsPin(args []string) error {
+	lintVersion := ""
+	goVersion := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--go="):
+			goVersion = strings.TrimPrefix(arg, "--go=")
+		case arg == "--go":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for --go")
+			}
+			goVersion = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "-"):
+			return usageErrorf("unknown tools pin flag %q", arg)
+		default:
+			if lintVersion != "" {
+				return usageErrorf("multiple lint versions provided")
+			}
+			lintVersion = arg
+		}
+	}
+	if lintVersion == "" {
+		return usageErrorf("usage: switcher tools pin <lint-version>|recommended [--go <go-version>]")
+	}
+	if lintVersion != tools.LintRecommended {
+		if _, err := tools.NormalizeLintVersion(lintVersion); err != nil {
+			return asUsageError(err)
+		}
+	}
+
+	pinnedGo, resolved, err := c.service.PinLint(c.cwd, goVersion, lintVersion)
+	if err != nil {
+		return err
+	}
+	if lintVersion == tools.LintRecommended {
+		c.printf("unpinned golangci-lint for %s; it now resolves to %s\n", pinnedGo, resolved)
+	} else {
+		c.printf("pinned golangci-lint %s for %s\n", resolved, pinnedGo)
+	}
+	if goVersion == "" {
+		c.println("run 'switcher tools sync' to install it")
+	} else {
+		c.println("run 'switcher tools sync --all-installed' to install it")
+	}
+	return nil
+}
+
+func (c *CLI) runToolsSyncAll(ctx context.Context, dryRun bool) error {
+	results, err := c.service.SyncAllInstalledTools(ctx, dryRun, c.progressReporter(false))
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		c.printf("no installed versions\n")
+		return nil
+	}
+
+	failed := 0
+	for _, result := range results {
+		switch result.State {
+		case ToolSyncPresent:
+			c.printf("%s: golangci-lint %s already installed\n", result.GoVersion, result.LintVersion)
+		case ToolSyncInstalled:
+			c.printf("%s: installed golangci-lint %s\n", result.GoVersion, result.LintVersion)
+		case ToolSyncShared:
+			c.printf("%s: shares golangci-lint %s\n", result.GoVersion, result.LintVersion)
+		case ToolSyncWouldInstall:
+			c.printf("%s: would install golangci-lint %s\n", result.GoVersion, result.LintVersion)
+		case ToolSyncFailed:
+			failed++
+			c.printf("%s: golangci-lint %s failed: %v\n", result.GoVersion, result.LintVersion, result.Err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d versions failed", failed, len(results))
+	}
+	return nil
+}
+
+func (c *CLI) runExport(args []string) error {
+	output := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--output="):
+			output = strings.TrimPrefix(arg, "--output=")
+		case arg == "--output" || arg == "-o":
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for %s", arg)
+			}
+			output = args[i+1]
+			i++
+		default:
+			return usageErrorf("unknown export argument %q", arg)
+		}
+	}
+
+	manifest, err := c.service.Export()
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	encoded = append(encoded, '\n')
+
+	if output == "" || output == "-" {
+		_, err := c.stdout.Write(encoded)
+		return err
+	}
+
+	if err := os.WriteFile(output, encoded, 0o644); err != nil {
+		return fmt.Errorf("write manifest %s: %w", output, err)
+	}
+	c.printf("exported %d versions to %s\n", len(manifest.Versions), output)
+	return nil
+}
+
+func (c *CLI) runImport(ctx context.Context, args []string) error {
+	file := ""
+	dryRun := false
+	for _, arg := range args {
+		switch {
+		case arg == "--dry-run":
+			dryRun = true
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			return usageErrorf("unknown import flag %q", arg)
+		default:
+			if file != "" {
+				return usageErrorf("multiple manifest files provided")
+			}
+			file = arg
+		}
+	}
+	if file == "" {
+		return usageErrorf("usage: switcher import <file> [--dry-run]")
+	}
+
+	var reader io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("open manifest %s: %w", file, err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		reader = f
+	}
+
+	manifest, err := DecodeManifest(reader)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.service.Import(ctx, manifest, dryRun, nil)
+	if err != nil {
+		return err
+	}
+
+	prefix := ""
+	if result.DryRun {
+		prefix = "would "
+	}
+	for _, version := range result.AlreadyInstalled {
+		c.printf("already installed %s\n", version)
+	}
+	for _, version := range result.Installed {
+		c.printf("%sinstall %s\n", prefix, version)
+	}
+	goVersions := make([]string, 0, len(result.LintMappings))
+	for goVersion := range result.LintMappings {
+		goVersions = append(goVersions, goVersion)
+	}
+	sort.Strings(goVersions)
+	for _, goVersion := range goVersions {
+		verb := "map"
+		if slices.Contains(result.LintPinned, goVersion) {
+			verb = "pin"
+		}
+		c.printf("%s%s golangci-lint %s for %s\n", prefix, verb, result.LintMappings[goVersion], goVersion)
+	}
+	if result.GlobalVersion != "" {
+		c.printf("%sset global version %s\n", prefix, result.GlobalVersion)
+	}
+	if len(result.Installed) == 0 && len(result.LintMappings) == 0 && result.GlobalVersion == "" {
+		c.println("environment already matches manifest")
+	}
+	return nil
+}
+
+func (c *CLI) runExec(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("usage: switcher exec <tool> [args...]")
+	}
+	cfg, warnings, err := c.service.ReadConfig()
+	for _, warning := range warnings {
+		c.warnf("%s\n", warning)
+	}
+	if err != nil {
+		return err
+	}
+	if args[0] == "--self-check" {
+		return c.runSelfCheck()
+	}
+
+	tool := args[0]
+	binaryPath, activeVersion, err := c.service.resolveBinaryForTool(c.cwd, tool, cfg)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, binaryPath, args[1:]...)
+	cmd.Env = os.Environ()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if runErr := cmd.Run(); runErr != nil {
+		return fmt.Errorf("run %s with %s: %w", tool, activeVersion, runErr)
+	}
+
+	return nil
+}
+
+func (c *CLI) runVerify(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		return usageErrorf("usage: switcher verify [go-version]")
+	}
+	version := ""
+	if len(args) == 1 {
+		version = args[0]
+	}
+
+	results, err := c.service.Verify(ctx, version)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		c.println("no local toolchains installed")
+		return nil
+	}
+
+	failed := 0
+	for _, result := range results {
+		if len(result.Problems) == 0 {
+			note := ""
+			if !result.HasManifest {
+				note = " (no install manifest; checked go version only)"
+			}
+			c.printf("%s: ok%s\n", result.Version, note)
+			continue
+		}
+
+		failed++
+		c.printf("%s: FAILED\n", result.Version)
+		for _, problem := range result.Problems {
+			c.printf("  - %s\n", problem)
+		}
+		c.printf("  reinstall: remove %s and run 'switcher install %s'\n", switcher.ToolchainDir(c.service.Paths, result.Version), result.Version)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d toolchains failed verification", failed, len(results))
+	}
+	return nil
+}
+
+func (c *CLI) runDoctor(args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("usage: switcher doctor")
+	}
+
+	problems := 0
+	for _, check := range c.service.Doctor(c.cwd) {
+		status := "ok"
+		if !check.OK {
+			status = "WARN"
+			problems++
+		}
+		c.printf("%-4s %s: %s\n", status, check.Name, check.Detail)
+	}
+	if problems > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", problems)
+	}
+	return nil
+}
+
+func (c *CLI) runTUI(ctx context.Context, args []string) error {
+	opts := tui.Options{ReadOnly: tui.ReadOnlyRequested()}
+	selectMode := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--read-only" {
+			opts.ReadOnly = true
+			continue
+		}
+		if arg == "--select" {
+			selectMode = true
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--mode", "--scope", "--search":
+		default:
+			return usageErrorf("unknown flag %q", arg)
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for %s", name)
+			}
+			value = args[i+1]
+			i++
+		}
+
+		switch name {
+		case "--mode":
+			switch value {
+			case "local":
+				opts.Remote = false
+			case "remote":
+				opts.Remote = true
+			default:
+				return usageErrorf("invalid mode %q (expected local or remote)", value)
+			}
+		case "--scope":
+			parsed, err := switcher.ParseScope(value)
+			if err != nil {
+				return asUsageError(err)
+			}
+			opts.Scope = parsed
+		case "--search":
+			opts.Search = value
+		}
+	}
+
+	if !selectMode {
+		return tui.RunWithOptions(ctx, tuiService{c.service}, c.cwd, opts)
+	}
+	version, err := tui.Select(ctx, tuiService{c.service}, c.cwd, opts)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		return &ExitError{Code: ExitCodeGeneric}
+	}
+	c.println(version)
+	return nil
+}
+
+// tuiService adapts Service to the TUI, which cannot import this package.
+type tuiService struct {
+	*Service
+}
+
+// PruneStorage removes cached archives that no installed toolchain needs and
+// broken toolchain directories, keeping every installed version.
+func (s tuiService) PruneStorage(ctx context.Context, cwd string, dryRun bool) (tui.PruneSummary, error) {
+	result, err := s.Prune(ctx, cwd, PruneOptions{KeepNewest: -1, KeepActive: true, CleanCache: true, RemoveBroken: true, DryRun: dryRun})
+	if err != nil {
+		return tui.PruneSummary{}, err
+	}
+	return tui.PruneSummary{
+		BrokenToolchains: len(result.BrokenToolchains),
+		CacheEntries:     len(result.CacheEntries),
+		BytesReclaimed:   result.BytesReclaimed,
+	}, nil
+}
+
+func (c *CLI) runConfig(ctx context.Context, args []string) error {
+	if len(args) != 1 || args[0] != "edit" {
+		return usageErrorf("usage: switcher config edit")
+	}
+	return c.runConfigEdit(ctx)
+}
+
+// runConfigEdit opens a temporary copy of the config in the user's editor
+// and only replaces the real file once the edited copy parses.
+func (c *CLI) runConfigEdit(ctx context.Context) error {
+	configFile := c.service.Paths.ConfigFile
+	original, err := os.ReadFile(configFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("read config %s: %w", configFile, err)
+		}
+		original = []byte("{}\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configFile), 0o755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(configFile), "config-edit-*.json")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpPath)
+	}()
+	if _, err := tmp.Write(original); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	for {
+		if err := c.runEditor(ctx, tmpPath); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("read edited config: %w", err)
+		}
+		if bytes.Equal(edited, original) {
+			c.println("config unchanged")
+			return nil
+		}
+
+		if _, parseErr := switcher.ParseConfig(edited); parseErr != nil {
+			_, _ = fmt.Fprintf(c.stderr, "invalid config: %v\n", parseErr)
+			_, _ = fmt.Fprint(c.stderr, "edit again? [y/N] ")
+			answer, _ := stdin.ReadString('\n')
+			if strings.EqualFold(strings.TrimSpace(answer), "y") {
+				continue
+			}
+			return fmt.Errorf("config %s left unchanged: %w", configFile, parseErr)
+		}
+
+		if err := switcher.WriteConfigData(c.service.Paths, edited); err != nil {
+			return err
+		}
+		c.printf("updated %s\n", configFile)
+		return nil
+	}
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, falling back to vi. The
+// variable may include arguments, e.g. "code --wait".
+func (c *CLI) runEditor(ctx context.Context, path string) error {
+	editor := strings.TrimSpace(os.Getenv("VISUAL"))
+	if editor == "" {
+		editor = strings.TrimSpace(os.Getenv("EDITOR"))
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	fields := strings.Fields(editor)
+	cmd := exec.CommandContext(ctx, fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = c.stdout
+	cmd.Stderr = c.stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor %q: %w", editor, err)
+	}
+	return nil
+}
+
+// isHistoryStep reports whether arg is `-` or `-N`, which select a previous
+// global version for `use`.
+func isHistoryStep(arg string) bool {
+	digits, ok := strings.CutPrefix(arg, "-")
+	if !ok || strings.Trim(digits, "0123456789") != "" {
+		return false
+	}
+	steps, err := strconv.Atoi(digits)
+	return digits == "" || err == nil && steps > 0
+}
+
+func (c *CLI) runHistory(args []string) error {
+	if len(args) == 1 && args[0] == "--global" {
+		return c.printGlobalHistory()
+	}
+
+	filter := HistoryFilter{Limit: 20}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--global":
+			return usageErrorf("--global cannot be combined with other history flags")
+		case "--scope", "--version", "--limit":
+		default:
+			return usageErrorf("unknown flag %q", arg)
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return usageErrorf("missing value for %s", name)
+			}
+			value = args[i+1]
+			i++
+		}
+
+		switch name {
+		case "--scope":
+			parsed, err := switcher.ParseScope(value)
+			if err != nil {
+				return asUsageError(err)
+			}
+			filter.Scope = parsed
+		case "--version":
+			filter.Version = value
+		case "--limit":
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 0 {
+				return usageErrorf("invalid --limit value %q (expected a non-negative number)", value)
+			}
+			filter.Limit = limit
+		}
+	}
+
+	entries, err := c.service.History(filter)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		c.println("no recorded history (enable with 'switcher use --record' or \"record_history\": true)")
+		return nil
+	}
+	for _, entry := range entries {
+		line := fmt.Sprintf("%s  %-10s %-6s %-6s %s", entry.Time.Local().Format(time.RFC3339), entry.Version, entry.Scope, entry.Outcome, entry.Cwd)
+		if entry.Error != "" {
+			line += "  (" + entry.Error + ")"
+		}
+		c.println(line)
+	}
+	return nil
+}
+
+func (c *CLI) runLink(ctx context.Context, args []string) error {
+	switch len(args) {
+	case 0:
+		links, err := c.service.ListLinked()
+		if err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			c.println("no linked toolchains")
+			return nil
+		}
+		for _, link := range links {
+			c.printf("%s -> %s\n", link.Name, link.Path)
+		}
+		return nil
+	case 2:
+		link, goVersion, err := c.service.Link(ctx, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		c.printf("linked %s -> %s (%s)\n", link.Name, link.Path, goVersion)
+		c.printf("switch to it with 'switcher use %s'\n", link.Name)
+		return nil
+	default:
+		return usageErrorf("usage: switcher link [<name> <goroot>]")
+	}
+}
+
+func (c *CLI) runUnlink(args []string) error {
+	if len(args) != 1 {
+		return usageErrorf("usage: switcher unlink <name>")
+	}
+	if err := c.service.Unlink(args[0]); err != nil {
+		return err
+	}
+	c.printf("unlinked %s\n", args[0])
+	return nil
+}
+
+func (c *CLI) printGlobalHistory() error {
+	history, err := c.service.GlobalHistory()
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		c.println("no previous global versions")
+		return nil
+	}
+	for i, version := range history {
+		c.printf("-%d  %s\n", i+1, version)
+	}
+	return nil
+}
+
+func (c *CLI) runPrune(ctx context.Context, args []string) error {
+	opts := PruneOptions{KeepNewest: -1, KeepActive: true}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--include-active":
+			opts.KeepActive = false
+		case arg == "--cache":
+			opts.CleanCache = true
+		case arg == "--toolchains":
+			opts.RemoveBroken = true
+		case arg == "--dry-run":
+			opts.DryRun = true
+		case strings.HasPrefix(arg, "--keep="), arg == "--keep":
+			value := strings.TrimPrefix(arg, "--keep=")
+			if arg == "--keep" {
+				if i+1 >= len(args) {
+					return usageErrorf("missing value for --keep")
+				}
+				value = args[i+1]
+				i++
+			}
+			keep, err := strconv.Atoi(value)
+			if err != nil || keep < 0 {
+				return usageErrorf("invalid --keep value %q (expected a non-negative number)", value)
+			}
+			opts.KeepNewest = keep
+		default:
+			return usageErrorf("unknown flag %q", arg)
+		}
+	}
+	if opts.KeepNewest < 0 && !opts.CleanCache && !opts.RemoveBroken {
+		return usageErrorf("usage: switcher prune [--keep <n>] [--include-active] [--cache] [--toolchains] [--dry-run]")
+	}
+
+	result, err := c.service.Prune(ctx, c.cwd, opts)
+	if err != nil {
+		return err
+	}
+
+	verb := "removed"
+	if result.DryRun {
+		verb = "would remove"
+	}
+	for _, version := range result.Toolchains {
+		c.printf("%s %s\n", verb, version)
+	}
+	for _, entry := range result.BrokenToolchains {
+		c.printf("%s broken toolchain %s (%s)\n", verb, entry.Path, entry.Reason)
+	}
+	for _, name := range result.CacheEntries {
+		c.printf("%s cache entry %s\n", verb, name)
+	}
+	if len(result.Toolchains) == 0 && len(result.BrokenToolchains) == 0 && len(result.CacheEntries) == 0 {
+		c.println("nothing to prune")
+		return nil
+	}
+	if result.DryRun {
+		c.printf("would reclaim %s\n", progress.FormatBytes(result.BytesReclaimed))
+	} else {
+		c.printf("reclaimed %s\n", progress.FormatBytes(result.BytesReclaimed))
+	}
+	return nil
+}
+
+func (c *CLI) runGC(args []string) error {
+	dryRun := false
+	for _, arg := range args {
+		switch arg {
+		case "--dry-run":
+			dryRun = true
+		default:
+			return usageErrorf("usage: switcher gc [--dry-run]")
+		}
+	}
+
+	result, err := c.service.GarbageCollectTools(dryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(result.LintVersions) == 0 {
+		c.println("no unreferenced golangci-lint binaries")
+		return nil
+	}
+	verb := "removed"
+	if result.DryRun {
+		verb = "would remove"
+	}
+	for _, version := range result.LintVersions {
+		c.printf("%s golangci-lint %s\n", verb, version)
+	}
+	if result.DryRun {
+		c.printf("would reclaim %s\n", progress.FormatBytes(result.BytesReclaimed))
+	} else {
+		c.printf("reclaimed %s\n", progress.FormatBytes(result.BytesReclaimed))
+	}
+	return nil
+}
+
+func (c *CLI) runUninstall(ctx context.Context, args []string) error {
+	version := ""
+	allExceptActive := false
+	yes := false
+	force := false
+	for _, arg := range args {
+		switch {
+		case arg == "--all-except-active":
+			allExceptActive = true
+		case arg == "--yes" || arg == "-y":
+			yes = true
+		case arg == "--force":
+			force = true
+		case strings.HasPrefix(arg, "-"):
+			return usageErrorf("unknown flag %q", arg)
+		default:
+			if version != "" {
+				return usageErrorf("multiple versions provided")
+			}
+			version = arg
+		}
+	}
+	if (version == "") == !allExceptActive {
+		return usageErrorf("usage: switcher uninstall|remove <go-version>|--all-except-active [--yes] [--force]")
+	}
+	if version != "" {
+		if force {
+			return usageErrorf("--force only applies to --all-except-active")
+		}
+		if !yes && !c.confirm(fmt.Sprintf("uninstall %s?", version)) {
+			return fmt.Errorf("uninstall cancelled")
+		}
+		result, err := c.service.DeleteInstalledWithProgress(ctx, c.cwd, version, nil)
+		if err != nil {
+			return err
+		}
+		c.printDeleteResult(result)
+		return nil
+	}
+
+	_, err := c.service.Current(c.cwd)
+	switch {
+	case err == switcher.ErrNoActiveVersion:
+		if !force {
+			return fmt.Errorf("%w; pass --force to uninstall every installed toolchain", err)
+		}
+	case err != nil:
+		return err
+	}
+
+	opts := PruneOptions{KeepNewest: 0, KeepActive: true, DryRun: true}
+	planned, err := c.service.Prune(ctx, c.cwd, opts)
+	if err != nil {
+		return err
+	}
+	if len(planned.Toolchains) == 0 {
+		c.println("nothing to uninstall")
+		return nil
+	}
+	kept, err := c.service.ActiveVersions(c.cwd)
+	if err != nil {
+		return err
+	}
+	if !yes && !c.confirm(fmt.Sprintf("uninstall %s, keeping %s?", strings.Join(planned.Toolchains, ", "), keptVersions(kept))) {
+		return fmt.Errorf("uninstall cancelled")
+	}
+
+	opts.DryRun = false
+	result, err := c.service.Prune(ctx, c.cwd, opts)
+	if err != nil {
+		return err
+	}
+	for _, version := range result.Toolchains {
+		c.printf("uninstalled %s\n", version)
+	}
+	c.printf("reclaimed %s\n", progress.FormatBytes(result.BytesReclaimed))
+	return nil
+}
+
+func (c *CLI) printDeleteResult(result switcher.DeleteResult) {
+	c.printf("uninstalled %s\n", result.DeletedVersion)
+	switch {
+	case result.WasActive && result.SwitchedToNewest && result.ActiveAfter.Version != "":
+		c.printf("it was active; switched to %s (%s)\n", result.ActiveAfter.Version, result.ActiveAfter.Scope)
+	case result.WasActive && result.ActiveAfter.Version == "":
+		c.println("it was active; no installed versions remain")
+	}
+	if result.ToolSyncWarning != "" {
+		c.warnf("tool sync: %s\n", result.ToolSyncWarning)
+	}
+}
+
+func keptVersions(versions []string) string {
+	if len(versions) == 0 {
+		return "nothing"
+	}
+	return strings.Join(versions, " and ")
+}
+
+// confirm asks question on stderr and reports whether stdin answered yes.
+func (c *CLI) confirm(question string) bool {
+	_, _ = fmt.Fprintf(c.stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes")
+}
+
+// printPathHint prints the command that puts the shims on PATH when they are
+// not on it yet.
+func (c *CLI) printPathHint() {
+	hint, inPath, err := c.service.PathHint()
+	if err == nil && !inPath {
+		c.printf("add %s to PATH to use shims:\n  %s\n", c.service.Paths.BinDir, hint)
+	}
+}
+
+func (c *CLI) runSelfCheck() error {
+	problems := switcher.CheckShims(c.service.Paths)
+	// The marker only says the shims were bootstrapped; a partially deleted
+	// toolchain would still fail every shim call.
+	active, err := c.service.Current(c.cwd)
+	switch {
+	case err == nil:
+		if _, err := switcher.GoToolBinary(c.service.Paths, active.Version, "go"); err != nil {
+			problems = append(problems, fmt.Sprintf("active toolchain is incomplete: %v; reinstall it with 'switcher install %s'", err, active.Version))
+		}
+	case err != switcher.ErrNoActiveVersion:
+		problems = append(problems, fmt.Sprintf("resolve active version: %v", err))
+	}
+	for _, problem := range problems {
+		c.printf("problem: %s\n", problem)
+	}
+
+	if hint, inPath, err := c.service.PathHint(); err == nil && !inPath {
+		c.printf("note: %s is not on PATH; add it with:\n  %s\n", c.service.Paths.BinDir, hint)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("self-check found %d problem(s)", len(problems))
+	}
+	c.printf("shims in %s are ok\n", c.service.Paths.BinDir)
+	return nil
+}
+
+func (c *CLI) printUsage() {
+	usage := `switcher - Go toolchain switcher
+
+Usage:
+  switcher [--strict-config] [--json] <command> ...
+  switcher current [--exit-code] [--quiet]
+  switcher list [--remote [--refresh]] [--json] [--long|-l] [--latest-per-minor] [--minor <major.minor>]
+  switcher install [<go-version>]|--channel stable|rc|tip [--platform os/arch,...] [--variant GOARM=7] [--no-cache] [--verify-only|--check-only] [--sha256 <hex>|--checksums <url|path>] [--insecure-skip-verify] [--go-telemetry off|local|on] [--notify] [--quiet]
+  switcher use [<go-version>]|-[N]|--channel stable|rc|tip|--latest|--latest-stable [--scope global|local] [--reresolve-tools] [--write-gitignore] [--promote-local] [--set-goroot] [--record] [--no-hook] [--lint <version>|recommended] [--if-unset] [--alias] [--strict] [--notify] [--quiet]
+  switcher history [--scope global|local] [--version <go-version>] [--limit <n>]
+  switcher history --global
+  switcher tools sync [--scope global|local] [--all-installed [--dry-run]]
+  switcher tools pin <lint-version>|recommended [--go <go-version>]
+  switcher export [--output <file>]
+  switcher import <file> [--dry-run]
+  switcher verify [go-version]
+  switcher doctor
+  switcher link [<name> <goroot>]
+  switcher unlink <name>
+  switcher config edit
+  switcher prune [--keep <n>] [--include-active] [--cache] [--toolchains] [--dry-run]
+  switcher gc [--dry-run]
+  switcher uninstall|remove <go-version>|--all-except-active [--yes] [--force]
+  switcher exec --self-check
+  switcher tui [--mode local|remote] [--scope global|local] [--search <query>] [--read-only] [--select]
+
+Notes:
+  - local scope uses .switcher-version in the working tree
+  - local scope overrides global scope when both are set
+  - add ~/.switcher/bin to PATH to use go/gofmt/golangci-lint shims
+  - a corrupt config.json is backed up and replaced by defaults unless
+    --strict-config is given
+  - --json prints current, list and use results as JSON on stdout
+`
+	c.println(usage)
+}
+
+func (c *CLI) println(line string) {
+	_, _ = fmt.Fprintln(c.stdout, line)
+}
+
+func (c *CLI) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(c.stdout, format, args...)
+}
+
+// statusf prints a line that accompanies a command's result. With --json it
+// goes to stderr, so stdout stays a single JSON document.
+func (c *CLI) statusf(format string, args ...any) {
+	out := c.stdout
+	if c.jsonOutput {
+		out = c.stderr
+	}
+	_, _ = fmt.Fprintf(out, format, args...)
+}
+
+// notifyCompletion shows a desktop notification for a finished long-running
+// command when enabled. A notifier failure is only a warning.
+func (c *CLI) notifyCompletion(ctx context.Context, enabled bool, success string, action string, err error) {
+	if !enabled {
+		return
+	}
+	message := success
+	if err != nil {
+		message = fmt.Sprintf("%s failed: %v", action, err)
+	}
+	if notifyErr := notify.Send(context.WithoutCancel(ctx), "switcher", message); notifyErr != nil {
+		c.warnf("could not send desktop notification: %v\n", notifyErr)
+	}
+}
+
+func (c *CLI) warnf(format string, args ...any) {
+	_, _ = fmt.Fprintf(c.stderr, "warning: "+format, args...)
 }
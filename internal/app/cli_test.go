@@ -0,0 +1,952 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
+	"github.com/mrtuuro/go-switcher/internal/install"
+	"github.com/mrtuuro/go-switcher/internal/releases"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/tools"
+)
+
+func TestRunList_JSONIncludesStructuredFields(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	mustWriteToolchain(t, paths, "go1.25.1")
+	mustWriteToolchain(t, paths, "go1.26rc1")
+	if err := switcher.WriteConfig(paths, switcher.Config{GlobalVersion: "go1.24.2"}); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cli, stdout := testCLI(paths, projectDir)
+	if err := cli.Run(context.Background(), []string{"list", "--json"}); err != nil {
+		t.Fatalf("list --json: %v", err)
+	}
+
+	var entries []listEntry
+	if err := json.Unmarshal(stdout.Bytes(), &entries); err != nil {
+		t.Fatalf("unmarshal output %q: %v", stdout.String(), err)
+	}
+
+	want := []listEntry{
+		{Version: "go1.26rc1", Major: 1, Minor: 26, Prerelease: "rc1"},
+		{Version: "go1.25.1", Major: 1, Minor: 25, Patch: 1},
+		{Version: "go1.24.2", Major: 1, Minor: 24, Patch: 2, Active: true},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(entries))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, want[i], entries[i])
+		}
+	}
+}
+
+func TestRunList_RemoteJSONPrintsVersions(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	// A broken pin fails active version resolution, which the remote list
+	// does not need.
+	if err := os.WriteFile(filepath.Join(projectDir, switcher.LocalVersionFile), []byte("not-a-version\n"), 0o644); err != nil {
+		t.Fatalf("write pin: %v", err)
+	}
+	cli, stdout := testCLI(paths, projectDir)
+	cli.service.ReleaseClient = &fakeFetcher{releases: []releases.Release{
+		{Version: "go1.25.0", Files: []releases.File{hostArchive("go1.25.0")}},
+		{Version: "go1.24.2", Files: []releases.File{hostArchive("go1.24.2")}},
+	}}
+	if err := cli.Run(context.Background(), []string{"list", "--json"}); err == nil {
+		t.Fatalf("expected the broken pin to fail the local list")
+	}
+
+	stdout.Reset()
+	if err := cli.Run(context.Background(), []string{"list", "--remote", "--json"}); err != nil {
+		t.Fatalf("list --remote --json: %v", err)
+	}
+	var versions []string
+	if err := json.Unmarshal(stdout.Bytes(), &versions); err != nil {
+		t.Fatalf("unmarshal output %q: %v", stdout.String(), err)
+	}
+	if !slices.Equal(versions, []string{"go1.25.0", "go1.24.2"}) {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+}
+
+func TestRunList_LongShowsAlignedDetails(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	mustWriteToolchain(t, paths, "go1.25.1")
+	if err := os.WriteFile(filepath.Join(switcher.ToolchainDir(paths, "go1.25.1"), "VERSION"), make([]byte, 2048), 0o644); err != nil {
+		t.Fatalf("write VERSION: %v", err)
+	}
+	cfg := switcher.Config{
+		GlobalVersion:    "go1.24.2",
+		GolangCILintByGo: map[string]string{"go1.24.2": "v1.64.8", "go1.25.1": "v2.9.0"},
+	}
+	if err := switcher.WriteConfig(paths, cfg); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cli, stdout := testCLI(paths, projectDir)
+	if err := cli.Run(context.Background(), []string{"list", "-l"}); err != nil {
+		t.Fatalf("list -l: %v", err)
+	}
+
+	want := "" +
+		"  VERSION   SCOPE   GOLANGCI-LINT  SIZE\n" +
+		"  go1.25.1  -       v2.9.0         2.00 KB\n" +
+		"* go1.24.2  global  v1.64.8        0 B\n"
+	if stdout.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", stdout.String(), want)
+	}
+
+	err := cli.Run(context.Background(), []string{"list", "--long", "--json"})
+	var usageErr *UsageError
+	if !errors.As(err, &usageErr) {
+		t.Fatalf("expected usage error for --long --json, got %v", err)
+	}
+}
+
+func TestLatestPerMinor(t *testing.T) {
+	t.Parallel()
+
+	got := latestPerMinor([]string{"go1.25.1", "go1.25.0", "go1.24.3", "go1.24.10", "go1.23.0", "bogus", "go1.26rc1"})
+	want := []string{"go1.25.1", "go1.24.10", "go1.23.0", "go1.26rc1"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestIsHistoryStep(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"-":        true,
+		"-1":       true,
+		"-12":      true,
+		"-0":       false,
+		"-+1":      false,
+		"-q":       false,
+		"--record": false,
+		"go1.24.2": false,
+	}
+	for arg, want := range tests {
+		if got := isHistoryStep(arg); got != want {
+			t.Fatalf("isHistoryStep(%q) = %v, want %v", arg, got, want)
+		}
+	}
+}
+
+func TestRunInstall_CheckOnly(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	available := hostArchive("go1.25.0")
+	available.Size = 3 << 20
+	fetcher := &fakeFetcher{releases: []releases.Release{
+		{Version: "go1.25.0", Files: []releases.File{available}},
+		{Version: "go1.23.0"},
+	}}
+
+	tests := []struct {
+		version  string
+		wantCode int
+		want     string
+	}{
+		{version: "1.24.2", wantCode: 0, want: "go1.24.2: already installed"},
+		{version: "1.25.0", wantCode: ExitCodeDownloadNeeded, want: "go1.25.0: available (will download 3.00 MB)"},
+		{version: "1.23.0", wantCode: ExitCodeNotInstalled, want: "go1.23.0: not available for " + runtime.GOOS + "/" + runtime.GOARCH},
+	}
+	for _, tc := range tests {
+		cli, stdout := testCLI(paths, projectDir)
+		cli.service.ReleaseClient = fetcher
+		err := cli.Run(context.Background(), []string{"install", "--check-only", tc.version})
+		if got := ExitCode(err); got != tc.wantCode {
+			t.Fatalf("%s: expected exit code %d, got %d (%v)", tc.version, tc.wantCode, got, err)
+		}
+		if !strings.HasPrefix(stdout.String(), tc.want) {
+			t.Fatalf("%s: expected output starting with %q, got %q", tc.version, tc.want, stdout.String())
+		}
+	}
+	if fetcher.calls != 2 {
+		t.Fatalf("expected the installed version to be checked without fetching, got %d fetches", fetcher.calls)
+	}
+	if _, err := os.Stat(switcher.ToolchainDir(paths, "go1.25.0")); !os.IsNotExist(err) {
+		t.Fatalf("expected --check-only not to install anything")
+	}
+}
+
+func testCLI(paths switcher.Paths, cwd string) (*CLI, *bytes.Buffer) {
+	stdout := &bytes.Buffer{}
+	return &CLI{
+		stdout:  stdout,
+		stderr:  &bytes.Buffer{},
+		cwd:     cwd,
+		service: &Service{Paths: paths},
+	}, stdout
+}
+
+func TestRunCurrent_ExitCode(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	cli, stdout := testCLI(paths, projectDir)
+
+	err := cli.Run(context.Background(), []string{"current", "--exit-code", "--quiet"})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitCodeNoActiveVersion {
+		t.Fatalf("expected exit code %d, got %v", ExitCodeNoActiveVersion, err)
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected quiet output, got %q", stdout.String())
+	}
+
+	if err := cli.Run(context.Background(), []string{"current"}); err != nil {
+		t.Fatalf("expected plain current to succeed without a version, got %v", err)
+	}
+
+	mustWriteToolchain(t, paths, "go1.25.0")
+	if err := switcher.SetGlobalVersion(paths, "go1.25.0"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+	stdout.Reset()
+	if err := cli.Run(context.Background(), []string{"current"}); err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if want := "source: global config at " + paths.ConfigFile; !strings.Contains(stdout.String(), want) {
+		t.Fatalf("expected %q in output, got %q", want, stdout.String())
+	}
+}
+
+func TestRun_StrictConfigAppliesToOneRun(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	if err := os.WriteFile(paths.ConfigFile, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cli, _ := testCLI(paths, projectDir)
+	stderr := cli.stderr.(*bytes.Buffer)
+
+	if err := cli.Run(context.Background(), []string{"--strict-config", "current"}); err == nil {
+		t.Fatalf("expected --strict-config to fail on a corrupt config")
+	}
+	if _, err := os.Stat(paths.ConfigFile); err != nil {
+		t.Fatalf("expected strict mode to leave the config in place, got %v", err)
+	}
+
+	if err := cli.Run(context.Background(), []string{"current"}); err != nil {
+		t.Fatalf("expected the next run to recover, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "warning: config "+paths.ConfigFile+" is corrupt") {
+		t.Fatalf("expected a corrupt config warning, got %q", stderr.String())
+	}
+}
+
+// newHomeCLI builds a CLI the way main does, through NewCLI, with the
+// switcher home pointed at a temp dir. prepare runs before the CLI sets up
+// its service, so it can leave state for startup to find.
+func newHomeCLI(t *testing.T, prepare func(paths switcher.Paths)) (*CLI, switcher.Paths, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	tmp := t.TempDir()
+	paths := switcher.PathsForBase(filepath.Join(tmp, ".switcher"))
+	t.Setenv(switcher.HomeEnv, paths.BaseDir)
+	if err := switcher.EnsureLayout(paths); err != nil {
+		t.Fatalf("ensure layout: %v", err)
+	}
+	if prepare != nil {
+		prepare(paths)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	return NewCLI(stdout, stderr, tmp), paths, stdout, stderr
+}
+
+func TestNewCLI_StrictConfigFailsOnCorruptConfig(t *testing.T) {
+	cli, paths, _, stderr := newHomeCLI(t, func(paths switcher.Paths) {
+		if err := os.WriteFile(paths.ConfigFile, []byte("{bad"), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	})
+
+	err := cli.Run(context.Background(), []string{"--strict-config", "list"})
+	if err == nil || ExitCode(err) == 0 {
+		t.Fatalf("expected --strict-config list to fail with a non-zero exit code, got %v", err)
+	}
+	raw, readErr := os.ReadFile(paths.ConfigFile)
+	if readErr != nil || string(raw) != "{bad" {
+		t.Fatalf("expected the corrupt config to be left in place, got %q (%v)", raw, readErr)
+	}
+	if backups, _ := filepath.Glob(paths.ConfigFile + ".corrupt-*"); len(backups) != 0 {
+		t.Fatalf("expected no backup in strict mode, got %v", backups)
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("expected no warnings in strict mode, got %q", stderr.String())
+	}
+}
+
+func TestNewCLI_WarnsAndBacksUpCorruptConfig(t *testing.T) {
+	cli, paths, _, stderr := newHomeCLI(t, func(paths switcher.Paths) {
+		if err := os.WriteFile(paths.ConfigFile, []byte("{bad"), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	})
+
+	if err := cli.Run(context.Background(), []string{"list"}); err != nil {
+		t.Fatalf("expected list to recover from a corrupt config, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "warning: config "+paths.ConfigFile+" is corrupt") {
+		t.Fatalf("expected a corrupt config warning, got %q", stderr.String())
+	}
+	backups, _ := filepath.Glob(paths.ConfigFile + ".corrupt-*")
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup of the corrupt config, got %v", backups)
+	}
+	if raw, err := os.ReadFile(backups[0]); err != nil || string(raw) != "{bad" {
+		t.Fatalf("expected the backup to keep the corrupt contents, got %q (%v)", raw, err)
+	}
+	if _, err := os.Stat(paths.ConfigFile); !os.IsNotExist(err) {
+		t.Fatalf("expected the corrupt config to be moved aside, got %v", err)
+	}
+}
+
+func TestNewCLI_DoctorReportsInterruptedExtractionsAndInstallRecovers(t *testing.T) {
+	var tmpDir string
+	cli, _, stdout, stderr := newHomeCLI(t, func(paths switcher.Paths) {
+		tmpDir = filepath.Join(paths.ToolchainsDir, ".tmp-toolchain-killed")
+		if err := os.MkdirAll(filepath.Join(tmpDir, "src"), 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		stale := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(tmpDir, stale, stale); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	})
+
+	_ = cli.Run(context.Background(), []string{"doctor"})
+	if !strings.Contains(stdout.String(), "WARN extractions: interrupted toolchain extractions in "+tmpDir) {
+		t.Fatalf("expected doctor to list the interrupted extraction, got %q", stdout.String())
+	}
+	if _, err := os.Stat(tmpDir); err != nil {
+		t.Fatalf("expected doctor to leave the extraction alone: %v", err)
+	}
+
+	_ = cli.Run(context.Background(), []string{"install"})
+	if !strings.Contains(stderr.String(), "removed incomplete extraction "+tmpDir) {
+		t.Fatalf("expected install to report the removal, got %q", stderr.String())
+	}
+	if _, err := os.Stat(tmpDir); !os.IsNotExist(err) {
+		t.Fatalf("expected install to remove the extraction, got %v", err)
+	}
+}
+
+func TestNewCLI_ExecWorksWithReadOnlyCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no permission bits or sh scripts on windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+
+	cli, paths, _, _ := newHomeCLI(t, func(paths switcher.Paths) {
+		mustWriteGoScript(t, paths, "go1.24.2", "exit 0")
+		if err := switcher.SetGlobalVersion(paths, "go1.24.2"); err != nil {
+			t.Fatalf("set global version: %v", err)
+		}
+		if err := os.Chmod(paths.CacheDir, 0o555); err != nil {
+			t.Fatalf("chmod: %v", err)
+		}
+		t.Cleanup(func() { _ = os.Chmod(paths.CacheDir, 0o755) })
+	})
+
+	if err := cli.Run(context.Background(), []string{"exec", "go", "version"}); err != nil {
+		t.Fatalf("expected exec to work with a read-only cache dir, got %v", err)
+	}
+	err := cli.Run(context.Background(), []string{"install", "go1.25.0"})
+	if err == nil || !strings.Contains(err.Error(), paths.CacheDir+" is not writable") {
+		t.Fatalf("expected install to fail naming the read-only cache dir, got %v", err)
+	}
+}
+
+// mustWriteGoScript installs a toolchain whose bin/go is a shell script
+// running body.
+func mustWriteGoScript(t testing.TB, paths switcher.Paths, version string, body string) {
+	t.Helper()
+	binary := switcher.GoBinaryPath(switcher.ToolchainDir(paths, version))
+	if err := os.MkdirAll(filepath.Dir(binary), 0o755); err != nil {
+		t.Fatalf("create toolchain bin dir: %v", err)
+	}
+	script := "#!/bin/sh\n" + body + "\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatalf("write go script: %v", err)
+	}
+}
+
+// BenchmarkExec compares running go through `switcher exec`, from NewCLI
+// as a shim does, with running the same binary directly, from a nested
+// package without a pin. The difference is the shim overhead.
+func BenchmarkExec(b *testing.B) {
+	if runtime.GOOS == "windows" {
+		b.Skip("the fake go binary is a sh script")
+	}
+	tmp := b.TempDir()
+	paths := switcher.PathsForBase(filepath.Join(tmp, ".switcher"))
+	b.Setenv(switcher.HomeEnv, paths.BaseDir)
+	mustWriteGoScript(b, paths, "go1.25.0", "exit 0")
+	if err := switcher.SetGlobalVersion(paths, "go1.25.0"); err != nil {
+		b.Fatalf("set global version: %v", err)
+	}
+	cwd := filepath.Join(tmp, "src", "github.com", "example", "project", "internal", "pkg")
+	if err := os.MkdirAll(cwd, 0o755); err != nil {
+		b.Fatalf("MkdirAll: %v", err)
+	}
+
+	b.Run("direct", func(b *testing.B) {
+		binary := switcher.GoBinaryPath(switcher.ToolchainDir(paths, "go1.25.0"))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := exec.Command(binary, "version").Run(); err != nil {
+				b.Fatalf("run go: %v", err)
+			}
+		}
+	})
+	b.Run("shim", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cli := NewCLI(io.Discard, io.Discard, cwd)
+			if err := cli.Run(context.Background(), []string{"exec", "go", "version"}); err != nil {
+				b.Fatalf("exec: %v", err)
+			}
+		}
+	})
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	cli, _ := testCLI(paths, projectDir)
+	run := func(args ...string) error {
+		return cli.Run(context.Background(), args)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: 0},
+		{name: "explicit", err: &ExitError{Code: 7}, want: 7},
+		{name: "unknown command", err: run("frobnicate"), want: ExitCodeUsage},
+		{name: "unknown flag", err: run("install", "1.25.0", "--bogus"), want: ExitCodeUsage},
+		{name: "invalid scope", err: run("use", "1.25.0", "--scope", "galaxy"), want: ExitCodeUsage},
+		{name: "refresh without remote", err: run("list", "--refresh"), want: ExitCodeUsage},
+		{name: "json unsupported", err: run("--json", "doctor"), want: ExitCodeUsage},
+		{name: "no active version", err: run("exec", "go", "version"), want: ExitCodeNoActiveVersion},
+		{name: "not installed", err: run("verify", "1.25.0"), want: ExitCodeNotInstalled},
+		{name: "release not found", err: fmt.Errorf("install: %w", releases.ErrNotFound), want: ExitCodeNotInstalled},
+		{name: "network", err: fmt.Errorf("fetch releases: %w", &url.Error{Op: "Get", URL: "https://go.dev/dl", Err: errors.New("connection refused")}), want: ExitCodeNetwork},
+		{name: "http status", err: fmt.Errorf("download: %w", &httpclient.StatusError{StatusCode: 503}), want: ExitCodeNetwork},
+		{name: "checksum", err: fmt.Errorf("install: %w", install.ErrChecksumMismatch), want: ExitCodeChecksum},
+		{name: "generic", err: errors.New("disk full"), want: ExitCodeGeneric},
+	}
+	for _, tc := range tests {
+		if got := ExitCode(tc.err); got != tc.want {
+			t.Fatalf("%s: ExitCode(%v) = %d, want %d", tc.name, tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRunTUI_RejectsInvalidFlags(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	cli, _ := testCLI(paths, projectDir)
+
+	tests := [][]string{
+		{"tui", "--mode", "everything"},
+		{"tui", "--scope=project"},
+		{"tui", "--search"},
+		{"tui", "--verbose"},
+	}
+	for _, args := range tests {
+		if err := cli.Run(context.Background(), args); err == nil {
+			t.Fatalf("%v: expected error", args)
+		}
+	}
+}
+
+func TestRunConfigEdit_ValidatesBeforeReplacing(t *testing.T) {
+	paths, projectDir := testPaths(t)
+	if err := switcher.WriteConfig(paths, switcher.Config{GlobalVersion: "go1.24.2"}); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	before, err := os.ReadFile(paths.ConfigFile)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	writeEditor := func(content string) {
+		script := "#!/bin/sh\nprintf '%s' '" + content + "' > \"$1\"\n"
+		if err := os.WriteFile(editor, []byte(script), 0o755); err != nil {
+			t.Fatalf("write editor: %v", err)
+		}
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", editor)
+
+	writeEditor(`{"global_version": `)
+	cli, _ := testCLI(paths, projectDir)
+	if err := cli.Run(context.Background(), []string{"config", "edit"}); err == nil {
+		t.Fatalf("expected invalid edit to be rejected")
+	}
+	after, err := os.ReadFile(paths.ConfigFile)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if !bytes.Equal(after, before) {
+		t.Fatalf("expected config to be left unchanged, got %q", string(after))
+	}
+
+	writeEditor(`{"global_version": "go1.25.0"}`)
+	if err := cli.Run(context.Background(), []string{"config", "edit"}); err != nil {
+		t.Fatalf("config edit: %v", err)
+	}
+	cfg, err := switcher.ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if cfg.GlobalVersion != "go1.25.0" {
+		t.Fatalf("expected edited global version, got %q", cfg.GlobalVersion)
+	}
+	entries, err := os.ReadDir(filepath.Dir(paths.ConfigFile))
+	if err != nil {
+		t.Fatalf("read config dir: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "config-edit-") {
+			t.Fatalf("expected temp copy %s to be removed", entry.Name())
+		}
+	}
+}
+
+func TestRunUninstall_AllExceptActiveKeepsGlobal(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	cli, stdout := testCLI(paths, projectDir)
+	for _, version := range []string{"go1.23.4", "go1.24.2", "go1.25.0"} {
+		mustWriteToolchain(t, paths, version)
+	}
+	if err := switcher.SetGlobalVersion(paths, "go1.23.4"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+	if err := switcher.SetLocalVersionAtPath(filepath.Join(projectDir, switcher.LocalVersionFile), "go1.24.2"); err != nil {
+		t.Fatalf("set local version: %v", err)
+	}
+
+	if err := cli.Run(context.Background(), []string{"uninstall", "--all-except-active", "--yes"}); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "uninstalled go1.25.0\n") {
+		t.Fatalf("expected go1.25.0 to be uninstalled, got %q", stdout.String())
+	}
+	installed, err := switcher.ListInstalledVersions(paths)
+	if err != nil {
+		t.Fatalf("list installed: %v", err)
+	}
+	if !slices.Equal(installed, []string{"go1.24.2", "go1.23.4"}) {
+		t.Fatalf("expected the local and global versions to remain, got %v", installed)
+	}
+}
+
+func TestRunUninstall_AllExceptActive(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	cli, stdout := testCLI(paths, projectDir)
+	for _, version := range []string{"go1.23.4", "go1.24.2", "go1.25.0"} {
+		mustWriteToolchain(t, paths, version)
+	}
+	if err := switcher.WriteConfig(paths, switcher.Config{GolangCILintByGo: map[string]string{"go1.23.4": "v1.64.8", "go1.24.2": "v1.64.8"}}); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	err := cli.Run(context.Background(), []string{"uninstall", "--all-except-active", "--yes"})
+	if ExitCode(err) != ExitCodeNoActiveVersion || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected no active version error mentioning --force, got %v", err)
+	}
+	if err := cli.Run(context.Background(), []string{"uninstall", "go1.25.0", "--force"}); ExitCode(err) != ExitCodeUsage {
+		t.Fatalf("expected usage error for --force with a version, got %v", err)
+	}
+
+	if err := switcher.SetGlobalVersion(paths, "go1.24.2"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+	if err := cli.Run(context.Background(), []string{"uninstall", "--all-except-active", "--yes"}); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	for _, want := range []string{"uninstalled go1.25.0\n", "uninstalled go1.23.4\n", "reclaimed "} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("expected %q in output, got %q", want, stdout.String())
+		}
+	}
+
+	installed, err := switcher.ListInstalledVersions(paths)
+	if err != nil {
+		t.Fatalf("list installed: %v", err)
+	}
+	if len(installed) != 1 || installed[0] != "go1.24.2" {
+		t.Fatalf("expected only go1.24.2 to remain, got %v", installed)
+	}
+	cfg, err := switcher.ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if _, ok := cfg.GolangCILintByGo["go1.23.4"]; ok || cfg.GolangCILintByGo["go1.24.2"] != "v1.64.8" {
+		t.Fatalf("expected only the go1.23.4 lint mapping to be pruned, got %v", cfg.GolangCILintByGo)
+	}
+}
+
+func TestInvalidDefaultScopeFallsBackToGlobal(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	raw := `{"global_version": "go1.24.2", "remember_scope": true, "default_scope": "project"}`
+	if err := os.WriteFile(paths.ConfigFile, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cli, stdout := testCLI(paths, projectDir)
+	if err := cli.Run(context.Background(), []string{"current"}); err != nil {
+		t.Fatalf("current with invalid default_scope: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "go1.24.2") {
+		t.Fatalf("expected active version in output, got %q", stdout.String())
+	}
+
+	scope, ok, err := cli.service.PreferredScope()
+	if err != nil || !ok || scope != switcher.ScopeGlobal {
+		t.Fatalf("expected global preferred scope, got %q %v %v", scope, ok, err)
+	}
+}
+
+func TestRunInstall_ProjectVersion(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	cli, _ := testCLI(paths, projectDir)
+	err := cli.Run(context.Background(), []string{"install"})
+	if err == nil || !strings.Contains(err.Error(), "no go version given") {
+		t.Fatalf("expected guidance without a project version, got %v", err)
+	}
+
+	mustWriteToolchain(t, paths, "go1.24.2")
+	goMod := "module example.com/project\n\ngo 1.24\n\ntoolchain go1.24.2\n"
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte(goMod), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+
+	cli, stdout := testCLI(paths, projectDir)
+	if err := cli.Run(context.Background(), []string{"install"}); err != nil {
+		t.Fatalf("install without a version: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "go1.24.2 already installed") {
+		t.Fatalf("expected the toolchain directive to be installed, got %q", stdout.String())
+	}
+}
+
+func TestRunUse_Latest(t *testing.T) {
+	t.Parallel()
+
+	fetched := []releases.Release{
+		{Version: "go1.26rc1", Files: []releases.File{hostArchive("go1.26rc1")}},
+		{Version: "go1.25.1", Stable: true, Files: []releases.File{hostArchive("go1.25.1")}},
+		{Version: "go1.25.0", Stable: true, Files: []releases.File{hostArchive("go1.25.0")}},
+	}
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{flag: "--latest", want: "go1.26rc1"},
+		{flag: "--latest-stable", want: "go1.25.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			t.Parallel()
+
+			paths, projectDir := testPaths(t)
+			for _, version := range []string{"go1.26rc1", "go1.25.1"} {
+				mustWriteToolchain(t, paths, version)
+				mustWriteLintBinary(t, paths, tools.RecommendedGolangCILint(version))
+			}
+			cli, stdout := testCLI(paths, projectDir)
+			cli.service.ReleaseClient = &fakeFetcher{releases: fetched}
+
+			if err := cli.Run(context.Background(), []string{"use", tt.flag, "--quiet"}); err != nil {
+				t.Fatalf("use %s: %v", tt.flag, err)
+			}
+			if !strings.HasPrefix(stdout.String(), tt.flag+" resolves to "+tt.want+"\n") {
+				t.Fatalf("expected %s to resolve to %s, got %q", tt.flag, tt.want, stdout.String())
+			}
+			global, _, err := switcher.GlobalVersion(paths)
+			if err != nil || global != tt.want {
+				t.Fatalf("expected global %s, got %q (%v)", tt.want, global, err)
+			}
+		})
+	}
+
+	paths, projectDir := testPaths(t)
+	cli, _ := testCLI(paths, projectDir)
+	cli.service.ReleaseClient = &fakeFetcher{releases: fetched}
+	for _, args := range [][]string{
+		{"use", "1.25.0", "--latest"},
+		{"use", "--latest", "--latest-stable"},
+		{"use", "--latest-stable", "--channel", "stable"},
+	} {
+		if err := cli.Run(context.Background(), args); ExitCode(err) != ExitCodeUsage {
+			t.Fatalf("%v: expected a usage error, got %v", args, err)
+		}
+	}
+}
+
+func TestRun_JSONOutput(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	cli, stdout := testCLI(paths, projectDir)
+	run := func(args ...string) {
+		t.Helper()
+		stdout.Reset()
+		if err := cli.Run(context.Background(), append([]string{"--json"}, args...)); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+
+	run("current")
+	if got := strings.TrimSpace(stdout.String()); got != "null" {
+		t.Fatalf("expected null without an active version, got %q", got)
+	}
+
+	for _, version := range []string{"go1.24.2", "go1.25.0"} {
+		mustWriteToolchain(t, paths, version)
+		mustWriteLintBinary(t, paths, tools.RecommendedGolangCILint(version))
+	}
+	if err := switcher.SetGlobalVersion(paths, "go1.24.2"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+
+	run("current")
+	var current activeEntry
+	if err := json.Unmarshal(stdout.Bytes(), &current); err != nil {
+		t.Fatalf("unmarshal current %q: %v", stdout.String(), err)
+	}
+	want := activeEntry{Version: "go1.24.2", Scope: "global", Source: paths.ConfigFile, SourceKind: string(switcher.SourceGlobalConfig)}
+	if current != want {
+		t.Fatalf("expected %+v, got %+v", want, current)
+	}
+
+	run("list")
+	var entries []listEntry
+	if err := json.Unmarshal(stdout.Bytes(), &entries); err != nil {
+		t.Fatalf("unmarshal list %q: %v", stdout.String(), err)
+	}
+	if len(entries) != 2 || entries[0].Version != "go1.25.0" || entries[0].Active || !entries[1].Active {
+		t.Fatalf("unexpected list entries %+v", entries)
+	}
+
+	run("use", "1.25.0", "--quiet")
+	var used useEntry
+	if err := json.Unmarshal(stdout.Bytes(), &used); err != nil {
+		t.Fatalf("unmarshal use %q: %v", stdout.String(), err)
+	}
+	if used.Version != "go1.25.0" || used.Scope != "global" || used.LintVersion != tools.RecommendedGolangCILint("go1.25.0") {
+		t.Fatalf("unexpected use output %+v", used)
+	}
+	if used.Active == nil || used.Active.Version != "go1.25.0" || used.Active.Scope != "global" {
+		t.Fatalf("expected go1.25.0 to be active, got %+v", used.Active)
+	}
+}
+
+// warnCheckingFetcher records whether the insecure warning was printed
+// before the release list was fetched.
+type warnCheckingFetcher struct {
+	fakeFetcher
+	stderr      *bytes.Buffer
+	warnedFirst bool
+}
+
+func (f *warnCheckingFetcher) Fetch(ctx context.Context) ([]releases.Release, error) {
+	f.warnedFirst = strings.Contains(f.stderr.String(), "TLS certificate verification is DISABLED")
+	return f.fakeFetcher.Fetch(ctx)
+}
+
+func TestRun_WarnsBeforeInsecureReleaseFetch(t *testing.T) {
+	t.Setenv(httpclient.InsecureEnv, "1")
+	t.Setenv(httpclient.StrictHostsEnv, "")
+
+	tests := [][]string{
+		{"use", "--latest-stable", "--quiet"},
+		{"use", "--channel", "stable", "--quiet"},
+		{"install", "--channel", "stable", "--quiet"},
+	}
+	for _, args := range tests {
+		paths, projectDir := testPaths(t)
+		mustWriteToolchain(t, paths, "go1.25.1")
+		mustWriteLintBinary(t, paths, tools.RecommendedGolangCILint("go1.25.1"))
+		cli, _ := testCLI(paths, projectDir)
+		fetcher := &warnCheckingFetcher{
+			fakeFetcher: fakeFetcher{releases: []releases.Release{
+				{Version: "go1.25.1", Stable: true, Files: []releases.File{hostArchive("go1.25.1")}},
+			}},
+			stderr: cli.stderr.(*bytes.Buffer),
+		}
+		cli.service.InsecureReleaseClient = fetcher
+
+		if err := cli.Run(context.Background(), args); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		if fetcher.calls == 0 || !fetcher.warnedFirst {
+			t.Fatalf("%v: expected the insecure warning before the fetch, got stderr %q", args, fetcher.stderr.String())
+		}
+	}
+}
+
+func TestRunUninstall_ReportsActiveSwitch(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	mustWriteToolchain(t, paths, "go1.25.0")
+	if err := switcher.SetGlobalVersion(paths, "go1.25.0"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+
+	cli, stdout := testCLI(paths, projectDir)
+	if err := cli.Run(context.Background(), []string{"remove", "1.25.0", "--yes"}); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	want := "uninstalled go1.25.0\nit was active; switched to go1.24.2 (global)\n"
+	if stdout.String() != want {
+		t.Fatalf("expected %q, got %q", want, stdout.String())
+	}
+	if switcher.ToolchainExists(paths, "go1.25.0") {
+		t.Fatalf("expected go1.25.0 to be removed")
+	}
+}
+
+func TestRunToolsPin(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.23.4")
+	mustWriteToolchain(t, paths, "go1.24.2")
+	if err := switcher.SetGlobalVersion(paths, "go1.24.2"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+
+	cli, stdout := testCLI(paths, projectDir)
+	if err := cli.Run(context.Background(), []string{"tools", "pin", "1.64.8"}); err != nil {
+		t.Fatalf("tools pin: %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "pinned golangci-lint v1.64.8 for go1.24.2\n") {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+	if err := cli.Run(context.Background(), []string{"tools", "pin", "v2", "--go", "1.23.4"}); ExitCode(err) != ExitCodeUsage {
+		t.Fatalf("expected usage error for an invalid lint version, got %v", err)
+	}
+	if err := cli.Run(context.Background(), []string{"uninstall", "go1.23.4", "--yes"}); err != nil {
+		t.Fatalf("uninstall unrelated version: %v", err)
+	}
+
+	cfg, err := switcher.ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if got := tools.ResolveLintVersion(cfg, "go1.24.2"); got != "v1.64.8" {
+		t.Fatalf("expected the pin to survive, resolved %s (config %+v)", got, cfg)
+	}
+
+	if err := cli.Run(context.Background(), []string{"tools", "pin", "recommended"}); err != nil {
+		t.Fatalf("tools pin recommended: %v", err)
+	}
+	cfg, err = switcher.ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if len(cfg.GolangCILintPinned) != 0 {
+		t.Fatalf("expected the pin to be dropped, got %v", cfg.GolangCILintPinned)
+	}
+}
+
+func TestRunExecSelfCheck_ReportsIncompleteActiveToolchain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		global      string
+		removeGo    bool
+		wantProblem bool
+	}{
+		{name: "complete toolchain", global: "go1.24.2"},
+		{name: "missing bin/go", global: "go1.24.2", removeGo: true, wantProblem: true},
+		{name: "no active version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			paths, projectDir := testPaths(t)
+			if tt.global != "" {
+				mustWriteToolchain(t, paths, tt.global)
+				if err := switcher.SetGlobalVersion(paths, tt.global); err != nil {
+					t.Fatalf("set global version: %v", err)
+				}
+			}
+			if tt.removeGo {
+				if err := os.Remove(switcher.GoBinaryPath(switcher.ToolchainDir(paths, tt.global))); err != nil {
+					t.Fatalf("remove go binary: %v", err)
+				}
+			}
+
+			cli, stdout := testCLI(paths, projectDir)
+			// The shims are not bootstrapped here, so self-check always fails;
+			// only the toolchain problem line is of interest.
+			if err := cli.Run(context.Background(), []string{"exec", "--self-check"}); err == nil {
+				t.Fatalf("expected self-check to report the missing shims")
+			}
+			got := strings.Contains(stdout.String(), "problem: active toolchain is incomplete")
+			if got != tt.wantProblem {
+				t.Fatalf("expected incomplete toolchain problem %v, got output:\n%s", tt.wantProblem, stdout.String())
+			}
+			if tt.wantProblem && !strings.Contains(stdout.String(), "switcher install "+tt.global) {
+				t.Fatalf("expected reinstall hint, got output:\n%s", stdout.String())
+			}
+		})
+	}
+}
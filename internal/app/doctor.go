@@ -0,0 +1,134 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mrtuuro/go-switcher/internal/install"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/versionutil"
+)
+
+type DoctorCheck struct {
+	Name string
+	OK   bool
+	// Detail explains the result; for failed checks it includes the fix.
+	Detail string
+}
+
+// Doctor diagnoses the switcher install: the shims themselves, whether their
+// directory is on PATH, whether another go or golangci-lint earlier on PATH
+// shadows them, and whether GOTOOLCHAIN overrides the version active in cwd.
+// Each of these makes switching appear to have no effect. It also lists
+// extractions left behind by a killed install, without touching them.
+func (s *Service) Doctor(cwd string) []DoctorCheck {
+	return s.doctor(cwd, exec.LookPath, os.Getenv)
+}
+
+func (s *Service) doctor(cwd string, lookPath func(string) (string, error), getenv func(string) string) []DoctorCheck {
+	checks := []DoctorCheck{s.shimInstallCheck()}
+
+	hint, inPath, err := s.PathHint()
+	switch {
+	case err != nil:
+		checks = append(checks, DoctorCheck{Name: "path", Detail: err.Error()})
+	case !inPath:
+		checks = append(checks, DoctorCheck{Name: "path", Detail: fmt.Sprintf("%s is not on PATH; add it before any other Go installation: %s", s.Paths.BinDir, hint)})
+	default:
+		checks = append(checks, DoctorCheck{Name: "path", OK: true, Detail: fmt.Sprintf("%s is on PATH", s.Paths.BinDir)})
+	}
+
+	for _, tool := range []string{"go", "golangci-lint"} {
+		found, err := lookPath(tool)
+		checks = append(checks, shadowCheck(tool, switcher.ShimPath(s.Paths, tool), found, err))
+	}
+	return append(checks, s.goToolchainCheck(cwd, getenv("GOTOOLCHAIN")), s.extractionCheck())
+}
+
+func (s *Service) extractionCheck() DoctorCheck {
+	const name = "extractions"
+	dirs, err := install.InterruptedExtractions(s.Paths)
+	if err != nil {
+		return DoctorCheck{Name: name, Detail: err.Error()}
+	}
+	if len(dirs) == 0 {
+		return DoctorCheck{Name: name, OK: true, Detail: "no interrupted toolchain extractions"}
+	}
+	return DoctorCheck{Name: name, Detail: fmt.Sprintf("interrupted toolchain extractions in %s; run 'switcher install <version>' or 'switcher use <version>' to restore or remove them", strings.Join(dirs, ", "))}
+}
+
+func (s *Service) goToolchainCheck(cwd string, goToolchain string) DoctorCheck {
+	const name = "GOTOOLCHAIN"
+	if strings.TrimSpace(goToolchain) == "" {
+		return DoctorCheck{Name: name, OK: true, Detail: "not set"}
+	}
+	active := ""
+	if current, err := s.Current(cwd); err == nil {
+		active = current.Version
+	}
+	if override := goToolchainOverride(goToolchain, active); override != "" {
+		return DoctorCheck{Name: name, Detail: override}
+	}
+	return DoctorCheck{Name: name, OK: true, Detail: fmt.Sprintf("GOTOOLCHAIN=%s does not override the switcher version", goToolchain)}
+}
+
+// goToolchainOverride explains how a GOTOOLCHAIN value makes go run a
+// toolchain other than active, or returns "" when it does not. A toolchain
+// name, with or without +auto or +path, replaces the local toolchain; local,
+// auto and path keep it. active may be empty when no version is configured.
+func goToolchainOverride(goToolchain string, active string) string {
+	value := strings.TrimSpace(goToolchain)
+	base, _, _ := strings.Cut(value, "+")
+	switch base {
+	case "", "local", "auto", "path":
+		return ""
+	}
+
+	forced, err := versionutil.NormalizeGoVersion(base)
+	if err != nil {
+		return fmt.Sprintf("GOTOOLCHAIN=%s is not a toolchain go understands, so go commands may fail; unset it or set GOTOOLCHAIN=local", value)
+	}
+	if forced == active {
+		return ""
+	}
+	if active == "" {
+		return fmt.Sprintf("GOTOOLCHAIN=%s makes go run %s regardless of the switcher version; unset it or set GOTOOLCHAIN=local", value, forced)
+	}
+	return fmt.Sprintf("GOTOOLCHAIN=%s makes go run %s instead of the switcher version %s; unset it or set GOTOOLCHAIN=local", value, forced, active)
+}
+
+func (s *Service) shimInstallCheck() DoctorCheck {
+	problems := switcher.CheckShims(s.Paths)
+	if len(problems) == 0 {
+		return DoctorCheck{Name: "shims", OK: true, Detail: fmt.Sprintf("shims installed in %s", s.Paths.BinDir)}
+	}
+	return DoctorCheck{Name: "shims", Detail: strings.Join(problems, "; ") + "; run 'switcher use <version>' to reinstall them"}
+}
+
+// shadowCheck compares the tool PATH resolves to with the switcher shim.
+func shadowCheck(tool string, shimPath string, found string, lookErr error) DoctorCheck {
+	name := tool + " on PATH"
+	if lookErr != nil {
+		return DoctorCheck{Name: name, Detail: fmt.Sprintf("%s not found on PATH; expected the shim %s", tool, shimPath)}
+	}
+	if sameExecutable(found, shimPath) {
+		return DoctorCheck{Name: name, OK: true, Detail: fmt.Sprintf("%s resolves to the switcher shim", tool)}
+	}
+	return DoctorCheck{Name: name, Detail: fmt.Sprintf("%s resolves to %s, which shadows the switcher shim %s; move %s earlier on PATH or remove the other installation", tool, found, shimPath, filepath.Dir(shimPath))}
+}
+
+func sameExecutable(a string, b string) bool {
+	resolve := func(path string) string {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if evaluated, err := filepath.EvalSymlinks(path); err == nil {
+			path = evaluated
+		}
+		return filepath.Clean(path)
+	}
+	return resolve(a) == resolve(b)
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
+	"github.com/mrtuuro/go-switcher/internal/install"
+	"github.com/mrtuuro/go-switcher/internal/releases"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+// Exit codes are part of the CLI contract; see "Exit codes" in the README.
+const (
+	ExitCodeGeneric = 1
+	ExitCodeUsage   = 2
+	// ExitCodeNoActiveVersion is returned when no Go version is configured
+	// for the working directory, e.g. by `current --exit-code`.
+	ExitCodeNoActiveVersion = 3
+	// ExitCodeNotInstalled covers a toolchain that is not installed and a
+	// version that is not in the release list.
+	ExitCodeNotInstalled = 4
+	ExitCodeNetwork      = 5
+	ExitCodeChecksum     = 6
+	// ExitCodeDownloadNeeded is returned by `install --check-only` for a
+	// version that is available but not installed yet.
+	ExitCodeDownloadNeeded = 7
+)
+
+// ExitError asks the caller to terminate with Code. Err may be nil when the
+// command already reported everything it needed to.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// UsageError reports invalid command-line arguments.
+type UsageError struct {
+	Message string
+}
+
+func (e *UsageError) Error() string {
+	return e.Message
+}
+
+func usageErrorf(format string, args ...any) error {
+	return &UsageError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ExitCode maps an error returned by CLI.Run to the process exit code.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *ExitError
+	var usageErr *UsageError
+	var statusErr *httpclient.StatusError
+	var urlErr *url.Error
+	var netErr net.Error
+	switch {
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &usageErr):
+		return ExitCodeUsage
+	case errors.Is(err, switcher.ErrNoActiveVersion):
+		return ExitCodeNoActiveVersion
+	case errors.Is(err, switcher.ErrNotInstalled), errors.Is(err, releases.ErrNotFound):
+		return ExitCodeNotInstalled
+	case errors.Is(err, install.ErrChecksumMismatch):
+		return ExitCodeChecksum
+	case errors.As(err, &statusErr), errors.As(err, &urlErr), errors.As(err, &netErr):
+		return ExitCodeNetwork
+	default:
+		return ExitCodeGeneric
+	}
+}
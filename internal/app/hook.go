@@ -0,0 +1,81 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/progress"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+// postSwitchHookTimeout bounds how long the configured hook may run.
+var postSwitchHookTimeout = 2 * time.Minute
+
+type hookData struct {
+	Version string
+	Scope   string
+}
+
+// renderPostSwitchHook expands the {{.Version}} and {{.Scope}} placeholders
+// in the configured hook command.
+func renderPostSwitchHook(hook string, version string, scope switcher.Scope) (string, error) {
+	tmpl, err := template.New("post_switch_hook").Option("missingkey=error").Parse(hook)
+	if err != nil {
+		return "", fmt.Errorf("parse post_switch_hook: %w", err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, hookData{Version: version, Scope: string(scope)}); err != nil {
+		return "", fmt.Errorf("render post_switch_hook: %w", err)
+	}
+	return rendered.String(), nil
+}
+
+// runPostSwitchHook runs the configured post_switch_hook with sh in cwd after a
+// successful switch, with the new toolchain's bin directory first on PATH.
+// Output is forwarded as progress events. Failures are returned as a warning
+// because the switch itself has already been applied.
+func (s *Service) runPostSwitchHook(ctx context.Context, version string, scope switcher.Scope, cwd string, reporter progress.Reporter) string {
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil || strings.TrimSpace(cfg.PostSwitchHook) == "" {
+		return ""
+	}
+
+	command, err := renderPostSwitchHook(cfg.PostSwitchHook, version, scope)
+	if err != nil {
+		return fmt.Sprintf("post-switch hook skipped: %v", err)
+	}
+
+	hookCtx, cancel := context.WithTimeout(ctx, postSwitchHookTimeout)
+	defer cancel()
+
+	progress.Emit(reporter, "post-switch-hook", fmt.Sprintf("Running post-switch hook: %s", command), 0, 0)
+	cmd := exec.CommandContext(hookCtx, "sh", "-c", command)
+	cmd.Dir = cwd
+	toolchainBin := filepath.Join(switcher.ToolchainDir(s.Paths, version), "bin")
+	cmd.Env = append(os.Environ(), "PATH="+toolchainBin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	output, err := cmd.CombinedOutput()
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			progress.Emit(reporter, "post-switch-hook", line, 0, 0)
+		}
+	}
+
+	switch {
+	case errors.Is(hookCtx.Err(), context.DeadlineExceeded):
+		return fmt.Sprintf("post-switch hook timed out after %s", postSwitchHookTimeout)
+	case err != nil:
+		return fmt.Sprintf("post-switch hook failed: %v", err)
+	}
+	return ""
+}
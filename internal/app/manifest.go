@@ -0,0 +1,205 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/mrtuuro/go-switcher/internal/progress"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/tools"
+	"github.com/mrtuuro/go-switcher/internal/versionutil"
+)
+
+type Manifest struct {
+	GlobalVersion    string            `json:"global_version,omitempty"`
+	Versions         []string          `json:"versions"`
+	GolangCILintByGo map[string]string `json:"golangci_lint_by_go,omitempty"`
+	// GolangCILintPinned lists the Go versions whose GolangCILintByGo entry
+	// is pinned, as with 'switcher use --lint'.
+	GolangCILintPinned []string `json:"golangci_lint_pinned,omitempty"`
+}
+
+type ImportResult struct {
+	Installed        []string
+	AlreadyInstalled []string
+	LintMappings     map[string]string
+	// LintPinned lists the Go versions in LintMappings the import pinned.
+	LintPinned    []string
+	GlobalVersion string
+	DryRun        bool
+}
+
+func DecodeManifest(r io.Reader) (Manifest, error) {
+	var manifest Manifest
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("decode manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+func (s *Service) Export() (Manifest, error) {
+	versions, err := s.ListLocal()
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest := Manifest{
+		GlobalVersion:    cfg.GlobalVersion,
+		Versions:         versions,
+		GolangCILintByGo: map[string]string{},
+	}
+	for _, version := range versions {
+		if lintVersion, ok := cfg.GolangCILintByGo[version]; ok {
+			manifest.GolangCILintByGo[version] = lintVersion
+			if slices.Contains(cfg.GolangCILintPinned, version) {
+				manifest.GolangCILintPinned = append(manifest.GolangCILintPinned, version)
+			}
+		}
+	}
+
+	return manifest, nil
+}
+
+func (s *Service) Import(ctx context.Context, manifest Manifest, dryRun bool, reporter progress.Reporter) (ImportResult, error) {
+	normalized, err := normalizeManifest(manifest)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	result := ImportResult{LintMappings: map[string]string{}, DryRun: dryRun}
+	for _, version := range normalized.Versions {
+		if switcher.ToolchainExists(s.Paths, version) {
+			result.AlreadyInstalled = append(result.AlreadyInstalled, version)
+			continue
+		}
+		if !dryRun {
+			if _, err := s.InstallWithProgress(ctx, version, reporter); err != nil {
+				return result, fmt.Errorf("install %s: %w", version, err)
+			}
+		}
+		result.Installed = append(result.Installed, version)
+	}
+
+	update := func(cfg *switcher.Config) bool {
+		for goVersion, lintVersion := range normalized.GolangCILintByGo {
+			pinned := slices.Contains(normalized.GolangCILintPinned, goVersion)
+			alreadyPinned := slices.Contains(cfg.GolangCILintPinned, goVersion)
+			if cfg.GolangCILintByGo[goVersion] == lintVersion && (!pinned || alreadyPinned) {
+				continue
+			}
+			if pinned {
+				tools.PinLintVersion(cfg, goVersion, lintVersion)
+				result.LintPinned = append(result.LintPinned, goVersion)
+			} else {
+				cfg.GolangCILintByGo[goVersion] = lintVersion
+			}
+			result.LintMappings[goVersion] = lintVersion
+		}
+		slices.Sort(result.LintPinned)
+
+		if normalized.GlobalVersion != "" && cfg.GlobalVersion != normalized.GlobalVersion {
+			result.GlobalVersion = normalized.GlobalVersion
+		}
+		return len(result.LintMappings) > 0
+	}
+
+	if dryRun {
+		cfg, err := switcher.ReadConfig(s.Paths)
+		if err != nil {
+			return result, err
+		}
+		update(&cfg)
+		return result, nil
+	}
+	if err := switcher.UpdateConfig(s.Paths, update); err != nil {
+		return result, err
+	}
+
+	if result.GlobalVersion != "" {
+		// SetGlobalVersion records the previous version, so 'switcher use -'
+		// undoes the import.
+		if err := switcher.SetGlobalVersion(s.Paths, result.GlobalVersion); err != nil {
+			return result, err
+		}
+		progress.Emit(reporter, "shim-update", "Refreshing shims...", 0, 0)
+		if err := switcher.EnsureShims(s.Paths); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func normalizeManifest(manifest Manifest) (Manifest, error) {
+	normalized := Manifest{GolangCILintByGo: map[string]string{}}
+
+	seen := map[string]struct{}{}
+	for _, version := range manifest.Versions {
+		v, err := versionutil.NormalizeGoVersion(version)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("invalid manifest version: %w", err)
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		normalized.Versions = append(normalized.Versions, v)
+	}
+
+	for goVersion, lintVersion := range manifest.GolangCILintByGo {
+		v, err := versionutil.NormalizeGoVersion(goVersion)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("invalid manifest lint mapping: %w", err)
+		}
+		lintVersion = strings.TrimSpace(lintVersion)
+		if err := versionutil.ValidateDottedVersion(lintVersion); err != nil {
+			return Manifest{}, fmt.Errorf("invalid golangci-lint version for %s: %w", v, err)
+		}
+		normalized.GolangCILintByGo[v] = lintVersion
+	}
+
+	for _, goVersion := range manifest.GolangCILintPinned {
+		v, err := versionutil.NormalizeGoVersion(goVersion)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("invalid manifest lint pin: %w", err)
+		}
+		if _, ok := normalized.GolangCILintByGo[v]; !ok {
+			return Manifest{}, fmt.Errorf("manifest pins golangci-lint for %s without a golangci_lint_by_go entry", v)
+		}
+		if !slices.Contains(normalized.GolangCILintPinned, v) {
+			normalized.GolangCILintPinned = append(normalized.GolangCILintPinned, v)
+		}
+	}
+
+	if strings.TrimSpace(manifest.GlobalVersion) != "" {
+		v, err := versionutil.NormalizeVersionSpec(manifest.GlobalVersion)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("invalid manifest global version: %w", err)
+		}
+		listed := false
+		if _, majorMinor, ok := versionutil.ParseMinorAlias(v); ok {
+			_, listed = versionutil.LatestPatch(normalized.Versions, majorMinor)
+		} else {
+			_, listed = seen[v]
+		}
+		if !listed {
+			return Manifest{}, fmt.Errorf("manifest global version %s is not listed in versions", v)
+		}
+		normalized.GlobalVersion = v
+	}
+
+	versionutil.SortNewestFirst(normalized.Versions)
+
+	return normalized, nil
+}
@@ -0,0 +1,324 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/tools"
+	"github.com/mrtuuro/go-switcher/internal/versionutil"
+)
+
+type PruneOptions struct {
+	// KeepNewest is the number of newest installed toolchains to keep. A
+	// negative value leaves toolchains untouched.
+	KeepNewest int
+	// KeepActive keeps the version active in cwd and the global version
+	// even when they are not among the newest.
+	KeepActive bool
+	// CleanCache removes cached Go and golangci-lint archives that no
+	// toolchain left installed by the prune needs.
+	CleanCache bool
+	// RemoveBroken removes version-named entries in the toolchains directory
+	// that ListLocal skips, e.g. a half-extracted toolchain without bin/go.
+	RemoveBroken bool
+	// DryRun reports what would be removed without removing anything.
+	DryRun bool
+}
+
+type PruneResult struct {
+	Toolchains       []string
+	BrokenToolchains []switcher.BrokenToolchain
+	CacheEntries     []string
+	BytesReclaimed   int64
+	DryRun           bool
+}
+
+// Prune removes old toolchains and cached downloads according to opts. The
+// result lists what was removed, or would be for a dry run.
+func (s *Service) Prune(ctx context.Context, cwd string, opts PruneOptions) (PruneResult, error) {
+	result := PruneResult{DryRun: opts.DryRun}
+
+	installed, err := s.ListLocal()
+	if err != nil {
+		return PruneResult{}, err
+	}
+
+	if opts.KeepNewest >= 0 {
+		var keep []string
+		if opts.KeepActive {
+			keep, err = s.ActiveVersions(cwd)
+			if err != nil {
+				return PruneResult{}, err
+			}
+		}
+
+		// installed is sorted newest first.
+		for i, version := range installed {
+			if i < opts.KeepNewest || slices.Contains(keep, version) {
+				continue
+			}
+			size, err := pathSize(switcher.ToolchainDir(s.Paths, version))
+			if err != nil {
+				return PruneResult{}, err
+			}
+			result.Toolchains = append(result.Toolchains, version)
+			result.BytesReclaimed += size
+		}
+	}
+
+	if opts.RemoveBroken {
+		broken, err := s.ListBroken()
+		if err != nil {
+			return PruneResult{}, err
+		}
+		for _, entry := range broken {
+			size, err := pathSize(entry.Path)
+			if err != nil {
+				return PruneResult{}, err
+			}
+			result.BrokenToolchains = append(result.BrokenToolchains, entry)
+			result.BytesReclaimed += size
+		}
+	}
+
+	if opts.CleanCache {
+		remaining := slices.DeleteFunc(slices.Clone(installed), func(version string) bool {
+			return slices.Contains(result.Toolchains, version)
+		})
+		names, err := s.unneededCacheEntries(remaining)
+		if err != nil {
+			return PruneResult{}, err
+		}
+		for _, name := range names {
+			size, err := pathSize(filepath.Join(s.Paths.CacheDir, name))
+			if err != nil {
+				return PruneResult{}, err
+			}
+			result.CacheEntries = append(result.CacheEntries, name)
+			result.BytesReclaimed += size
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	for _, version := range result.Toolchains {
+		if err := ctx.Err(); err != nil {
+			return PruneResult{}, err
+		}
+		if _, err := s.DeleteInstalledWithProgress(ctx, cwd, version, nil); err != nil {
+			return PruneResult{}, fmt.Errorf("prune %s: %w", version, err)
+		}
+	}
+	for _, entry := range result.BrokenToolchains {
+		if err := os.RemoveAll(entry.Path); err != nil {
+			return PruneResult{}, fmt.Errorf("remove broken toolchain %s: %w", entry.Path, err)
+		}
+	}
+	for _, name := range result.CacheEntries {
+		if err := os.RemoveAll(filepath.Join(s.Paths.CacheDir, name)); err != nil {
+			return PruneResult{}, fmt.Errorf("remove cache entry %s: %w", name, err)
+		}
+	}
+
+	return result, nil
+}
+
+// ActiveVersions returns the version active in cwd followed by the global
+// version when it differs, so a local pin does not leave other directories
+// without their toolchain. It is empty when neither is set.
+func (s *Service) ActiveVersions(cwd string) ([]string, error) {
+	var versions []string
+	active, err := s.Current(cwd)
+	switch {
+	case err == nil:
+		versions = append(versions, active.Version)
+	case err != switcher.ErrNoActiveVersion:
+		return nil, err
+	}
+	global, ok, err := switcher.GlobalVersion(s.Paths)
+	if err != nil {
+		return nil, err
+	}
+	if ok && !slices.Contains(versions, global) {
+		versions = append(versions, global)
+	}
+	return versions, nil
+}
+
+// unneededCacheEntries lists the Go archives in the cache whose version is
+// not in installed, and the golangci-lint archives of versions no installed
+// toolchain maps to. Other files, such as locks and partial downloads, are
+// left alone.
+func (s *Service) unneededCacheEntries(installed []string) ([]string, error) {
+	entries, err := os.ReadDir(s.Paths.CacheDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read cache directory: %w", err)
+	}
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return nil, err
+	}
+	referenced := referencedLintVersions(installed, cfg)
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if goVersion, ok := goArchiveVersion(entry.Name()); ok {
+			if !slices.Contains(installed, goVersion) {
+				names = append(names, entry.Name())
+			}
+			continue
+		}
+		if lintVersion, ok := lintArchiveVersion(entry.Name()); ok && !referenced[lintVersion] {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// goArchiveVersion returns the Go version of a release archive name such as
+// go1.24.2.linux-amd64.tar.gz.
+func goArchiveVersion(name string) (string, bool) {
+	base, ok := trimArchiveSuffix(name)
+	if !ok || !strings.HasPrefix(base, "go") {
+		return "", false
+	}
+	// Versions contain no dash, so the last dot before the first dash ends
+	// the version and starts the platform.
+	platformEnd := strings.Index(base, "-")
+	if platformEnd < 0 {
+		return "", false
+	}
+	versionEnd := strings.LastIndex(base[:platformEnd], ".")
+	if versionEnd < 0 {
+		return "", false
+	}
+	version, err := versionutil.NormalizeGoVersion(base[:versionEnd])
+	if err != nil {
+		return "", false
+	}
+	return version, true
+}
+
+// lintArchiveVersion returns the golangci-lint version of an archive name
+// such as golangci-lint-1.64.8-linux-amd64.tar.gz.
+func lintArchiveVersion(name string) (string, bool) {
+	base, ok := trimArchiveSuffix(name)
+	if !ok {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(base, "golangci-lint-")
+	if !ok {
+		return "", false
+	}
+	version, _, ok := strings.Cut(rest, "-")
+	if !ok || version == "" {
+		return "", false
+	}
+	return "v" + version, true
+}
+
+func trimArchiveSuffix(name string) (string, bool) {
+	for _, suffix := range []string{".tar.gz", ".zip"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			return base, true
+		}
+	}
+	return "", false
+}
+
+// referencedLintVersions returns the golangci-lint versions that installed
+// Go toolchains map to in golangci_lint_by_go.
+func referencedLintVersions(installed []string, cfg switcher.Config) map[string]bool {
+	referenced := map[string]bool{}
+	for _, goVersion := range installed {
+		if lintVersion := strings.TrimSpace(cfg.GolangCILintByGo[goVersion]); lintVersion != "" {
+			referenced[lintVersion] = true
+		}
+	}
+	return referenced
+}
+
+type GCResult struct {
+	// LintVersions are the golangci-lint versions removed, or that would be
+	// for a dry run.
+	LintVersions   []string
+	BytesReclaimed int64
+	DryRun         bool
+}
+
+// GarbageCollectTools removes golangci-lint versions that no installed Go
+// toolchain maps to in golangci_lint_by_go. Mappings of toolchains that are
+// no longer installed do not keep a binary.
+func (s *Service) GarbageCollectTools(dryRun bool) (GCResult, error) {
+	installed, err := s.ListLocal()
+	if err != nil {
+		return GCResult{}, err
+	}
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return GCResult{}, err
+	}
+
+	referenced := referencedLintVersions(installed, cfg)
+
+	root := tools.GolangCILintRoot(s.Paths)
+	entries, err := os.ReadDir(root)
+	if err != nil && !os.IsNotExist(err) {
+		return GCResult{}, fmt.Errorf("read tools directory: %w", err)
+	}
+
+	result := GCResult{DryRun: dryRun}
+	for _, entry := range entries {
+		if !entry.IsDir() || referenced[entry.Name()] {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		size, err := pathSize(dir)
+		if err != nil {
+			return GCResult{}, err
+		}
+		if !dryRun {
+			if err := os.RemoveAll(dir); err != nil {
+				return GCResult{}, fmt.Errorf("remove golangci-lint %s: %w", entry.Name(), err)
+			}
+		}
+		result.LintVersions = append(result.LintVersions, entry.Name())
+		result.BytesReclaimed += size
+	}
+	return result, nil
+}
+
+// pathSize returns the total size of regular files under path without
+// following symlinks.
+func pathSize(path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("measure %s: %w", path, err)
+	}
+	return total, nil
+}
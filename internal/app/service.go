@@ -2,10 +2,16 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
 	"github.com/mrtuuro/go-switcher/internal/install"
 	"github.com/mrtuuro/go-switcher/internal/progress"
 	"github.com/mrtuuro/go-switcher/internal/releases"
@@ -16,76 +22,591 @@ import (
 
 type Service struct {
 	Paths         switcher.Paths
-	ReleaseClient *releases.Client
+	ReleaseClient releases.Fetcher
+	// InsecureReleaseClient serves lookups made with InsecureSkipVerify. It
+	// must not share a release cache with ReleaseClient.
+	InsecureReleaseClient releases.Fetcher
+	// StartupNotes reports problems NewService worked around, such as a
+	// base directory that fell back to the system temp dir, and the
+	// interrupted extractions it repaired.
+	StartupNotes []string
+	// ConfigOptions control how CheckConfig treats a broken config.
+	ConfigOptions switcher.ConfigOptions
 }
 
+// ServiceOptions select the startup work NewServiceWithOptions does beyond
+// resolving paths and creating the layout.
+type ServiceOptions struct {
+	// SkipWriteProbe skips checking that every managed directory is
+	// writable. Commands that never write to them set it, so they keep
+	// working when, say, a CI cache dir is mounted read-only.
+	SkipWriteProbe bool
+	// RecoverExtractions repairs toolchain extractions left behind by a
+	// killed install and reports each repair in StartupNotes.
+	RecoverExtractions bool
+}
+
+// NewService sets up a service with all startup work enabled.
 func NewService() (*Service, error) {
-	paths, err := switcher.DefaultPaths()
+	return NewServiceWithOptions(ServiceOptions{RecoverExtractions: true})
+}
+
+func NewServiceWithOptions(opts ServiceOptions) (*Service, error) {
+	paths, pathNote, err := switcher.DefaultPaths()
 	if err != nil {
 		return nil, err
 	}
+	if err := paths.Validate(); err != nil {
+		return nil, err
+	}
 
+	base := releases.NewClient()
+	base.CachePath = filepath.Join(paths.CacheDir, releases.CacheFile)
+	client, insecureClient := releaseClients(base)
 	service := &Service{
-		Paths:         paths,
-		ReleaseClient: releases.NewClient(),
+		Paths:                 paths,
+		ReleaseClient:         client,
+		InsecureReleaseClient: insecureClient,
+	}
+	if pathNote != "" {
+		service.StartupNotes = append(service.StartupNotes, pathNote)
 	}
 
-	if err := switcher.EnsureLayout(paths); err != nil {
+	// The probe makes commands that change the managed directories fail
+	// early and name the directory, instead of failing deep inside a
+	// download or extraction.
+	if err := switcher.EnsureLayoutWithOptions(paths, switcher.LayoutOptions{ProbeWritable: !opts.SkipWriteProbe}); err != nil {
 		return nil, err
 	}
+	if opts.RecoverExtractions {
+		service.StartupNotes = append(service.StartupNotes, service.recoverExtractions()...)
+	}
 
 	return service, nil
 }
 
+// recoverExtractions repairs toolchain extractions left behind by a killed
+// install and returns a note for each repair or failure.
+func (s *Service) recoverExtractions() []string {
+	var notes []string
+	recovered, err := install.RecoverInterruptedExtractions(s.Paths)
+	for _, extraction := range recovered {
+		notes = append(notes, extraction.String())
+	}
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("could not recover interrupted extraction: %v", err))
+	}
+	return notes
+}
+
+// ReadConfig reads the config with s.ConfigOptions and returns a warning
+// for every problem it repaired or ignored.
+func (s *Service) ReadConfig() (switcher.Config, []string, error) {
+	return switcher.ReadConfigWithOptions(s.Paths, s.ConfigOptions)
+}
+
+// CheckConfig reads the config with s.ConfigOptions before a command runs,
+// so problems are reported once, or fail the command in strict mode.
+// NewService never reads the config, so this is the first read; later reads
+// repair or ignore problems silently. Settings the service itself uses,
+// such as release_cache_ttl, are applied from this read.
+func (s *Service) CheckConfig() ([]string, error) {
+	cfg, warnings, err := s.ReadConfig()
+	if err != nil {
+		return warnings, err
+	}
+	if err := s.applyReleaseCacheTTL(cfg); err != nil {
+		if s.ConfigOptions.Strict {
+			return warnings, err
+		}
+		warnings = append(warnings, fmt.Sprintf("%v; using the default release cache TTL", err))
+	}
+	return warnings, nil
+}
+
 func (s *Service) ListLocal() ([]string, error) {
 	return switcher.ListInstalledVersions(s.Paths)
 }
 
+func (s *Service) ListLocalCtx(ctx context.Context) ([]string, error) {
+	return switcher.ListInstalledVersionsCtx(ctx, s.Paths)
+}
+
+func (s *Service) ListBroken() ([]switcher.BrokenToolchain, error) {
+	return switcher.ListBrokenToolchains(s.Paths)
+}
+
 func (s *Service) ListRemote(ctx context.Context) ([]string, error) {
-	all, err := s.ReleaseClient.Fetch(ctx)
+	listing, err := s.ListRemoteListing(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return releases.AvailableVersions(all, runtime.GOOS, runtime.GOARCH), nil
+	return listing.Versions, nil
+}
+
+// ListRemoteListing is ListRemote with the time the release data was fetched,
+// which is older than now when the fetcher served it from a cache.
+func (s *Service) ListRemoteListing(ctx context.Context) (releases.Listing, error) {
+	return s.ListRemoteListingWithOptions(ctx, releases.FetchOptions{})
+}
+
+// ListRemoteListingWithOptions is ListRemoteListing with opts, e.g. to
+// revalidate a cached release list.
+func (s *Service) ListRemoteListingWithOptions(ctx context.Context, opts releases.FetchOptions) (releases.Listing, error) {
+	fetcher, err := s.releaseFetcher(false)
+	if err != nil {
+		return releases.Listing{}, err
+	}
+
+	all, fetchedAt, err := releases.FetchList(ctx, fetcher, opts)
+	if err != nil {
+		return releases.Listing{}, err
+	}
+	return releases.Listing{Versions: releases.AvailableVersions(all, runtime.GOOS, runtime.GOARCH), FetchedAt: fetchedAt}, nil
 }
 
 func (s *Service) Current(cwd string) (switcher.ActiveVersion, error) {
 	return switcher.ResolveActiveVersion(cwd, s.Paths)
 }
 
+// ProjectVersion returns the Go version the project around cwd asks for and
+// the file that named it. A minor alias resolves to the newest installed
+// match, or the newest published one when none is installed.
+func (s *Service) ProjectVersion(ctx context.Context, cwd string) (string, string, error) {
+	spec, path, found, err := switcher.FindProjectVersion(cwd)
+	if err != nil {
+		return "", "", err
+	}
+	if !found {
+		return "", "", fmt.Errorf("no go version given and no %s, go.work or go.mod found above %s; pass a version or run 'switcher use <go-version> --scope local' to pin one", switcher.LocalVersionFile, cwd)
+	}
+
+	_, majorMinor, isAlias := versionutil.ParseMinorAlias(spec)
+	if !isAlias {
+		return spec, path, nil
+	}
+	if installed, err := switcher.ResolveVersionSpec(s.Paths, spec); err == nil {
+		return installed, path, nil
+	}
+	remote, err := s.ListRemote(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	latest, ok := versionutil.LatestPatch(remote, majorMinor)
+	if !ok {
+		return "", "", fmt.Errorf("%s in %s matches no published Go release", spec, path)
+	}
+	return latest, path, nil
+}
+
 func (s *Service) Install(ctx context.Context, version string) (string, error) {
 	return s.InstallWithProgress(ctx, version, nil)
 }
 
+type InstallOptions struct {
+	Reporter progress.Reporter
+	// NoCache streams the archive into extraction without caching it.
+	NoCache bool
+	// Variant requests a microarchitecture build such as goarm7. When empty,
+	// GOARM/GOAMD64 are consulted and the default archive is used if the
+	// mirror has no matching variant.
+	Variant string
+	// InsecureSkipVerify disables TLS certificate verification for release
+	// metadata and archive downloads. Ignored in strict hosts mode.
+	InsecureSkipVerify bool
+	// Telemetry is passed to the new toolchain's `go telemetry` command, e.g.
+	// off. Empty falls back to the go_telemetry config default.
+	Telemetry string
+	// SHA256 is the expected archive checksum, for mirrors whose metadata
+	// has none. Checksums names a URL or file listing checksums by archive
+	// filename instead. Either must agree with a published checksum.
+	SHA256    string
+	Checksums string
+}
+
+// releaseClients returns base with a copy for insecure lookups, which skips
+// TLS verification and never reads or writes the release cache.
+func releaseClients(base *releases.Client) (client *releases.Client, insecure *releases.Client) {
+	copied := *base
+	copied.HTTPClient = httpclient.NewWithOptions(httpclient.Options{Timeout: 60 * time.Second, InsecureSkipVerify: true})
+	// The cached list carries the checksums later secure installs trust,
+	// so an unverified response must never end up in it.
+	copied.CachePath = ""
+	return base, &copied
+}
+
+// applyReleaseCacheTTL sets the cache TTL of the secure release client from
+// cfg. An invalid TTL is returned and the client keeps the default.
+func (s *Service) applyReleaseCacheTTL(cfg switcher.Config) error {
+	ttl, set, err := cfg.ReleaseCacheDuration()
+	if err != nil || !set {
+		return err
+	}
+	client, ok := s.ReleaseClient.(*releases.Client)
+	if !ok {
+		return nil
+	}
+	client.CacheTTL = ttl
+	if ttl == 0 {
+		// The client spells "revalidate every time" as a negative TTL;
+		// zero is its default.
+		client.CacheTTL = -1
+	}
+	return nil
+}
+
+// releaseFetcher returns the release source for secure or insecure lookups.
+func (s *Service) releaseFetcher(insecure bool) (releases.Fetcher, error) {
+	if !insecure {
+		return s.ReleaseClient, nil
+	}
+	if s.InsecureReleaseClient == nil {
+		return nil, errors.New("insecure release lookups are not configured")
+	}
+	return s.InsecureReleaseClient, nil
+}
+
+// ResolveChannel returns the concrete version channel currently points to
+// for the host platform.
+func (s *Service) ResolveChannel(ctx context.Context, channel releases.Channel, insecure bool) (string, error) {
+	fetcher, err := s.releaseFetcher(insecure)
+	if err != nil {
+		return "", err
+	}
+	all, err := fetcher.Fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	return releases.NewIndex(all).LatestInChannel(channel, runtime.GOOS, runtime.GOARCH)
+}
+
 func (s *Service) InstallWithProgress(ctx context.Context, version string, reporter progress.Reporter) (string, error) {
+	result, err := s.InstallWithOptions(ctx, version, InstallOptions{Reporter: reporter})
+	return result.Version, err
+}
+
+// InstallResult describes a finished install. AlreadyInstalled is set when
+// the toolchain was present and nothing was downloaded.
+type InstallResult struct {
+	Version          string
+	AlreadyInstalled bool
+}
+
+func (s *Service) InstallWithOptions(ctx context.Context, version string, opts InstallOptions) (InstallResult, error) {
+	reporter := opts.Reporter
 	normalized, err := versionutil.NormalizeGoVersion(version)
 	if err != nil {
-		return "", err
+		return InstallResult{}, err
+	}
+	if err := ValidateTelemetryMode(opts.Telemetry); err != nil {
+		return InstallResult{}, err
 	}
 
+	if switcher.ToolchainExists(s.Paths, normalized) {
+		progress.Emit(reporter, "go-install", fmt.Sprintf("%s already installed (skipped download)", normalized), 0, 0)
+		if err := s.finishInstall(ctx, normalized, opts.Telemetry, reporter); err != nil {
+			return InstallResult{}, err
+		}
+		return InstallResult{Version: normalized, AlreadyInstalled: true}, nil
+	}
+
+	fetcher, err := s.releaseFetcher(opts.InsecureSkipVerify)
+	if err != nil {
+		return InstallResult{}, err
+	}
 	progress.Emit(reporter, "release-fetch", "Fetching Go release metadata...", 0, 0)
-	all, err := s.ReleaseClient.Fetch(ctx)
+	release, err := releases.FetchVersion(ctx, fetcher, normalized)
 	if err != nil {
-		return "", err
+		return InstallResult{}, err
 	}
 
 	progress.Emit(reporter, "release-select", fmt.Sprintf("Selecting %s for %s/%s", normalized, runtime.GOOS, runtime.GOARCH), 0, 0)
-	archive, normalized, err := releases.FindArchive(all, normalized, runtime.GOOS, runtime.GOARCH)
+	variant := releases.NormalizeVariant(opts.Variant)
+	if variant == "" {
+		envVariant := releases.VariantFromEnv(runtime.GOARCH, os.Getenv)
+		if _, ok := release.ArchiveForVariant(runtime.GOOS, runtime.GOARCH, envVariant); ok {
+			variant = envVariant
+		}
+	}
+
+	candidates := []releases.Release{release}
+	if _, ok := release.ArchiveForVariant(runtime.GOOS, runtime.GOARCH, variant); !ok && variant == "" {
+		// The full list lets FindArchive suggest the nearest installable version.
+		if all, fetchErr := fetcher.Fetch(ctx); fetchErr == nil {
+			candidates = all
+		}
+	}
+	archive, normalized, err := releases.FindArchiveVariant(candidates, normalized, runtime.GOOS, runtime.GOARCH, variant)
 	if err != nil {
-		return "", err
+		return InstallResult{}, err
+	}
+	if variant != "" {
+		progress.Emit(reporter, "release-select", fmt.Sprintf("Using %s variant archive %s", variant, archive.Filename), 0, 0)
+	}
+	if archive, err = overrideChecksum(ctx, archive, opts); err != nil {
+		return InstallResult{}, err
 	}
 
-	if err := install.InstallGoArchiveWithOptions(ctx, s.Paths, normalized, archive, install.InstallOptions{Reporter: reporter}); err != nil {
-		return "", err
+	installOpts, err := s.archiveInstallOptions(reporter)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	installOpts.NoCache = opts.NoCache
+	installOpts.InsecureSkipVerify = opts.InsecureSkipVerify
+	if err := install.InstallGoArchiveWithOptions(ctx, s.Paths, normalized, archive, installOpts); err != nil {
+		return InstallResult{}, err
+	}
+
+	if err := s.finishInstall(ctx, normalized, opts.Telemetry, reporter); err != nil {
+		return InstallResult{}, err
 	}
 
+	progress.Emit(reporter, "go-install", fmt.Sprintf("Ready: %s", normalized), 0, 0)
+	return InstallResult{Version: normalized}, nil
+}
+
+// finishInstall refreshes the shims and applies the telemetry mode; it runs
+// for fresh and already present toolchains alike.
+func (s *Service) finishInstall(ctx context.Context, version string, telemetry string, reporter progress.Reporter) error {
 	progress.Emit(reporter, "shim-update", "Updating tool shims...", 0, 0)
 	if err := switcher.EnsureShims(s.Paths); err != nil {
-		return "", err
+		return err
 	}
+	return s.applyTelemetry(ctx, version, telemetry, reporter)
+}
 
-	progress.Emit(reporter, "go-install", fmt.Sprintf("Ready: %s", normalized), 0, 0)
-	return normalized, nil
+// overrideChecksum sets the archive checksum from opts.SHA256 or
+// opts.Checksums. A published checksum that disagrees is an error rather than
+// being replaced.
+func overrideChecksum(ctx context.Context, archive releases.File, opts InstallOptions) (releases.File, error) {
+	var expected string
+	var err error
+	switch {
+	case opts.SHA256 != "":
+		expected, err = install.NormalizeSHA256(opts.SHA256)
+	case opts.Checksums != "":
+		expected, err = install.LoadChecksum(ctx, opts.Checksums, archive.Filename, opts.InsecureSkipVerify)
+	default:
+		return archive, nil
+	}
+	if err != nil {
+		return releases.File{}, err
+	}
+
+	if published := strings.ToLower(strings.TrimSpace(archive.SHA256)); published != "" && published != expected {
+		return releases.File{}, fmt.Errorf("sha256 %s for %s does not match the published %s", expected, archive.Filename, published)
+	}
+	archive.SHA256 = expected
+	return archive, nil
+}
+
+// archiveInstallOptions returns the download options shared by all installs,
+// including the configured mirrors.
+func (s *Service) archiveInstallOptions(reporter progress.Reporter) (install.InstallOptions, error) {
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return install.InstallOptions{}, err
+	}
+	return install.InstallOptions{
+		Reporter:     reporter,
+		Mirrors:      cfg.Mirrors,
+		ProbeMirrors: cfg.ProbeMirrors,
+	}, nil
+}
+
+// VerifyArchive checks the cached host archive for version against the
+// published SHA256, downloading it into the cache when absent. Nothing is
+// extracted or installed. Only Reporter, Variant, InsecureSkipVerify and the
+// checksum overrides are used from opts.
+func (s *Service) VerifyArchive(ctx context.Context, version string, opts InstallOptions) (string, install.ArchiveCheck, error) {
+	normalized, err := versionutil.NormalizeGoVersion(version)
+	if err != nil {
+		return "", install.ArchiveCheck{}, err
+	}
+
+	fetcher, err := s.releaseFetcher(opts.InsecureSkipVerify)
+	if err != nil {
+		return "", install.ArchiveCheck{}, err
+	}
+	progress.Emit(opts.Reporter, "release-fetch", "Fetching Go release metadata...", 0, 0)
+	release, err := releases.FetchVersion(ctx, fetcher, normalized)
+	if err != nil {
+		return "", install.ArchiveCheck{}, err
+	}
+	archive, normalized, err := releases.FindArchiveVariant([]releases.Release{release}, normalized, runtime.GOOS, runtime.GOARCH, releases.NormalizeVariant(opts.Variant))
+	if err != nil {
+		return "", install.ArchiveCheck{}, err
+	}
+	if archive, err = overrideChecksum(ctx, archive, opts); err != nil {
+		return "", install.ArchiveCheck{}, err
+	}
+
+	installOpts, err := s.archiveInstallOptions(opts.Reporter)
+	if err != nil {
+		return "", install.ArchiveCheck{}, err
+	}
+	installOpts.InsecureSkipVerify = opts.InsecureSkipVerify
+	check, err := install.VerifyArchive(ctx, s.Paths, archive, installOpts)
+	if err != nil {
+		return "", install.ArchiveCheck{}, err
+	}
+	return normalized, check, nil
+}
+
+type InstallCheckState int
+
+const (
+	InstallCheckInstalled InstallCheckState = iota
+	InstallCheckAvailable
+	InstallCheckUnavailable
+)
+
+type InstallCheck struct {
+	Version string
+	State   InstallCheckState
+	// Size is the archive size for InstallCheckAvailable; Cached reports
+	// that the archive is already in the download cache.
+	Size   int64
+	Cached bool
+	// Reason explains InstallCheckUnavailable.
+	Reason string
+}
+
+// CheckInstall reports whether version is installed or could be installed
+// for this platform without downloading or installing anything. Installed
+// versions are answered without fetching release metadata.
+func (s *Service) CheckInstall(ctx context.Context, version string, opts InstallOptions) (InstallCheck, error) {
+	normalized, err := versionutil.NormalizeGoVersion(version)
+	if err != nil {
+		return InstallCheck{}, err
+	}
+	if switcher.ToolchainExists(s.Paths, normalized) {
+		return InstallCheck{Version: normalized, State: InstallCheckInstalled}, nil
+	}
+
+	fetcher, err := s.releaseFetcher(opts.InsecureSkipVerify)
+	if err != nil {
+		return InstallCheck{}, err
+	}
+	all, err := fetcher.Fetch(ctx)
+	if err != nil {
+		return InstallCheck{}, err
+	}
+	archive, _, err := releases.FindArchiveVariant(all, normalized, runtime.GOOS, runtime.GOARCH, releases.NormalizeVariant(opts.Variant))
+	if err != nil {
+		return InstallCheck{Version: normalized, State: InstallCheckUnavailable, Reason: err.Error()}, nil
+	}
+
+	check := InstallCheck{Version: normalized, State: InstallCheckAvailable, Size: archive.Size}
+	if info, err := os.Stat(filepath.Join(s.Paths.CacheDir, archive.Filename)); err == nil && info.Size() == archive.Size {
+		check.Cached = true
+	}
+	return check, nil
+}
+
+type PlatformInstallResult struct {
+	Platform releases.Platform
+	Dir      string
+	Err      error
+}
+
+// InstallForPlatforms downloads version for each platform using a single
+// release metadata fetch. Non-host platforms are extracted under
+// switcher.CrossToolchainDir and are not registered as runnable toolchains.
+func (s *Service) InstallForPlatforms(ctx context.Context, version string, platforms []releases.Platform, reporter progress.Reporter) (string, []PlatformInstallResult, error) {
+	normalized, err := versionutil.NormalizeGoVersion(version)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fetcher, err := s.releaseFetcher(false)
+	if err != nil {
+		return "", nil, err
+	}
+	progress.Emit(reporter, "release-fetch", "Fetching Go release metadata...", 0, 0)
+	all, err := fetcher.Fetch(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	baseOpts, err := s.archiveInstallOptions(reporter)
+	if err != nil {
+		return "", nil, err
+	}
+	index := releases.NewIndex(all)
+
+	host := releases.Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+	results := make([]PlatformInstallResult, 0, len(platforms))
+	for _, platform := range platforms {
+		result := PlatformInstallResult{Platform: platform}
+
+		archive, _, err := index.FindArchive(normalized, platform.OS, platform.Arch)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+
+		opts := baseOpts
+		if platform == host {
+			result.Dir = switcher.ToolchainDir(s.Paths, normalized)
+		} else {
+			result.Dir = switcher.CrossToolchainDir(s.Paths, normalized, platform.OS, platform.Arch)
+			opts.TargetDir = result.Dir
+		}
+
+		progress.Emit(reporter, "release-select", fmt.Sprintf("Selecting %s for %s", normalized, platform), 0, 0)
+		if err := install.InstallGoArchiveWithOptions(ctx, s.Paths, normalized, archive, opts); err != nil {
+			result.Err = err
+		}
+		results = append(results, result)
+	}
+
+	for _, result := range results {
+		if result.Platform == host && result.Err == nil {
+			progress.Emit(reporter, "shim-update", "Updating tool shims...", 0, 0)
+			if err := switcher.EnsureShims(s.Paths); err != nil {
+				return normalized, results, err
+			}
+		}
+	}
+
+	return normalized, results, nil
+}
+
+type UseOptions struct {
+	Reporter progress.Reporter
+	// ReresolveTools reinstalls golangci-lint for the target version even if
+	// a binary is already present.
+	ReresolveTools bool
+	// WriteGitignore adds the local version file to the repository's
+	// .gitignore. Only valid with local scope.
+	WriteGitignore bool
+	// PromoteLocal also rewrites the .switcher-version pin governing cwd when
+	// setting the global version, so the effective version changes too.
+	// Only valid with global scope.
+	PromoteLocal bool
+	// SetGoroot persists GOROOT for the new toolchain with its own
+	// `go env -w`. Without it, a GOROOT set by an earlier switch is cleared.
+	SetGoroot bool
+	// Record appends the outcome to the history log even when the
+	// record_history config toggle is off.
+	Record bool
+	// NoHook skips the configured post_switch_hook.
+	NoHook bool
+	// LintVersion pins golangci-lint for the target Go version instead of
+	// the recommended one; tools.LintRecommended removes the pin.
+	LintVersion string
+	// IfUnset leaves an already configured global version untouched. It
+	// requires global scope.
+	IfUnset bool
+	// Strict turns the warning about a local pin older than the go.mod go
+	// directive into an error, reported before anything is written.
+	Strict bool
+	// Alias pins the minor-line alias of the resolved version, such as
+	// go1.24.x, instead of the version itself. Without it local scope always
+	// pins the concrete resolved version so the pin is reproducible.
+	Alias bool
 }
 
 func (s *Service) Use(ctx context.Context, version string, scope switcher.Scope, cwd string) (string, string, error) {
@@ -93,38 +614,484 @@ func (s *Service) Use(ctx context.Context, version string, scope switcher.Scope,
 }
 
 func (s *Service) UseWithProgress(ctx context.Context, version string, scope switcher.Scope, cwd string, reporter progress.Reporter) (string, string, error) {
-	normalized, err := versionutil.NormalizeGoVersion(version)
+	result, err := s.UseWithOptions(ctx, version, scope, cwd, UseOptions{Reporter: reporter})
 	if err != nil {
 		return "", "", err
 	}
+	return result.Version, result.LintVersion, nil
+}
+
+type UseResult struct {
+	Version     string
+	LintVersion string
+	// LintSkipped is set when golangci-lint has no release for this platform
+	// and the lint step was skipped.
+	LintSkipped bool
+	Warnings    []string
+	// GitignoreUpdated is the .gitignore that WriteGitignore changed, if any.
+	GitignoreUpdated string
+	// PromotedLocal is the local pin file that PromoteLocal rewrote, if any.
+	PromotedLocal string
+	// GorootSet is the GOROOT persisted by SetGoroot; GorootCleared reports
+	// that a previously persisted GOROOT was removed.
+	GorootSet     string
+	GorootCleared bool
+	// Unchanged is set when IfUnset found a global version already
+	// configured; Version is that version and nothing else was done.
+	Unchanged bool
+}
+
+func (s *Service) UseWithOptions(ctx context.Context, version string, scope switcher.Scope, cwd string, opts UseOptions) (UseResult, error) {
+	result, err := s.use(ctx, version, scope, cwd, opts)
+	if err == nil && result.Unchanged {
+		return result, nil
+	}
+	if err == nil && !opts.NoHook {
+		if warning := s.runPostSwitchHook(ctx, result.Version, scope, cwd, opts.Reporter); warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
+	}
+	if warning := s.recordUse(version, scope, cwd, opts.Record, result, err); warning != "" && err == nil {
+		result.Warnings = append(result.Warnings, warning)
+	}
+	return result, err
+}
+
+// pinnedSpec returns what use writes for the requested spec, which resolved
+// to version. A minor alias is kept as written in global scope and re-resolved
+// on every lookup; local scope pins the concrete version unless alias asks
+// for the minor line.
+func pinnedSpec(spec string, version string, scope switcher.Scope, alias bool) (string, error) {
+	if alias {
+		major, minor, _, err := versionutil.ParseGoVersion(version)
+		if err != nil {
+			return "", fmt.Errorf("--alias requires a Go version, not %s", version)
+		}
+		return fmt.Sprintf("go%d.%d.x", major, minor), nil
+	}
+	if scope == switcher.ScopeLocal {
+		return version, nil
+	}
+	return spec, nil
+}
+
+// goModRequirementWarning describes why version is too old for the go
+// directive of the nearest go.mod above cwd, or returns "" when it is not.
+func goModRequirementWarning(cwd string, version string) string {
+	required, goModPath, found := switcher.FindGoModVersion(cwd)
+	if !found {
+		return ""
+	}
+	cmp, err := versionutil.CompareGoVersions(version, required)
+	if err != nil || cmp >= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s requires %s but %s is older; use %s or newer", goModPath, required, version, required)
+}
+
+// recordUse appends a history entry when recording is enabled. Failures are
+// returned as a warning so they never block the switch.
+func (s *Service) recordUse(version string, scope switcher.Scope, cwd string, record bool, result UseResult, useErr error) string {
+	if !record {
+		cfg, err := switcher.ReadConfig(s.Paths)
+		if err != nil || !cfg.RecordHistory {
+			return ""
+		}
+	}
+
+	entry := switcher.HistoryEntry{
+		Time:    time.Now().UTC(),
+		Version: version,
+		Scope:   scope,
+		Cwd:     cwd,
+		Outcome: switcher.HistoryOutcomeOK,
+	}
+	if normalized, err := versionutil.NormalizeVersionSpec(version); err == nil {
+		entry.Version = normalized
+	}
+	if result.Version != "" {
+		entry.Version = result.Version
+	}
+	if useErr != nil {
+		entry.Outcome = switcher.HistoryOutcomeFailed
+		entry.Error = useErr.Error()
+	}
+	if err := switcher.AppendHistory(s.Paths, entry); err != nil {
+		return fmt.Sprintf("could not record use history: %v", err)
+	}
+	return ""
+}
+
+type HistoryFilter struct {
+	Scope   switcher.Scope
+	Version string
+	// Limit keeps only the most recent entries. Zero returns all.
+	Limit int
+}
+
+// History returns recorded `use` entries matching filter, oldest first.
+func (s *Service) History(filter HistoryFilter) ([]switcher.HistoryEntry, error) {
+	if filter.Version != "" {
+		normalized, err := versionutil.NormalizeGoVersion(filter.Version)
+		if err != nil {
+			return nil, err
+		}
+		filter.Version = normalized
+	}
+
+	entries, err := switcher.ReadHistory(s.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]switcher.HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if filter.Scope != "" && entry.Scope != filter.Scope {
+			continue
+		}
+		if filter.Version != "" && entry.Version != filter.Version {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[len(matched)-filter.Limit:]
+	}
+	return matched, nil
+}
+
+func (s *Service) use(ctx context.Context, version string, scope switcher.Scope, cwd string, opts UseOptions) (UseResult, error) {
+	reporter := opts.Reporter
+	spec, err := versionutil.NormalizeVersionSpec(version)
+	if err != nil {
+		return UseResult{}, err
+	}
+	normalized, err := switcher.ResolveVersionSpec(s.Paths, spec)
+	if err != nil {
+		return UseResult{}, err
+	}
+	pin, err := pinnedSpec(spec, normalized, scope, opts.Alias)
+	if err != nil {
+		return UseResult{}, err
+	}
+	if opts.WriteGitignore {
+		if scope != switcher.ScopeLocal {
+			return UseResult{}, fmt.Errorf("--write-gitignore requires local scope")
+		}
+		if _, ok := switcher.FindGitRoot(cwd); !ok {
+			return UseResult{}, fmt.Errorf("--write-gitignore: no git repository found above %s", cwd)
+		}
+	}
+	if opts.PromoteLocal && scope != switcher.ScopeGlobal {
+		return UseResult{}, fmt.Errorf("--promote-local requires global scope")
+	}
+	if opts.SetGoroot && scope != switcher.ScopeGlobal {
+		// go env -w applies to every directory, not just the project.
+		return UseResult{}, fmt.Errorf("--set-goroot requires global scope")
+	}
+	if opts.IfUnset {
+		if scope != switcher.ScopeGlobal {
+			return UseResult{}, fmt.Errorf("--if-unset requires global scope")
+		}
+		existing, found, err := switcher.GlobalVersion(s.Paths)
+		if err != nil {
+			return UseResult{}, err
+		}
+		if found {
+			return UseResult{Version: existing, Unchanged: true}, nil
+		}
+	}
+	if opts.LintVersion != "" && opts.LintVersion != tools.LintRecommended {
+		pinned, err := tools.NormalizeLintVersion(opts.LintVersion)
+		if err != nil {
+			return UseResult{}, err
+		}
+		opts.LintVersion = pinned
+	}
+	var goModWarning string
+	if scope == switcher.ScopeLocal {
+		goModWarning = goModRequirementWarning(cwd, normalized)
+		if goModWarning != "" && opts.Strict {
+			return UseResult{}, errors.New(goModWarning)
+		}
+	}
 
 	if !switcher.ToolchainExists(s.Paths, normalized) {
 		progress.Emit(reporter, "go-install", fmt.Sprintf("%s is not installed yet", normalized), 0, 0)
 		if _, err := s.InstallWithProgress(ctx, normalized, reporter); err != nil {
-			return "", "", fmt.Errorf("install %s before switching: %w", normalized, err)
+			return UseResult{}, fmt.Errorf("install %s before switching: %w", normalized, err)
 		}
 	} else {
 		progress.Emit(reporter, "go-install", fmt.Sprintf("Using installed toolchain %s", normalized), 0, 0)
 	}
 
+	result := UseResult{Version: normalized}
+	if goModWarning != "" {
+		result.Warnings = append(result.Warnings, goModWarning)
+	}
+	lintVersion, lintAvailable, err := s.checkLintAvailable(ctx, normalized, opts.LintVersion, reporter)
+	if err != nil {
+		return UseResult{}, err
+	}
+	if warning := tools.CompatibilityWarning(normalized, lintVersion); warning != "" {
+		result.Warnings = append(result.Warnings, warning)
+	}
+	if !lintAvailable {
+		result.LintSkipped = true
+		result.Warnings = append(result.Warnings, fmt.Sprintf("golangci-lint %s has no release for %s/%s; skipping lint sync", lintVersion, runtime.GOOS, runtime.GOARCH))
+	}
+
 	progress.Emit(reporter, "scope-update", fmt.Sprintf("Applying %s scope...", scope), 0, 0)
-	if err := switcher.SetActiveVersion(normalized, scope, cwd, s.Paths); err != nil {
-		return "", "", err
+	if opts.IfUnset {
+		changed, err := s.SetGlobalIfUnset(pin)
+		if err != nil {
+			return UseResult{}, err
+		}
+		if !changed {
+			// Another process configured a global version during the install.
+			existing, _, err := switcher.GlobalVersion(s.Paths)
+			if err != nil {
+				return UseResult{}, err
+			}
+			return UseResult{Version: existing, Unchanged: true}, nil
+		}
+	} else if err := switcher.SetActiveVersion(pin, scope, cwd, s.Paths); err != nil {
+		return UseResult{}, err
+	}
+	if opts.PromoteLocal {
+		localVersion, localPath, found, err := switcher.FindLocalVersion(cwd)
+		if err != nil {
+			return UseResult{}, err
+		}
+		if found && localVersion != pin {
+			if err := switcher.SetLocalVersionAtPath(localPath, pin); err != nil {
+				return UseResult{}, err
+			}
+			result.PromotedLocal = localPath
+		}
+	}
+	if opts.WriteGitignore {
+		gitignorePath, changed, err := switcher.IgnoreLocalVersionFile(cwd)
+		if err != nil {
+			return UseResult{}, err
+		}
+		if changed {
+			result.GitignoreUpdated = gitignorePath
+		}
 	}
 
 	progress.Emit(reporter, "shim-update", "Refreshing shims...", 0, 0)
 	if err := switcher.EnsureShims(s.Paths); err != nil {
-		return "", "", err
+		return UseResult{}, err
 	}
 
-	progress.Emit(reporter, "lint-sync", "Syncing golangci-lint...", 0, 0)
-	lintVersion, err := s.SyncToolsForVersionWithProgress(ctx, normalized, reporter)
-	if err != nil {
-		return "", "", err
+	if scope == switcher.ScopeGlobal {
+		if err := s.applyGoroot(ctx, normalized, opts.SetGoroot, &result); err != nil {
+			return UseResult{}, err
+		}
+	}
+
+	if !result.LintSkipped {
+		progress.Emit(reporter, "lint-sync", "Syncing golangci-lint...", 0, 0)
+		lintVersion, err := s.syncToolsForVersion(ctx, normalized, tools.EnsureOptions{Reporter: reporter, Reinstall: opts.ReresolveTools, LintVersion: opts.LintVersion})
+		switch {
+		case errors.Is(err, tools.ErrUnavailable):
+			// The availability probe can miss this, e.g. when it failed on
+			// the network; the Go switch has already been applied.
+			result.LintSkipped = true
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%v; skipping lint sync", err))
+		case err != nil:
+			return UseResult{}, err
+		default:
+			result.LintVersion = lintVersion
+		}
 	}
 	progress.Emit(reporter, "done", fmt.Sprintf("Switch complete: %s (%s)", normalized, scope), 0, 0)
 
-	return normalized, lintVersion, nil
+	return result, nil
+}
+
+// applyGoroot persists or clears GOROOT through the target toolchain's own
+// go binary. Failures of `go env` are reported as warnings since newer Go
+// releases refuse to modify GOROOT this way.
+func (s *Service) applyGoroot(ctx context.Context, goVersion string, set bool, result *UseResult) error {
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return err
+	}
+	if !set && !cfg.GorootManaged {
+		return nil
+	}
+
+	goBinary, err := switcher.GoToolBinary(s.Paths, goVersion, "go")
+	if err != nil {
+		return err
+	}
+
+	if set {
+		goroot := switcher.ToolchainDir(s.Paths, goVersion)
+		result.Warnings = append(result.Warnings, "persisting GOROOT is usually unnecessary when the switcher shims are on PATH")
+		if err := runGoEnv(ctx, goBinary, "-w", "GOROOT="+goroot); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("could not persist GOROOT: %v", err))
+			return nil
+		}
+		result.GorootSet = goroot
+		return setGorootManaged(s.Paths, true)
+	}
+
+	if err := runGoEnv(ctx, goBinary, "-u", "GOROOT"); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("could not clear GOROOT set by an earlier --set-goroot: %v", err))
+		return nil
+	}
+	result.GorootCleared = true
+	return setGorootManaged(s.Paths, false)
+}
+
+func setGorootManaged(paths switcher.Paths, managed bool) error {
+	return switcher.UpdateConfig(paths, func(cfg *switcher.Config) bool {
+		if cfg.GorootManaged == managed {
+			return false
+		}
+		cfg.GorootManaged = managed
+		return true
+	})
+}
+
+// minTelemetryVersion is the first release with the `go telemetry` command.
+const minTelemetryVersion = "go1.23.0"
+
+func ValidateTelemetryMode(mode string) error {
+	switch mode {
+	case "", "off", "local", "on":
+		return nil
+	default:
+		return fmt.Errorf("invalid go telemetry mode %q (expected off, local or on)", mode)
+	}
+}
+
+// applyTelemetry runs `go telemetry <mode>` with the toolchain for goVersion.
+// The mode is user-wide in Go, not per toolchain. Older toolchains and
+// command failures are reported as progress warnings, not errors.
+func (s *Service) applyTelemetry(ctx context.Context, goVersion string, mode string, reporter progress.Reporter) error {
+	if mode == "" {
+		cfg, err := switcher.ReadConfig(s.Paths)
+		if err != nil {
+			return err
+		}
+		mode = cfg.GoTelemetry
+		if err := ValidateTelemetryMode(mode); err != nil {
+			return fmt.Errorf("config go_telemetry: %w", err)
+		}
+	}
+	if mode == "" {
+		return nil
+	}
+
+	if cmp, err := versionutil.CompareGoVersions(goVersion, minTelemetryVersion); err != nil || cmp < 0 {
+		progress.Emit(reporter, "go-telemetry", fmt.Sprintf("Skipping go telemetry %s: %s has no telemetry command", mode, goVersion), 0, 0)
+		return nil
+	}
+
+	goBinary, err := switcher.GoToolBinary(s.Paths, goVersion, "go")
+	if err != nil {
+		return err
+	}
+	progress.Emit(reporter, "go-telemetry", fmt.Sprintf("Running go telemetry %s", mode), 0, 0)
+	if err := runGo(ctx, goBinary, "telemetry", mode); err != nil {
+		progress.EmitWarning(reporter, "go-telemetry", fmt.Errorf("go telemetry %s failed: %w", mode, err))
+	}
+	return nil
+}
+
+func runGoEnv(ctx context.Context, goBinary string, args ...string) error {
+	return runGo(ctx, goBinary, append([]string{"env"}, args...)...)
+}
+
+func runGo(ctx context.Context, goBinary string, args ...string) error {
+	output, err := exec.CommandContext(ctx, goBinary, args...).CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return errors.New(message)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkLintAvailable verifies the golangci-lint release for goVersion exists
+// before the Go switch is committed. Network failures are not treated as
+// unavailability; the later install step reports them.
+func (s *Service) checkLintAvailable(ctx context.Context, goVersion string, pin string, reporter progress.Reporter) (string, bool, error) {
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return "", false, err
+	}
+	if cfg.GolangCILintDefault != "" {
+		if _, err := tools.NormalizeLintVersion(cfg.GolangCILintDefault); err != nil {
+			return "", false, fmt.Errorf("golangci_lint_default: %w", err)
+		}
+	}
+
+	// The pin is only persisted by the lint sync; apply it to this copy so
+	// the probe checks the version that will be installed.
+	switch pin {
+	case "":
+	case tools.LintRecommended:
+		tools.UnpinLintVersion(&cfg, goVersion)
+	default:
+		tools.PinLintVersion(&cfg, goVersion, pin)
+	}
+	lintVersion := tools.ResolveLintVersion(cfg, goVersion)
+	progress.Emit(reporter, "lint-check", fmt.Sprintf("Checking golangci-lint %s availability...", lintVersion), 0, 0)
+	ok, err := tools.CheckAvailable(ctx, s.Paths, lintVersion)
+	if err != nil {
+		return lintVersion, true, nil
+	}
+	return lintVersion, ok, nil
+}
+
+// PinLint pins golangci-lint lintVersion for goVersion, or for the version
+// active in cwd when goVersion is empty, so later syncs install it instead of
+// the recommended release. tools.LintRecommended drops the pin. It returns
+// the Go version and the golangci-lint version that now resolves for it.
+func (s *Service) PinLint(cwd string, goVersion string, lintVersion string) (string, string, error) {
+	if strings.TrimSpace(goVersion) == "" {
+		active, err := switcher.ResolveActiveVersion(cwd, s.Paths)
+		if err != nil {
+			return "", "", err
+		}
+		goVersion = active.Version
+	} else {
+		normalized, err := versionutil.NormalizeGoVersion(goVersion)
+		if err != nil {
+			return "", "", err
+		}
+		goVersion = normalized
+	}
+
+	pinned := lintVersion
+	if lintVersion != tools.LintRecommended {
+		normalized, err := tools.NormalizeLintVersion(lintVersion)
+		if err != nil {
+			return "", "", err
+		}
+		pinned = normalized
+	}
+
+	var resolved string
+	err := switcher.UpdateConfig(s.Paths, func(cfg *switcher.Config) bool {
+		if pinned == tools.LintRecommended {
+			tools.UnpinLintVersion(cfg, goVersion)
+		} else {
+			tools.PinLintVersion(cfg, goVersion, pinned)
+		}
+		resolved = tools.ResolveLintVersion(*cfg, goVersion)
+		return true
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return goVersion, resolved, nil
 }
 
 func (s *Service) SyncTools(ctx context.Context, cwd string, scopeOverride string) (string, string, error) {
@@ -154,7 +1121,10 @@ func (s *Service) SyncTools(ctx context.Context, cwd string, scopeOverride strin
 			if !found {
 				return "", "", fmt.Errorf("no local .switcher-version found")
 			}
-			activeVersion = localVersion
+			activeVersion, err = switcher.ResolveVersionSpec(s.Paths, localVersion)
+			if err != nil {
+				return "", "", err
+			}
 		case switcher.ScopeGlobal:
 			globalVersion, found, globalErr := switcher.GlobalVersion(s.Paths)
 			if globalErr != nil {
@@ -163,7 +1133,10 @@ func (s *Service) SyncTools(ctx context.Context, cwd string, scopeOverride strin
 			if !found {
 				return "", "", fmt.Errorf("no global version configured")
 			}
-			activeVersion = globalVersion
+			activeVersion, err = switcher.ResolveVersionSpec(s.Paths, globalVersion)
+			if err != nil {
+				return "", "", err
+			}
 		}
 	}
 
@@ -180,27 +1153,128 @@ func (s *Service) SyncToolsForVersion(ctx context.Context, goVersion string) (st
 }
 
 func (s *Service) SyncToolsForVersionWithProgress(ctx context.Context, goVersion string, reporter progress.Reporter) (string, error) {
+	return s.syncToolsForVersion(ctx, goVersion, tools.EnsureOptions{Reporter: reporter})
+}
+
+func (s *Service) syncToolsForVersion(ctx context.Context, goVersion string, opts tools.EnsureOptions) (string, error) {
 	cfg, err := switcher.ReadConfig(s.Paths)
 	if err != nil {
 		return "", err
 	}
 
-	lintVersion, err := tools.EnsureForGoVersionWithOptions(ctx, s.Paths, &cfg, goVersion, tools.EnsureOptions{Reporter: reporter})
+	lintVersion, err := tools.EnsureForGoVersionWithOptions(ctx, s.Paths, &cfg, goVersion, opts)
 	if err != nil {
 		return "", err
 	}
 
-	if err := switcher.WriteConfig(s.Paths, cfg); err != nil {
+	// The install ran without the config lock, so map the version again
+	// under it rather than writing back a config read before the download.
+	opts.Reporter = nil
+	if err := switcher.UpdateConfig(s.Paths, func(cfg *switcher.Config) bool {
+		_, err := tools.MapLintVersion(cfg, goVersion, opts)
+		return err == nil
+	}); err != nil {
 		return "", err
 	}
 
 	return lintVersion, nil
 }
 
-func (s *Service) DeleteInstalledWithProgress(ctx context.Context, cwd string, version string, reporter progress.Reporter) (switcher.DeleteResult, error) {
-	normalized, err := versionutil.NormalizeGoVersion(version)
+type ToolSyncState int
+
+const (
+	// ToolSyncPresent means the lint binary was already installed.
+	ToolSyncPresent ToolSyncState = iota
+	ToolSyncInstalled
+	// ToolSyncShared means the binary was handled for an earlier Go version
+	// in the same run that maps to the same golangci-lint release.
+	ToolSyncShared
+	ToolSyncWouldInstall
+	ToolSyncFailed
+)
+
+type ToolSyncResult struct {
+	GoVersion   string
+	LintVersion string
+	State       ToolSyncState
+	Err         error
+}
+
+// SyncAllInstalledTools installs the golangci-lint binary resolved for every
+// installed Go version. Each lint release is installed at most once, and a
+// failure for one version does not stop the others. With dryRun nothing is
+// downloaded and the config is left untouched.
+func (s *Service) SyncAllInstalledTools(ctx context.Context, dryRun bool, reporter progress.Reporter) ([]ToolSyncResult, error) {
+	versions, err := s.ListLocal()
 	if err != nil {
-		return switcher.DeleteResult{}, err
+		return nil, err
+	}
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	handled := map[string]error{}
+	var mapped []string
+	results := make([]ToolSyncResult, 0, len(versions))
+	for _, goVersion := range versions {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		lintVersion := tools.ResolveLintVersion(cfg, goVersion)
+		result := ToolSyncResult{GoVersion: goVersion, LintVersion: lintVersion}
+		prevErr, seen := handled[lintVersion]
+		switch {
+		case seen && prevErr != nil:
+			result.State, result.Err = ToolSyncFailed, prevErr
+		case seen:
+			result.State = ToolSyncShared
+			if !dryRun {
+				mapped = append(mapped, goVersion)
+			}
+		case dryRun:
+			result.State = ToolSyncWouldInstall
+			if _, err := os.Stat(tools.GolangCILintBinaryPath(s.Paths, lintVersion)); err == nil {
+				result.State = ToolSyncPresent
+			}
+			handled[lintVersion] = nil
+		default:
+			_, statErr := os.Stat(tools.GolangCILintBinaryPath(s.Paths, lintVersion))
+			mapped = append(mapped, goVersion)
+			if _, err := tools.EnsureForGoVersionWithOptions(ctx, s.Paths, &cfg, goVersion, tools.EnsureOptions{Reporter: reporter}); err != nil {
+				result.State, result.Err = ToolSyncFailed, err
+			} else if statErr == nil {
+				result.State = ToolSyncPresent
+			} else {
+				result.State = ToolSyncInstalled
+			}
+			handled[lintVersion] = result.Err
+		}
+		results = append(results, result)
+	}
+
+	if dryRun || len(mapped) == 0 {
+		return results, nil
+	}
+	return results, switcher.UpdateConfig(s.Paths, func(cfg *switcher.Config) bool {
+		for _, goVersion := range mapped {
+			_, _ = tools.MapLintVersion(cfg, goVersion, tools.EnsureOptions{})
+		}
+		return true
+	})
+}
+
+// DeleteInstalledWithProgress removes an installed toolchain. For a linked
+// toolchain only the link is removed.
+func (s *Service) DeleteInstalledWithProgress(ctx context.Context, cwd string, version string, reporter progress.Reporter) (switcher.DeleteResult, error) {
+	normalized, isLink := versionutil.ParseLinkName(version)
+	if !isLink {
+		var err error
+		normalized, err = versionutil.NormalizeGoVersion(version)
+		if err != nil {
+			return switcher.DeleteResult{}, err
+		}
 	}
 
 	progress.Emit(reporter, "delete", fmt.Sprintf("Removing toolchain %s...", normalized), 0, 0)
@@ -218,9 +1292,18 @@ func (s *Service) DeleteInstalledWithProgress(ctx context.Context, cwd string, v
 	if err := s.deleteLintMapping(normalized); err != nil {
 		return switcher.DeleteResult{}, err
 	}
+	if err := switcher.RemoveFromGlobalHistory(s.Paths, normalized); err != nil {
+		return switcher.DeleteResult{}, err
+	}
 
 	result := switcher.DeleteResult{DeletedVersion: normalized}
-	if !hasActive || active.Version != normalized {
+	aliasResolves := false
+	if hasActive && active.Alias != "" {
+		// An alias pin follows the next installed patch of its line on its own.
+		_, aliasErr := switcher.ResolveVersionSpec(s.Paths, active.Alias)
+		aliasResolves = aliasErr == nil
+	}
+	if !hasActive || active.Version != normalized || aliasResolves {
 		current, err := s.Current(cwd)
 		if err == nil {
 			result.ActiveAfter = current
@@ -230,6 +1313,12 @@ func (s *Service) DeleteInstalledWithProgress(ctx context.Context, cwd string, v
 	}
 
 	result.WasActive = true
+	if active.SourceKind == switcher.SourceGoMod {
+		// go.mod is the project's file, not ours to rewrite; it keeps asking
+		// for the deleted version until it is installed again or pinned.
+		progress.Emit(reporter, "delete", fmt.Sprintf("Deleted active version; %s still asks for it", active.Source), 0, 0)
+		return result, nil
+	}
 
 	remaining, err := s.ListLocal()
 	if err != nil {
@@ -273,7 +1362,7 @@ func (s *Service) DeleteInstalledWithProgress(ctx context.Context, cwd string, v
 	progress.Emit(reporter, "lint-sync", "Syncing golangci-lint for new active version...", 0, 0)
 	if _, err := s.SyncToolsForVersionWithProgress(ctx, newest, reporter); err != nil {
 		result.ToolSyncWarning = err.Error()
-		progress.Emit(reporter, "lint-sync", fmt.Sprintf("Warning: %s", err.Error()), 0, 0)
+		progress.EmitWarning(reporter, "lint-sync", err)
 	}
 
 	current, err := s.Current(cwd)
@@ -286,25 +1375,26 @@ func (s *Service) DeleteInstalledWithProgress(ctx context.Context, cwd string, v
 }
 
 func (s *Service) deleteLintMapping(goVersion string) error {
+	return switcher.UpdateConfig(s.Paths, func(cfg *switcher.Config) bool {
+		if _, ok := cfg.GolangCILintByGo[goVersion]; !ok {
+			return false
+		}
+		delete(cfg.GolangCILintByGo, goVersion)
+		return true
+	})
+}
+
+func (s *Service) ResolveBinaryForTool(cwd string, tool string) (string, string, error) {
 	cfg, err := switcher.ReadConfig(s.Paths)
 	if err != nil {
-		return err
-	}
-
-	if cfg.GolangCILintByGo == nil {
-		return nil
-	}
-
-	if _, ok := cfg.GolangCILintByGo[goVersion]; !ok {
-		return nil
+		return "", "", err
 	}
-
-	delete(cfg.GolangCILintByGo, goVersion)
-	return switcher.WriteConfig(s.Paths, cfg)
+	return s.resolveBinaryForTool(cwd, tool, cfg)
 }
 
-func (s *Service) ResolveBinaryForTool(cwd string, tool string) (string, string, error) {
-	active, err := switcher.ResolveActiveVersion(cwd, s.Paths)
+// resolveBinaryForTool is ResolveBinaryForTool with the config already read.
+func (s *Service) resolveBinaryForTool(cwd string, tool string, cfg switcher.Config) (string, string, error) {
+	active, err := switcher.ResolveActiveVersionWithConfig(cwd, s.Paths, cfg)
 	if err != nil {
 		return "", "", err
 	}
@@ -315,14 +1405,16 @@ func (s *Service) ResolveBinaryForTool(cwd string, tool string) (string, string,
 		if err != nil {
 			return "", "", err
 		}
+		if err := switcher.EnsureExecutable(s.Paths, binary); err != nil {
+			return "", "", err
+		}
 		return binary, active.Version, nil
 	case "golangci-lint":
-		cfg, err := switcher.ReadConfig(s.Paths)
+		binary, _, err := tools.ResolveBinary(s.Paths, cfg, active.Version)
 		if err != nil {
 			return "", "", err
 		}
-		binary, _, err := tools.ResolveBinary(s.Paths, cfg, active.Version)
-		if err != nil {
+		if err := switcher.EnsureExecutable(s.Paths, binary); err != nil {
 			return "", "", err
 		}
 		return binary, active.Version, nil
@@ -331,6 +1423,185 @@ func (s *Service) ResolveBinaryForTool(cwd string, tool string) (string, string,
 	}
 }
 
+type VerifyResult struct {
+	Version     string
+	HasManifest bool
+	Problems    []string
+}
+
+// Verify audits one installed version, or all of them when version is
+// empty. It never modifies the toolchains.
+func (s *Service) Verify(ctx context.Context, version string) ([]VerifyResult, error) {
+	versions, err := s.ListLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(version) != "" {
+		normalized, err := versionutil.NormalizeGoVersion(version)
+		if err != nil {
+			return nil, err
+		}
+		if !switcher.ToolchainExists(s.Paths, normalized) {
+			return nil, switcher.NotInstalled(normalized)
+		}
+		versions = []string{normalized}
+	}
+
+	results := make([]VerifyResult, 0, len(versions))
+	for _, v := range versions {
+		problems, hasManifest := install.VerifyToolchain(ctx, switcher.ToolchainDir(s.Paths, v), v)
+		results = append(results, VerifyResult{Version: v, HasManifest: hasManifest, Problems: problems})
+	}
+	return results, nil
+}
+
+type InstalledVersionDetail struct {
+	Version string
+	// LintVersion is the golangci-lint version mapped to Version in the
+	// config, or empty when none has been synced yet.
+	LintVersion string
+	Size        int64
+}
+
+// InstalledVersionDetails returns the config mapping and on-disk size of each
+// installed toolchain in versions.
+func (s *Service) InstalledVersionDetails(versions []string) ([]InstalledVersionDetail, error) {
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]InstalledVersionDetail, 0, len(versions))
+	for _, version := range versions {
+		size, err := pathSize(switcher.ToolchainDir(s.Paths, version))
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, InstalledVersionDetail{
+			Version:     version,
+			LintVersion: strings.TrimSpace(cfg.GolangCILintByGo[version]),
+			Size:        size,
+		})
+	}
+	return details, nil
+}
+
+// Link registers goroot, such as a Go built from source, as the linked
+// toolchain name after checking that its bin/go runs. It also returns the
+// `go version` output.
+func (s *Service) Link(ctx context.Context, name string, goroot string) (switcher.LinkedToolchain, string, error) {
+	if _, ok := versionutil.ParseLinkName(name); !ok {
+		return switcher.LinkedToolchain{}, "", fmt.Errorf("invalid link name %q (use letters, digits, '.', '-' or '_', starting with a letter and not a Go version)", name)
+	}
+	goBinary := switcher.GoBinaryPath(goroot)
+	output, err := exec.CommandContext(ctx, goBinary, "version").CombinedOutput()
+	if err != nil {
+		return switcher.LinkedToolchain{}, "", fmt.Errorf("%s is not a working Go installation: %v", goroot, err)
+	}
+
+	link, err := switcher.LinkToolchain(s.Paths, name, goroot)
+	if err != nil {
+		return switcher.LinkedToolchain{}, "", err
+	}
+	return link, strings.TrimSpace(string(output)), nil
+}
+
+// Unlink removes a linked toolchain without touching its GOROOT.
+func (s *Service) Unlink(name string) error {
+	if err := switcher.UnlinkToolchain(s.Paths, name); err != nil {
+		return err
+	}
+	if err := s.deleteLintMapping(name); err != nil {
+		return err
+	}
+	return switcher.RemoveFromGlobalHistory(s.Paths, name)
+}
+
+func (s *Service) ListLinked() ([]switcher.LinkedToolchain, error) {
+	return switcher.ListLinkedToolchains(s.Paths)
+}
+
+// PreviousGlobal returns the global version that was active steps global
+// switches ago, as used by `use -N`.
+func (s *Service) PreviousGlobal(steps int) (string, error) {
+	return switcher.PreviousGlobalVersion(s.Paths, steps)
+}
+
+// GlobalHistory returns the previous global versions, most recent first.
+func (s *Service) GlobalHistory() ([]string, error) {
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GlobalHistory, nil
+}
+
+// SetGlobalIfUnset sets the global Go version only when none is configured,
+// so provisioning scripts never clobber an existing choice. It does not
+// install the toolchain.
+func (s *Service) SetGlobalIfUnset(version string) (bool, error) {
+	return switcher.SetGlobalVersionIfUnset(s.Paths, version)
+}
+
+type ToolStatus struct {
+	Version    string
+	Installed  bool
+	BinaryPath string
+}
+
+// ActiveToolVersions resolves the managed tool versions for the Go version
+// active in cwd, keyed by tool name.
+func (s *Service) ActiveToolVersions(cwd string) (map[string]ToolStatus, error) {
+	active, err := switcher.ResolveActiveVersion(cwd, s.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := map[string]ToolStatus{}
+	binaryPath, lintVersion, err := tools.ResolveBinary(s.Paths, cfg, active.Version)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	statuses["golangci-lint"] = ToolStatus{
+		Version:    lintVersion,
+		Installed:  err == nil,
+		BinaryPath: binaryPath,
+	}
+
+	return statuses, nil
+}
+
+// PreferredScope returns the remembered TUI scope when scope memory is enabled.
+func (s *Service) PreferredScope() (switcher.Scope, bool, error) {
+	cfg, err := switcher.ReadConfig(s.Paths)
+	if err != nil {
+		return "", false, err
+	}
+	if !cfg.RememberScope || cfg.DefaultScope == "" {
+		return "", false, nil
+	}
+	// ReadConfig has already validated the scope.
+	return switcher.Scope(cfg.DefaultScope), true, nil
+}
+
+// RememberScope stores scope as the default for the next launch. It is a no-op
+// unless remember_scope is enabled in config.
+func (s *Service) RememberScope(scope switcher.Scope) error {
+	return switcher.UpdateConfig(s.Paths, func(cfg *switcher.Config) bool {
+		if !cfg.RememberScope || cfg.DefaultScope == string(scope) {
+			return false
+		}
+		cfg.DefaultScope = string(scope)
+		return true
+	})
+}
+
 func (s *Service) EnsureShims() error {
 	return switcher.EnsureShims(s.Paths)
 }
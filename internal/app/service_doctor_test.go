@@ -0,0 +1,90 @@
+package app
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShadowCheck(t *testing.T) {
+	t.Parallel()
+
+	shim := filepath.Join(t.TempDir(), "bin", "go")
+	tests := []struct {
+		name       string
+		found      string
+		err        error
+		wantOK     bool
+		wantDetail string
+	}{
+		{name: "shim first", found: shim, wantOK: true},
+		{name: "shadowed", found: "/usr/local/go/bin/go", wantDetail: "/usr/local/go/bin/go, which shadows the switcher shim"},
+		{name: "missing", err: exec.ErrNotFound, wantDetail: "not found on PATH"},
+	}
+	for _, tc := range tests {
+		check := shadowCheck("go", shim, tc.found, tc.err)
+		if check.OK != tc.wantOK || !strings.Contains(check.Detail, tc.wantDetail) {
+			t.Fatalf("%s: unexpected check %+v", tc.name, check)
+		}
+	}
+}
+
+func TestDoctor_ReportsShadowedTools(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	svc := &Service{Paths: paths}
+	lookPath := func(tool string) (string, error) {
+		if tool == "go" {
+			return "/usr/bin/go", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	checks := svc.doctor(t.TempDir(), lookPath, func(string) string { return "" })
+	byName := map[string]DoctorCheck{}
+	for _, check := range checks {
+		byName[check.Name] = check
+	}
+	if check := byName["go on PATH"]; check.OK || !strings.Contains(check.Detail, "/usr/bin/go") {
+		t.Fatalf("expected shadowing go to be reported, got %+v", check)
+	}
+	if check := byName["golangci-lint on PATH"]; check.OK {
+		t.Fatalf("expected missing golangci-lint to be reported, got %+v", check)
+	}
+	if check := byName["shims"]; check.OK {
+		t.Fatalf("expected missing shims to be reported, got %+v", check)
+	}
+	if check := byName["GOTOOLCHAIN"]; !check.OK {
+		t.Fatalf("expected unset GOTOOLCHAIN to pass, got %+v", check)
+	}
+}
+
+func TestGoToolchainOverride(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value  string
+		active string
+		want   string
+	}{
+		{value: "", active: "go1.25.0"},
+		{value: "local", active: "go1.25.0"},
+		{value: "auto", active: "go1.25.0"},
+		{value: "path", active: "go1.25.0"},
+		{value: "local+auto", active: "go1.25.0"},
+		{value: "go1.25.0", active: "go1.25.0"},
+		{value: "go1.22.0", active: "go1.25.0", want: "makes go run go1.22.0 instead of the switcher version go1.25.0"},
+		{value: "go1.26.1+auto", active: "go1.25.0", want: "makes go run go1.26.1 instead"},
+		{value: "go1.22.0", want: "regardless of the switcher version"},
+		{value: "banana", active: "go1.25.0", want: "not a toolchain go understands"},
+	}
+	for _, tc := range tests {
+		got := goToolchainOverride(tc.value, tc.active)
+		if (tc.want == "") != (got == "") || !strings.Contains(got, tc.want) {
+			t.Fatalf("%q with %q: expected %q, got %q", tc.value, tc.active, tc.want, got)
+		}
+	}
+}
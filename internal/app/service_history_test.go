@@ -0,0 +1,62 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+func TestRecordUse_FiltersHistoryAndNeverFails(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	svc := &Service{Paths: paths}
+
+	if warning := svc.recordUse("1.24.2", switcher.ScopeGlobal, projectDir, false, UseResult{}, nil); warning != "" {
+		t.Fatalf("unexpected warning %q", warning)
+	}
+	if entries, err := svc.History(HistoryFilter{}); err != nil || len(entries) != 0 {
+		t.Fatalf("expected nothing recorded without --record, got %v (%v)", entries, err)
+	}
+
+	svc.recordUse("1.24.2", switcher.ScopeGlobal, projectDir, true, UseResult{Version: "go1.24.2"}, nil)
+	svc.recordUse("1.25.0", switcher.ScopeLocal, projectDir, true, UseResult{}, errors.New("download failed"))
+	if err := switcher.WriteConfig(paths, switcher.Config{RecordHistory: true}); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	svc.recordUse("1.25.0", switcher.ScopeLocal, projectDir, false, UseResult{Version: "go1.25.0"}, nil)
+
+	local, err := svc.History(HistoryFilter{Scope: switcher.ScopeLocal})
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(local) != 2 || local[0].Outcome != switcher.HistoryOutcomeFailed || local[1].Outcome != switcher.HistoryOutcomeOK {
+		t.Fatalf("unexpected local history %+v", local)
+	}
+	byVersion, err := svc.History(HistoryFilter{Version: "1.24.2"})
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(byVersion) != 1 || byVersion[0].Scope != switcher.ScopeGlobal {
+		t.Fatalf("unexpected version history %+v", byVersion)
+	}
+	latest, err := svc.History(HistoryFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(latest) != 1 || latest[0].Version != "go1.25.0" || latest[0].Outcome != switcher.HistoryOutcomeOK {
+		t.Fatalf("unexpected latest history %+v", latest)
+	}
+
+	if err := os.Remove(switcher.HistoryFile(paths)); err != nil {
+		t.Fatalf("remove history: %v", err)
+	}
+	if err := os.Mkdir(switcher.HistoryFile(paths), 0o755); err != nil {
+		t.Fatalf("block history: %v", err)
+	}
+	if warning := svc.recordUse("1.25.0", switcher.ScopeGlobal, projectDir, true, UseResult{}, nil); warning == "" {
+		t.Fatalf("expected a warning when the history log cannot be written")
+	}
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/progress"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+func TestRunPostSwitchHook(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		hook        string
+		wantWarning string
+		wantOutput  string
+	}{
+		{name: "unset"},
+		{name: "placeholders", hook: `echo "{{.Version}} {{.Scope}}" > hook.out; echo done`, wantOutput: "done"},
+		{name: "failure warns", hook: "echo broken >&2; exit 3", wantWarning: "post-switch hook failed", wantOutput: "broken"},
+		{name: "bad template", hook: "echo {{.Missing}}", wantWarning: "post-switch hook skipped"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			paths, projectDir := testPaths(t)
+			svc := &Service{Paths: paths}
+			if err := switcher.WriteConfig(paths, switcher.Config{PostSwitchHook: tc.hook}); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+
+			var lines []string
+			reporter := func(event progress.Event) {
+				if event.Stage == "post-switch-hook" {
+					lines = append(lines, event.Message)
+				}
+			}
+			warning := svc.runPostSwitchHook(context.Background(), "go1.24.2", switcher.ScopeLocal, projectDir, reporter)
+			if tc.wantWarning == "" && warning != "" || !strings.Contains(warning, tc.wantWarning) {
+				t.Fatalf("expected warning %q, got %q", tc.wantWarning, warning)
+			}
+			if tc.wantOutput != "" && (len(lines) == 0 || lines[len(lines)-1] != tc.wantOutput) {
+				t.Fatalf("expected hook output %q as progress, got %v", tc.wantOutput, lines)
+			}
+			if tc.name != "placeholders" {
+				return
+			}
+			written, err := os.ReadFile(filepath.Join(projectDir, "hook.out"))
+			if err != nil {
+				t.Fatalf("read hook output: %v", err)
+			}
+			if strings.TrimSpace(string(written)) != "go1.24.2 local" {
+				t.Fatalf("unexpected rendered hook output %q", written)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/progress"
+	"github.com/mrtuuro/go-switcher/internal/releases"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+func TestApplyTelemetry_SkipsOldToolchainsAndUsesConfigDefault(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	logPath := filepath.Join(t.TempDir(), "go-telemetry.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\n"
+	for _, version := range []string{"go1.22.5", "go1.23.0"} {
+		mustWriteToolchain(t, paths, version)
+		goBinary := filepath.Join(switcher.ToolchainDir(paths, version), "bin", "go")
+		if err := os.WriteFile(goBinary, []byte(script), 0o755); err != nil {
+			t.Fatalf("write fake go: %v", err)
+		}
+	}
+
+	svc := &Service{Paths: paths}
+	if err := svc.applyTelemetry(context.Background(), "go1.23.0", "", nil); err != nil {
+		t.Fatalf("apply telemetry without mode: %v", err)
+	}
+	if err := svc.applyTelemetry(context.Background(), "go1.22.5", "off", nil); err != nil {
+		t.Fatalf("apply telemetry on old toolchain: %v", err)
+	}
+	if err := switcher.WriteConfig(paths, switcher.Config{GoTelemetry: "local"}); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := svc.applyTelemetry(context.Background(), "go1.23.0", "", nil); err != nil {
+		t.Fatalf("apply telemetry from config: %v", err)
+	}
+	if err := svc.applyTelemetry(context.Background(), "go1.23.0", "off", nil); err != nil {
+		t.Fatalf("apply telemetry off: %v", err)
+	}
+
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if want := "telemetry local\ntelemetry off\n"; string(logged) != want {
+		t.Fatalf("expected go calls %q, got %q", want, string(logged))
+	}
+
+	if err := ValidateTelemetryMode("disabled"); err == nil {
+		t.Fatalf("expected invalid telemetry mode to be rejected")
+	}
+}
+
+func TestInstallWithOptions_SkipsDownloadWhenInstalled(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+
+	fetcher := &fakeFetcher{}
+	svc := &Service{Paths: paths, ReleaseClient: fetcher}
+	var messages []string
+	reporter := func(event progress.Event) {
+		messages = append(messages, event.Message)
+	}
+
+	result, err := svc.InstallWithOptions(context.Background(), "1.24.2", InstallOptions{Reporter: reporter})
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if result.Version != "go1.24.2" || !result.AlreadyInstalled {
+		t.Fatalf("expected go1.24.2 already installed, got %+v", result)
+	}
+	if fetcher.calls != 0 {
+		t.Fatalf("expected no release metadata fetch, got %d", fetcher.calls)
+	}
+	if len(messages) == 0 || messages[0] != "go1.24.2 already installed (skipped download)" {
+		t.Fatalf("expected skip event first, got %q", messages)
+	}
+}
+
+func TestOverrideChecksum(t *testing.T) {
+	t.Parallel()
+
+	sumA := strings.Repeat("a", 64)
+	sumB := strings.Repeat("b", 64)
+	listPath := filepath.Join(t.TempDir(), "SHA256SUMS")
+	if err := os.WriteFile(listPath, []byte(sumB+"  go1.24.2.linux-amd64.tar.gz\n"), 0o644); err != nil {
+		t.Fatalf("write checksums: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		published string
+		opts      InstallOptions
+		want      string
+		wantErr   string
+	}{
+		{name: "no override", published: sumA, want: sumA},
+		{name: "sha256 without published", opts: InstallOptions{SHA256: strings.ToUpper(sumA)}, want: sumA},
+		{name: "sha256 matches published", published: sumA, opts: InstallOptions{SHA256: sumA}, want: sumA},
+		{name: "sha256 conflicts", published: sumA, opts: InstallOptions{SHA256: sumB}, wantErr: "does not match the published"},
+		{name: "invalid sha256", opts: InstallOptions{SHA256: "abc"}, wantErr: "invalid sha256"},
+		{name: "checksums file", opts: InstallOptions{Checksums: listPath}, want: sumB},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			archive := releases.File{Filename: "go1.24.2.linux-amd64.tar.gz", SHA256: tc.published}
+			got, err := overrideChecksum(context.Background(), archive, tc.opts)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got.SHA256 != tc.want {
+				t.Fatalf("expected %s, got %q (%v)", tc.want, got.SHA256, err)
+			}
+		})
+	}
+
+	archive := releases.File{Filename: "go1.24.2.darwin-arm64.tar.gz"}
+	if _, err := overrideChecksum(context.Background(), archive, InstallOptions{Checksums: listPath}); err == nil || !strings.Contains(err.Error(), "no sha256 for go1.24.2.darwin-arm64.tar.gz") {
+		t.Fatalf("expected missing checksum error, got %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/tools"
+)
+
+func TestExport_IncludesInstalledVersionsAndMappings(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	mustWriteToolchain(t, paths, "go1.25.0")
+
+	cfg := switcher.Config{
+		GlobalVersion: "go1.25.0",
+		GolangCILintByGo: map[string]string{
+			"go1.25.0": "v2.9.0",
+			"go1.20.0": "v1.54.2",
+		},
+	}
+	if err := switcher.WriteConfig(paths, cfg); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	manifest, err := svc.Export()
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	if manifest.GlobalVersion != "go1.25.0" {
+		t.Fatalf("expected global go1.25.0, got %q", manifest.GlobalVersion)
+	}
+	if len(manifest.Versions) != 2 || manifest.Versions[0] != "go1.25.0" || manifest.Versions[1] != "go1.24.2" {
+		t.Fatalf("unexpected versions %v", manifest.Versions)
+	}
+	if len(manifest.GolangCILintByGo) != 1 || manifest.GolangCILintByGo["go1.25.0"] != "v2.9.0" {
+		t.Fatalf("expected only installed version mappings, got %v", manifest.GolangCILintByGo)
+	}
+}
+
+func TestExportImport_RoundTripsLintPins(t *testing.T) {
+	t.Parallel()
+
+	source, _ := testPaths(t)
+	mustWriteToolchain(t, source, "go1.24.2")
+	mustWriteToolchain(t, source, "go1.25.0")
+	cfg := switcher.Config{GolangCILintByGo: map[string]string{"go1.25.0": "v2.9.0"}}
+	tools.PinLintVersion(&cfg, "go1.24.2", "v1.60.3")
+	if err := switcher.WriteConfig(source, cfg); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	exported, err := (&Service{Paths: source}).Export()
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(exported); err != nil {
+		t.Fatalf("encode manifest: %v", err)
+	}
+	manifest, err := DecodeManifest(&buf)
+	if err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+
+	target, _ := testPaths(t)
+	mustWriteToolchain(t, target, "go1.24.2")
+	mustWriteToolchain(t, target, "go1.25.0")
+	result, err := (&Service{Paths: target}).Import(context.Background(), manifest, false, nil)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !slices.Equal(result.LintPinned, []string{"go1.24.2"}) {
+		t.Fatalf("expected go1.24.2 to be reported as pinned, got %v", result.LintPinned)
+	}
+
+	imported, err := switcher.ReadConfig(target)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if !slices.Equal(imported.GolangCILintPinned, []string{"go1.24.2"}) {
+		t.Fatalf("expected the pin to be imported, got %v", imported.GolangCILintPinned)
+	}
+	if got := tools.ResolveLintVersion(imported, "go1.24.2"); got != "v1.60.3" {
+		t.Fatalf("expected the pinned golangci-lint v1.60.3, got %s", got)
+	}
+	if imported.GolangCILintByGo["go1.25.0"] != "v2.9.0" {
+		t.Fatalf("expected the unpinned mapping to be imported, got %v", imported.GolangCILintByGo)
+	}
+}
+
+func TestImport_DryRunReportsWithoutWriting(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+
+	manifest, err := DecodeManifest(strings.NewReader(`{
+  "global_version": "1.24.2",
+  "versions": ["go1.24.2", "go1.25.0"],
+  "golangci_lint_by_go": {"go1.24.2": "v1.64.8"}
+}`))
+	if err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	result, err := svc.Import(context.Background(), manifest, true, nil)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	if len(result.Installed) != 1 || result.Installed[0] != "go1.25.0" {
+		t.Fatalf("expected go1.25.0 to be installed, got %v", result.Installed)
+	}
+	if len(result.AlreadyInstalled) != 1 || result.AlreadyInstalled[0] != "go1.24.2" {
+		t.Fatalf("expected go1.24.2 already installed, got %v", result.AlreadyInstalled)
+	}
+	if result.LintMappings["go1.24.2"] != "v1.64.8" {
+		t.Fatalf("expected lint mapping to be reported, got %v", result.LintMappings)
+	}
+	if result.GlobalVersion != "go1.24.2" {
+		t.Fatalf("expected global version to be reported, got %q", result.GlobalVersion)
+	}
+
+	cfg, err := switcher.ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if cfg.GlobalVersion != "" || len(cfg.GolangCILintByGo) != 0 {
+		t.Fatalf("expected dry run to leave config untouched, got %+v", cfg)
+	}
+}
+
+func TestImport_RecordsPreviousGlobalVersion(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	mustWriteToolchain(t, paths, "go1.25.0")
+	if err := switcher.SetGlobalVersion(paths, "go1.24.2"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+
+	manifest := Manifest{GlobalVersion: "go1.25.0", Versions: []string{"go1.24.2", "go1.25.0"}}
+	if _, err := (&Service{Paths: paths}).Import(context.Background(), manifest, false, nil); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	cfg, err := switcher.ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if cfg.GlobalVersion != "go1.25.0" {
+		t.Fatalf("expected global go1.25.0, got %q", cfg.GlobalVersion)
+	}
+	previous, err := switcher.PreviousGlobalVersion(paths, 1)
+	if err != nil || previous != "go1.24.2" {
+		t.Fatalf("expected 'use -' to go back to go1.24.2, got %q (%v)", previous, err)
+	}
+}
+
+func TestImport_RejectsInvalidManifest(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	svc := &Service{Paths: paths}
+
+	tests := []struct {
+		name     string
+		manifest Manifest
+	}{
+		{name: "invalid version", manifest: Manifest{Versions: []string{"latest"}}},
+		{name: "invalid lint version", manifest: Manifest{Versions: []string{"go1.24.2"}, GolangCILintByGo: map[string]string{"go1.24.2": "newest"}}},
+		{name: "pin without mapping", manifest: Manifest{Versions: []string{"go1.24.2"}, GolangCILintPinned: []string{"go1.24.2"}}},
+		{name: "global not listed", manifest: Manifest{GlobalVersion: "go1.25.0", Versions: []string{"go1.24.2"}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := svc.Import(context.Background(), tc.manifest, true, nil); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
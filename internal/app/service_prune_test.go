@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/tools"
+)
+
+func TestPrune_KeepsNewestAndActive(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	for _, version := range []string{"go1.25.0", "go1.24.2", "go1.23.4", "go1.22.0"} {
+		mustWriteToolchain(t, paths, version)
+	}
+	if err := switcher.SetGlobalVersion(paths, "go1.22.0"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(paths.CacheDir, "go1.23.4.linux-amd64.tar.gz"), []byte("archive"), 0o644); err != nil {
+		t.Fatalf("write cache entry: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	opts := PruneOptions{KeepNewest: 1, KeepActive: true, CleanCache: true, DryRun: true}
+	dryRun, err := svc.Prune(context.Background(), projectDir, opts)
+	if err != nil {
+		t.Fatalf("dry-run prune: %v", err)
+	}
+	if len(dryRun.Toolchains) != 2 || dryRun.Toolchains[0] != "go1.24.2" || dryRun.Toolchains[1] != "go1.23.4" {
+		t.Fatalf("unexpected toolchains to prune %v", dryRun.Toolchains)
+	}
+	if len(dryRun.CacheEntries) != 1 || dryRun.BytesReclaimed != int64(len("archive")) {
+		t.Fatalf("unexpected cache result %v (%d bytes)", dryRun.CacheEntries, dryRun.BytesReclaimed)
+	}
+	if !switcher.ToolchainExists(paths, "go1.24.2") {
+		t.Fatalf("expected dry run to keep toolchains")
+	}
+
+	opts.DryRun = false
+	if _, err := svc.Prune(context.Background(), projectDir, opts); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	installed, err := svc.ListLocal()
+	if err != nil {
+		t.Fatalf("list local: %v", err)
+	}
+	if len(installed) != 2 || installed[0] != "go1.25.0" || installed[1] != "go1.22.0" {
+		t.Fatalf("expected newest and active toolchains to remain, got %v", installed)
+	}
+	entries, err := os.ReadDir(paths.CacheDir)
+	if err != nil {
+		t.Fatalf("read cache dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected empty cache, found %d entries", len(entries))
+	}
+}
+
+func TestPrune_CacheAndBrokenToolchains(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	if err := switcher.WriteConfig(paths, switcher.Config{GolangCILintByGo: map[string]string{"go1.24.2": "v1.64.8"}}); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cacheFiles := map[string]bool{
+		"go1.24.2.linux-amd64.tar.gz":             true,
+		"go1.24.2.linux-amd64.tar.gz.lock":        true,
+		"go1.21.0.darwin-arm64.tar.gz":            false,
+		"go1.23.0.windows-amd64.zip":              false,
+		"golangci-lint-1.64.8-linux-amd64.tar.gz": true,
+		"golangci-lint-1.57.2-linux-amd64.tar.gz": false,
+		"notes.txt": true,
+	}
+	for name := range cacheFiles {
+		if err := os.WriteFile(filepath.Join(paths.CacheDir, name), []byte("archive"), 0o644); err != nil {
+			t.Fatalf("write cache entry: %v", err)
+		}
+	}
+	// A half-extracted toolchain has files but no bin/go.
+	halfExtracted := switcher.ToolchainDir(paths, "go1.23.0")
+	if err := os.MkdirAll(filepath.Join(halfExtracted, "src"), 0o755); err != nil {
+		t.Fatalf("create toolchain dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(halfExtracted, "VERSION"), []byte("go1.23.0"), 0o644); err != nil {
+		t.Fatalf("write VERSION: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	opts := PruneOptions{KeepNewest: -1, CleanCache: true, RemoveBroken: true, DryRun: true}
+	dryRun, err := svc.Prune(context.Background(), projectDir, opts)
+	if err != nil {
+		t.Fatalf("dry-run prune: %v", err)
+	}
+	wantCache := []string{"go1.21.0.darwin-arm64.tar.gz", "go1.23.0.windows-amd64.zip", "golangci-lint-1.57.2-linux-amd64.tar.gz"}
+	if !slices.Equal(dryRun.CacheEntries, wantCache) {
+		t.Fatalf("expected cache entries %v, got %v", wantCache, dryRun.CacheEntries)
+	}
+	if len(dryRun.BrokenToolchains) != 1 || dryRun.BrokenToolchains[0].Path != halfExtracted {
+		t.Fatalf("expected the half-extracted toolchain, got %+v", dryRun.BrokenToolchains)
+	}
+	if want := int64(3*len("archive") + len("go1.23.0")); dryRun.BytesReclaimed != want {
+		t.Fatalf("expected %d bytes reclaimed, got %d", want, dryRun.BytesReclaimed)
+	}
+	if _, err := os.Stat(halfExtracted); err != nil {
+		t.Fatalf("dry run removed the broken toolchain: %v", err)
+	}
+
+	opts.DryRun = false
+	if _, err := svc.Prune(context.Background(), projectDir, opts); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	for name, wantKept := range cacheFiles {
+		_, err := os.Stat(filepath.Join(paths.CacheDir, name))
+		if kept := err == nil; kept != wantKept {
+			t.Fatalf("expected %s kept=%v, got %v", name, wantKept, kept)
+		}
+	}
+	if _, err := os.Stat(halfExtracted); !os.IsNotExist(err) {
+		t.Fatalf("expected broken toolchain to be removed, got %v", err)
+	}
+	if !switcher.ToolchainExists(paths, "go1.24.2") {
+		t.Fatalf("expected the installed toolchain to remain")
+	}
+}
+
+func TestGarbageCollectTools_RemovesUnreferencedLintVersions(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	for _, lintVersion := range []string{"v1.64.8", "v1.57.2", "v2.9.0"} {
+		mustWriteLintBinary(t, paths, lintVersion)
+	}
+	// go1.21.0 is not installed, so its mapping does not keep v1.57.2.
+	if err := switcher.WriteConfig(paths, switcher.Config{GolangCILintByGo: map[string]string{
+		"go1.24.2": "v1.64.8",
+		"go1.21.0": "v1.57.2",
+	}}); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	dryRun, err := svc.GarbageCollectTools(true)
+	if err != nil {
+		t.Fatalf("gc dry run: %v", err)
+	}
+	if want := []string{"v1.57.2", "v2.9.0"}; !slices.Equal(dryRun.LintVersions, want) {
+		t.Fatalf("expected %v, got %v", want, dryRun.LintVersions)
+	}
+	if _, err := os.Stat(tools.GolangCILintBinaryPath(paths, "v2.9.0")); err != nil {
+		t.Fatalf("dry run removed a binary: %v", err)
+	}
+
+	result, err := svc.GarbageCollectTools(false)
+	if err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+	if !slices.Equal(result.LintVersions, dryRun.LintVersions) {
+		t.Fatalf("expected %v, got %v", dryRun.LintVersions, result.LintVersions)
+	}
+	for lintVersion, wantExists := range map[string]bool{"v1.64.8": true, "v1.57.2": false, "v2.9.0": false} {
+		_, err := os.Stat(tools.GolangCILintBinaryPath(paths, lintVersion))
+		if exists := err == nil; exists != wantExists {
+			t.Fatalf("expected %s exists=%v, got %v", lintVersion, wantExists, exists)
+		}
+	}
+}
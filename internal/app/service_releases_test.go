@@ -0,0 +1,218 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/releases"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+type fakeFetcher struct {
+	releases []releases.Release
+	err      error
+	calls    int
+}
+
+func (f *fakeFetcher) Fetch(context.Context) ([]releases.Release, error) {
+	f.calls++
+	return f.releases, f.err
+}
+
+// datedFakeFetcher reports a fixed fetch time, like a *releases.Client
+// serving its cache.
+type datedFakeFetcher struct {
+	fakeFetcher
+	fetchedAt time.Time
+}
+
+func (f *datedFakeFetcher) FetchDatedWithOptions(ctx context.Context, _ releases.FetchOptions) ([]releases.Release, time.Time, error) {
+	all, err := f.Fetch(ctx)
+	return all, f.fetchedAt, err
+}
+
+func hostArchive(version string) releases.File {
+	return releases.File{
+		Filename: version + "." + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz",
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Kind:     "archive",
+	}
+}
+
+func TestListRemote_UsesFetcher(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	fetcher := &fakeFetcher{releases: []releases.Release{
+		{Version: "go1.24.2", Files: []releases.File{hostArchive("go1.24.2")}},
+		{Version: "go1.25.0", Files: []releases.File{hostArchive("go1.25.0")}},
+		{Version: "go1.23.0"},
+	}}
+	svc := &Service{Paths: paths, ReleaseClient: fetcher}
+
+	versions, err := svc.ListRemote(context.Background())
+	if err != nil {
+		t.Fatalf("list remote: %v", err)
+	}
+	if len(versions) != 2 || versions[0] != "go1.25.0" || versions[1] != "go1.24.2" {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+}
+
+func TestListRemoteListing_ReportsFetchTime(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	fetchedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetcher := &datedFakeFetcher{
+		fakeFetcher: fakeFetcher{releases: []releases.Release{{Version: "go1.25.0", Files: []releases.File{hostArchive("go1.25.0")}}}},
+		fetchedAt:   fetchedAt,
+	}
+	svc := &Service{Paths: paths, ReleaseClient: fetcher}
+
+	listing, err := svc.ListRemoteListing(context.Background())
+	if err != nil {
+		t.Fatalf("list remote: %v", err)
+	}
+	if !listing.FetchedAt.Equal(fetchedAt) || len(listing.Versions) != 1 {
+		t.Fatalf("unexpected listing %+v", listing)
+	}
+}
+
+func TestInstallWithProgress_FetcherErrors(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+
+	fetchErr := errors.New("offline")
+	svc := &Service{Paths: paths, ReleaseClient: &fakeFetcher{err: fetchErr}}
+	if _, err := svc.InstallWithProgress(context.Background(), "go1.25.0", nil); !errors.Is(err, fetchErr) {
+		t.Fatalf("expected fetch error, got %v", err)
+	}
+
+	svc.ReleaseClient = &fakeFetcher{releases: []releases.Release{{Version: "go1.25.0"}}}
+	_, err := svc.InstallWithProgress(context.Background(), "go1.25.0", nil)
+	if err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Fatalf("expected unavailable error, got %v", err)
+	}
+
+	svc.ReleaseClient = &fakeFetcher{}
+	_, err = svc.InstallWithProgress(context.Background(), "go1.25.0", nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestResolveChannel(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	svc := &Service{Paths: paths, ReleaseClient: &fakeFetcher{releases: []releases.Release{
+		{Version: "go1.26rc1", Files: []releases.File{hostArchive("go1.26rc1")}},
+		{Version: "go1.25.1", Stable: true},
+		{Version: "go1.25.0", Stable: true, Files: []releases.File{hostArchive("go1.25.0")}},
+		{Version: "go1.24.7", Stable: true, Files: []releases.File{hostArchive("go1.24.7")}},
+	}}}
+
+	version, err := svc.ResolveChannel(context.Background(), releases.ChannelStable, false)
+	if err != nil {
+		t.Fatalf("resolve stable: %v", err)
+	}
+	if version != "go1.25.0" {
+		t.Fatalf("expected newest stable release with a host archive, got %s", version)
+	}
+	version, err = svc.ResolveChannel(context.Background(), releases.ChannelRC, false)
+	if err != nil {
+		t.Fatalf("resolve rc: %v", err)
+	}
+	if version != "go1.26rc1" {
+		t.Fatalf("expected the release candidate newer than stable, got %s", version)
+	}
+	if _, err := svc.ResolveChannel(context.Background(), releases.ChannelTip, false); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Fatalf("expected tip channel to be unsupported, got %v", err)
+	}
+	if _, err := releases.ParseChannel("nightly"); err == nil {
+		t.Fatalf("expected invalid channel error")
+	}
+}
+
+func TestResolveChannel_InsecureLeavesReleaseCacheUntouched(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode([]releases.Release{
+			{Version: "go1.25.0", Stable: true, Files: []releases.File{hostArchive("go1.25.0")}},
+		})
+	}))
+	defer server.Close()
+
+	paths, _ := testPaths(t)
+	cachePath := filepath.Join(paths.CacheDir, releases.CacheFile)
+	if err := os.WriteFile(cachePath, []byte("secure"), 0o644); err != nil {
+		t.Fatalf("write cache: %v", err)
+	}
+	client, insecure := releaseClients(&releases.Client{URL: server.URL, CachePath: cachePath})
+	svc := &Service{Paths: paths, ReleaseClient: client, InsecureReleaseClient: insecure}
+
+	version, err := svc.ResolveChannel(context.Background(), releases.ChannelStable, true)
+	if err != nil {
+		t.Fatalf("resolve channel: %v", err)
+	}
+	if version != "go1.25.0" {
+		t.Fatalf("expected go1.25.0, got %s", version)
+	}
+	cached, err := os.ReadFile(cachePath)
+	if err != nil || string(cached) != "secure" {
+		t.Fatalf("expected the cache to be left alone, got %q (%v)", cached, err)
+	}
+}
+
+func TestCheckConfig_AppliesConfiguredTTL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw         string
+		strict      bool
+		want        time.Duration
+		wantWarning bool
+		wantErr     bool
+	}{
+		{raw: "", want: 0},
+		{raw: "15m", want: 15 * time.Minute},
+		{raw: "0", want: -1},
+		{raw: "soon", want: 0, wantWarning: true},
+		{raw: "soon", strict: true, want: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		paths, _ := testPaths(t)
+		if err := switcher.WriteConfig(paths, switcher.Config{ReleaseCacheTTL: tt.raw}); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		client, insecure := releaseClients(&releases.Client{CachePath: filepath.Join(paths.CacheDir, releases.CacheFile)})
+		svc := &Service{Paths: paths, ReleaseClient: client, InsecureReleaseClient: insecure, ConfigOptions: switcher.ConfigOptions{Strict: tt.strict}}
+
+		warnings, err := svc.CheckConfig()
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%q: unexpected error %v", tt.raw, err)
+		}
+		if (len(warnings) > 0) != tt.wantWarning {
+			t.Fatalf("%q: unexpected warnings %v", tt.raw, warnings)
+		}
+		if client.CacheTTL != tt.want {
+			t.Fatalf("%q: expected TTL %v, got %v", tt.raw, tt.want, client.CacheTTL)
+		}
+		if insecure.CachePath != "" || client.CachePath == "" {
+			t.Fatalf("%q: expected only the secure client to use the cache", tt.raw)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+func TestRememberScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		config        string
+		remember      switcher.Scope
+		wantScope     switcher.Scope
+		wantPreferred bool
+	}{
+		{name: "disabled by default", config: `{}`, remember: switcher.ScopeLocal},
+		{name: "disabled keeps stored scope unused", config: `{"default_scope": "local"}`, remember: switcher.ScopeGlobal},
+		{name: "enabled stores choice", config: `{"remember_scope": true}`, remember: switcher.ScopeLocal, wantScope: switcher.ScopeLocal, wantPreferred: true},
+		{name: "enabled replaces choice", config: `{"remember_scope": true, "default_scope": "local"}`, remember: switcher.ScopeGlobal, wantScope: switcher.ScopeGlobal, wantPreferred: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			paths, _ := testPaths(t)
+			if err := os.WriteFile(paths.ConfigFile, []byte(tt.config), 0o644); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+			svc := &Service{Paths: paths}
+
+			if err := svc.RememberScope(tt.remember); err != nil {
+				t.Fatalf("remember scope: %v", err)
+			}
+			scope, ok, err := svc.PreferredScope()
+			if err != nil {
+				t.Fatalf("preferred scope: %v", err)
+			}
+			if ok != tt.wantPreferred || scope != tt.wantScope {
+				t.Fatalf("expected preferred scope %q (%v), got %q (%v)", tt.wantScope, tt.wantPreferred, scope, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/tools"
+)
+
+func TestActiveToolVersions(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.25.0")
+	if err := switcher.SetGlobalVersion(paths, "go1.25.0"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	lintVersion := tools.RecommendedGolangCILint("go1.25.0")
+
+	statuses, err := svc.ActiveToolVersions(projectDir)
+	if err != nil {
+		t.Fatalf("active tool versions: %v", err)
+	}
+	lint := statuses["golangci-lint"]
+	if lint.Version != lintVersion || lint.Installed || lint.BinaryPath != "" {
+		t.Fatalf("expected uninstalled %s, got %+v", lintVersion, lint)
+	}
+
+	mustWriteLintBinary(t, paths, lintVersion)
+	statuses, err = svc.ActiveToolVersions(projectDir)
+	if err != nil {
+		t.Fatalf("active tool versions: %v", err)
+	}
+	lint = statuses["golangci-lint"]
+	if !lint.Installed || lint.BinaryPath != tools.GolangCILintBinaryPath(paths, lintVersion) {
+		t.Fatalf("expected installed %s, got %+v", lintVersion, lint)
+	}
+}
+
+func TestActiveToolVersions_NoActiveVersion(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	svc := &Service{Paths: paths}
+	if _, err := svc.ActiveToolVersions(projectDir); err != switcher.ErrNoActiveVersion {
+		t.Fatalf("expected ErrNoActiveVersion, got %v", err)
+	}
+}
+
+func TestActiveToolVersions_StatFailureIsAnError(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.25.0")
+	if err := switcher.SetGlobalVersion(paths, "go1.25.0"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+	// A file where the lint version directory belongs makes stat fail with
+	// ENOTDIR rather than report the binary as missing.
+	lintDir := filepath.Join(tools.GolangCILintRoot(paths), tools.RecommendedGolangCILint("go1.25.0"))
+	if err := os.MkdirAll(filepath.Dir(lintDir), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(lintDir, nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	if _, err := svc.ActiveToolVersions(projectDir); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a stat error, got %v", err)
+	}
+}
+
+func TestResolveBinaryForTool_RestoresExecutableBit(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.25.0")
+	if err := switcher.SetGlobalVersion(paths, "go1.25.0"); err != nil {
+		t.Fatalf("set global version: %v", err)
+	}
+
+	lintVersion := tools.RecommendedGolangCILint("go1.25.0")
+	mustWriteLintBinary(t, paths, lintVersion)
+	binaryPath := tools.GolangCILintBinaryPath(paths, lintVersion)
+	if err := os.Chmod(binaryPath, 0o644); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	resolved, _, err := svc.ResolveBinaryForTool(projectDir, "golangci-lint")
+	if err != nil {
+		t.Fatalf("resolve binary: %v", err)
+	}
+	if resolved != binaryPath {
+		t.Fatalf("expected %s, got %s", binaryPath, resolved)
+	}
+
+	info, err := os.Stat(binaryPath)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Fatalf("expected mode 0755, got %o", info.Mode().Perm())
+	}
+}
+
+func TestSyncAllInstalledTools(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	for _, version := range []string{"go1.23.4", "go1.24.2", "go1.25.0"} {
+		mustWriteToolchain(t, paths, version)
+	}
+	mustWriteLintBinary(t, paths, "v1.64.8")
+	svc := &Service{Paths: paths}
+
+	results, err := svc.SyncAllInstalledTools(context.Background(), true, nil)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	states := map[ToolSyncState]int{}
+	for _, result := range results {
+		states[result.State]++
+		if result.GoVersion == "go1.25.0" && (result.State != ToolSyncWouldInstall || result.LintVersion != "v2.9.0") {
+			t.Fatalf("expected go1.25.0 to need v2.9.0, got %+v", result)
+		}
+	}
+	if len(results) != 3 || states[ToolSyncPresent] != 1 || states[ToolSyncShared] != 1 || states[ToolSyncWouldInstall] != 1 {
+		t.Fatalf("unexpected dry run results %+v", results)
+	}
+	cfg, err := switcher.ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if len(cfg.GolangCILintByGo) != 0 {
+		t.Fatalf("expected dry run to leave config untouched, got %v", cfg.GolangCILintByGo)
+	}
+
+	mustWriteLintBinary(t, paths, "v2.9.0")
+	if _, err := svc.SyncAllInstalledTools(context.Background(), false, nil); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	cfg, err = switcher.ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	want := map[string]string{"go1.23.4": "v1.64.8", "go1.24.2": "v1.64.8", "go1.25.0": "v2.9.0"}
+	if !maps.Equal(cfg.GolangCILintByGo, want) {
+		t.Fatalf("expected mappings %v, got %v", want, cfg.GolangCILintByGo)
+	}
+}
+
+func TestPinLint_ConcurrentPinsAreAllKept(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	svc := &Service{Paths: paths}
+	var (
+		start = make(chan struct{})
+		wg    sync.WaitGroup
+		errs  = make(chan error, 8)
+	)
+	for patch := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, _, err := svc.PinLint(projectDir, fmt.Sprintf("go1.24.%d", patch), "v1.64.8")
+			errs <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("PinLint: %v", err)
+		}
+	}
+
+	cfg, err := switcher.ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if len(cfg.GolangCILintPinned) != 8 {
+		t.Fatalf("expected all 8 pins to be kept, got %v", cfg.GolangCILintPinned)
+	}
+}
@@ -0,0 +1,308 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/tools"
+)
+
+func TestUseWithOptions_PromoteLocalRewritesPin(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	mustWriteToolchain(t, paths, "go1.25.0")
+	mustWriteLintBinary(t, paths, tools.RecommendedGolangCILint("go1.25.0"))
+
+	pinPath := filepath.Join(projectDir, switcher.LocalVersionFile)
+	if err := switcher.SetLocalVersionAtPath(pinPath, "go1.24.2"); err != nil {
+		t.Fatalf("write local pin: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	result, err := svc.UseWithOptions(context.Background(), "go1.25.0", switcher.ScopeGlobal, projectDir, UseOptions{PromoteLocal: true})
+	if err != nil {
+		t.Fatalf("use: %v", err)
+	}
+	if result.PromotedLocal != pinPath {
+		t.Fatalf("expected %s to be promoted, got %q", pinPath, result.PromotedLocal)
+	}
+
+	active, err := svc.Current(projectDir)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if active.Version != "go1.25.0" || active.Scope != switcher.ScopeLocal {
+		t.Fatalf("expected local go1.25.0, got %+v", active)
+	}
+	global, _, err := switcher.GlobalVersion(paths)
+	if err != nil || global != "go1.25.0" {
+		t.Fatalf("expected global go1.25.0, got %q (%v)", global, err)
+	}
+}
+
+func TestUseWithOptions_GlobalOnlyOptionsRejectLocalScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts UseOptions
+		flag string
+	}{
+		{name: "promote local", opts: UseOptions{PromoteLocal: true}, flag: "--promote-local"},
+		{name: "set goroot", opts: UseOptions{SetGoroot: true}, flag: "--set-goroot"},
+	}
+	for _, tt := range tests {
+		paths, projectDir := testPaths(t)
+		mustWriteToolchain(t, paths, "go1.25.0")
+		svc := &Service{Paths: paths}
+		_, err := svc.UseWithOptions(context.Background(), "go1.25.0", switcher.ScopeLocal, projectDir, tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.flag+" requires global scope") {
+			t.Fatalf("%s: expected a global scope error, got %v", tt.name, err)
+		}
+		if _, err := os.Stat(filepath.Join(projectDir, switcher.LocalVersionFile)); !os.IsNotExist(err) {
+			t.Fatalf("%s: expected no local pin to be written", tt.name)
+		}
+	}
+}
+
+func TestUseWithOptions_IfUnset(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	mustWriteToolchain(t, paths, "go1.25.0")
+	mustWriteLintBinary(t, paths, tools.RecommendedGolangCILint("go1.24.2"))
+	mustWriteLintBinary(t, paths, tools.RecommendedGolangCILint("go1.25.0"))
+
+	svc := &Service{Paths: paths}
+	first, err := svc.UseWithOptions(context.Background(), "go1.24.2", switcher.ScopeGlobal, projectDir, UseOptions{IfUnset: true})
+	if err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if first.Unchanged || first.Version != "go1.24.2" {
+		t.Fatalf("expected go1.24.2 to be configured, got %+v", first)
+	}
+
+	second, err := svc.UseWithOptions(context.Background(), "go1.25.0", switcher.ScopeGlobal, projectDir, UseOptions{IfUnset: true})
+	if err != nil {
+		t.Fatalf("second use: %v", err)
+	}
+	if !second.Unchanged || second.Version != "go1.24.2" {
+		t.Fatalf("expected existing go1.24.2 to be kept, got %+v", second)
+	}
+	global, _, err := switcher.GlobalVersion(paths)
+	if err != nil || global != "go1.24.2" {
+		t.Fatalf("expected global go1.24.2, got %q (%v)", global, err)
+	}
+
+	changed, err := svc.SetGlobalIfUnset("go1.25.0")
+	if err != nil || changed {
+		t.Fatalf("expected SetGlobalIfUnset to leave the global version, got changed=%v err=%v", changed, err)
+	}
+	if _, err := svc.UseWithOptions(context.Background(), "go1.25.0", switcher.ScopeLocal, projectDir, UseOptions{IfUnset: true}); err == nil {
+		t.Fatalf("expected error for local scope")
+	}
+}
+
+func TestUseWithOptions_WarnsWhenOlderThanGoMod(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.23.4")
+	mustWriteLintBinary(t, paths, tools.RecommendedGolangCILint("go1.23.4"))
+	goModPath := filepath.Join(projectDir, "go.mod")
+	if err := os.WriteFile(goModPath, []byte("module example.com/app\n\ngo 1.24 // toolchain floor\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	subDir := filepath.Join(projectDir, "cmd", "app")
+	if err := os.MkdirAll(subDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	if _, err := svc.UseWithOptions(context.Background(), "go1.23.4", switcher.ScopeLocal, subDir, UseOptions{Strict: true}); err == nil || !strings.Contains(err.Error(), "use go1.24.0 or newer") {
+		t.Fatalf("expected strict go.mod error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(subDir, switcher.LocalVersionFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected no pin to be written in strict mode, got %v", err)
+	}
+
+	result, err := svc.UseWithOptions(context.Background(), "go1.23.4", switcher.ScopeLocal, subDir, UseOptions{})
+	if err != nil {
+		t.Fatalf("use: %v", err)
+	}
+	want := goModPath + " requires go1.24.0 but go1.23.4 is older; use go1.24.0 or newer"
+	if !slices.Contains(result.Warnings, want) {
+		t.Fatalf("expected warning %q, got %v", want, result.Warnings)
+	}
+}
+
+func TestUseWithOptions_LocalPinIsConcreteUnlessAlias(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.24.2")
+	mustWriteToolchain(t, paths, "go1.24.3")
+	mustWriteLintBinary(t, paths, tools.RecommendedGolangCILint("go1.24.3"))
+	pinPath := filepath.Join(projectDir, switcher.LocalVersionFile)
+	svc := &Service{Paths: paths}
+
+	tests := []struct {
+		version string
+		scope   switcher.Scope
+		alias   bool
+		want    string
+	}{
+		{version: "go1.24.x", scope: switcher.ScopeLocal, want: "go1.24.3"},
+		{version: "1.24.2", scope: switcher.ScopeLocal, want: "go1.24.2"},
+		{version: "1.24.2", scope: switcher.ScopeLocal, alias: true, want: "go1.24.x"},
+		{version: "go1.24.x", scope: switcher.ScopeLocal, alias: true, want: "go1.24.x"},
+		{version: "go1.24.x", scope: switcher.ScopeGlobal, want: "go1.24.x"},
+	}
+	for _, tc := range tests {
+		if _, err := svc.UseWithOptions(context.Background(), tc.version, tc.scope, projectDir, UseOptions{Alias: tc.alias, NoHook: true}); err != nil {
+			t.Fatalf("%s (%s, alias=%v): use: %v", tc.version, tc.scope, tc.alias, err)
+		}
+		got := ""
+		if tc.scope == switcher.ScopeLocal {
+			raw, err := os.ReadFile(pinPath)
+			if err != nil {
+				t.Fatalf("read pin: %v", err)
+			}
+			got = strings.TrimSpace(string(raw))
+		} else {
+			got, _, _ = switcher.GlobalVersion(paths)
+		}
+		if got != tc.want {
+			t.Fatalf("%s (%s, alias=%v): expected pin %s, got %s", tc.version, tc.scope, tc.alias, tc.want, got)
+		}
+	}
+}
+
+func TestLinkedToolchain_UseAndUnlink(t *testing.T) {
+	t.Parallel()
+
+	paths, projectDir := testPaths(t)
+	goroot := filepath.Join(t.TempDir(), "go-src")
+	if err := os.MkdirAll(filepath.Join(goroot, "bin"), 0o755); err != nil {
+		t.Fatalf("create goroot: %v", err)
+	}
+	goBinary := filepath.Join(goroot, "bin", "go")
+	if err := os.WriteFile(goBinary, []byte("#!/bin/sh\necho go version devel linux/amd64\n"), 0o755); err != nil {
+		t.Fatalf("write fake go: %v", err)
+	}
+	mustWriteLintBinary(t, paths, tools.RecommendedGolangCILint("devel"))
+
+	svc := &Service{Paths: paths}
+	if _, _, err := svc.Link(context.Background(), "go1.99.0", goroot); err == nil {
+		t.Fatalf("expected a version-like link name to be rejected")
+	}
+	link, goVersion, err := svc.Link(context.Background(), "devel", goroot)
+	if err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	if link.Path != goroot || goVersion != "go version devel linux/amd64" {
+		t.Fatalf("unexpected link %+v (%q)", link, goVersion)
+	}
+
+	if _, err := svc.UseWithOptions(context.Background(), "devel", switcher.ScopeLocal, projectDir, UseOptions{}); err != nil {
+		t.Fatalf("use devel: %v", err)
+	}
+	active, err := svc.Current(projectDir)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if active.Version != "devel" || active.Linked != goroot {
+		t.Fatalf("expected linked devel toolchain, got %+v", active)
+	}
+	binary, _, err := svc.ResolveBinaryForTool(projectDir, "go")
+	if err != nil || filepath.Dir(filepath.Dir(binary)) != switcher.ToolchainDir(paths, "devel") {
+		t.Fatalf("expected go to resolve through the link, got %q (%v)", binary, err)
+	}
+
+	if _, err := svc.DeleteInstalledWithProgress(context.Background(), t.TempDir(), "devel", nil); err != nil {
+		t.Fatalf("delete linked toolchain: %v", err)
+	}
+	if _, err := os.Stat(goBinary); err != nil {
+		t.Fatalf("expected the linked GOROOT to survive deletion: %v", err)
+	}
+	if _, err := svc.Current(projectDir); err == nil {
+		t.Fatalf("expected current to fail once the link is gone")
+	}
+}
+
+func TestApplyGoroot_SetsThenClears(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.25.0")
+	logPath := filepath.Join(t.TempDir(), "go-env.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\n"
+	goBinary := filepath.Join(switcher.ToolchainDir(paths, "go1.25.0"), "bin", "go")
+	if err := os.WriteFile(goBinary, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake go: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	var result UseResult
+	if err := svc.applyGoroot(context.Background(), "go1.25.0", true, &result); err != nil {
+		t.Fatalf("set goroot: %v", err)
+	}
+	if result.GorootSet != switcher.ToolchainDir(paths, "go1.25.0") {
+		t.Fatalf("expected GOROOT to be set, got %+v", result)
+	}
+
+	result = UseResult{}
+	if err := svc.applyGoroot(context.Background(), "go1.25.0", false, &result); err != nil {
+		t.Fatalf("clear goroot: %v", err)
+	}
+	if !result.GorootCleared {
+		t.Fatalf("expected GOROOT to be cleared, got %+v", result)
+	}
+
+	result = UseResult{}
+	if err := svc.applyGoroot(context.Background(), "go1.25.0", false, &result); err != nil {
+		t.Fatalf("no-op goroot: %v", err)
+	}
+
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	want := "env -w GOROOT=" + switcher.ToolchainDir(paths, "go1.25.0") + "\nenv -u GOROOT\n"
+	if string(logged) != want {
+		t.Fatalf("expected go env calls %q, got %q", want, string(logged))
+	}
+}
+
+func TestApplyGoroot_RefusalIsAWarning(t *testing.T) {
+	t.Parallel()
+
+	paths, _ := testPaths(t)
+	mustWriteToolchain(t, paths, "go1.25.0")
+	script := "#!/bin/sh\necho 'go: GOROOT cannot be modified' >&2\nexit 1\n"
+	goBinary := filepath.Join(switcher.ToolchainDir(paths, "go1.25.0"), "bin", "go")
+	if err := os.WriteFile(goBinary, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake go: %v", err)
+	}
+
+	svc := &Service{Paths: paths}
+	var result UseResult
+	if err := svc.applyGoroot(context.Background(), "go1.25.0", true, &result); err != nil {
+		t.Fatalf("set goroot: %v", err)
+	}
+	if result.GorootSet != "" || len(result.Warnings) != 2 {
+		t.Fatalf("expected refusal warning, got %+v", result)
+	}
+	cfg, err := switcher.ReadConfig(paths)
+	if err != nil || cfg.GorootManaged {
+		t.Fatalf("expected GOROOT not to be tracked, got %+v (%v)", cfg, err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package filelock provides advisory locks on files that processes, and
+// machines sharing a filesystem, use to take turns updating them.
+package filelock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// pollInterval is how often a lock held by another process is retried.
+const pollInterval = 250 * time.Millisecond
+
+// errLocksUnsupported is returned by tryLockFile when the filesystem or
+// platform has no advisory locks, e.g. an NFS mount with nolock.
+var errLocksUnsupported = errors.New("file locks are not supported")
+
+// Lock takes an exclusive lock on path+".lock" so that processes, including
+// ones on other machines sharing the directory over NFS, do not update path
+// at the same time. onWait is called once if another process holds the lock.
+// Without lock support path is used unlocked. The returned function releases
+// the lock.
+func Lock(ctx context.Context, path string, onWait func()) (func(), error) {
+	waited := false
+	for {
+		unlock, locked, err := TryLock(path)
+		if err != nil || locked {
+			return unlock, err
+		}
+
+		if !waited && onWait != nil {
+			onWait()
+			waited = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// TryLock is Lock without waiting: locked is false when another process
+// holds the lock. Without lock support it succeeds, like Lock.
+func TryLock(path string) (unlock func(), locked bool, err error) {
+	lockPath := path + ".lock"
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, false, fmt.Errorf("open lock %s: %w", lockPath, err)
+	}
+
+	locked, err = tryLockFile(file)
+	switch {
+	case errors.Is(err, errLocksUnsupported):
+		_ = file.Close()
+		return func() {}, true, nil
+	case err != nil:
+		_ = file.Close()
+		return nil, false, fmt.Errorf("lock %s: %w", lockPath, err)
+	case !locked:
+		_ = file.Close()
+		return nil, false, nil
+	}
+	return func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+	}, true, nil
+}
@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package filelock
+
+import "os"
+
+func tryLockFile(*os.File) (bool, error) {
+	return false, errLocksUnsupported
+}
+
+func unlockFile(*os.File) error {
+	return nil
+}
@@ -0,0 +1,88 @@
+package filelock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock_WaitsForOtherHolder(t *testing.T) {
+	t.Parallel()
+
+	cachePath := filepath.Join(t.TempDir(), "go1.24.2.linux-amd64.tar.gz")
+	probe, err := os.Create(cachePath + ".probe")
+	if err != nil {
+		t.Fatalf("create probe: %v", err)
+	}
+	_, probeErr := tryLockFile(probe)
+	_ = probe.Close()
+	if errors.Is(probeErr, errLocksUnsupported) {
+		t.Skip("file locks are not supported here")
+	}
+
+	unlock, err := Lock(context.Background(), cachePath, nil)
+	if err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+
+	// Locks belong to the open file, so a second open in this process
+	// conflicts just like another process or machine would.
+	ctx, cancel := context.WithTimeout(context.Background(), 3*pollInterval)
+	defer cancel()
+	waited := false
+	if _, err := Lock(ctx, cachePath, func() { waited = true }); !errors.Is(err, context.DeadlineExceeded) || !waited {
+		t.Fatalf("expected to wait until the deadline, got waited=%v err=%v", waited, err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		unlockSecond, err := Lock(context.Background(), cachePath, nil)
+		if err == nil {
+			unlockSecond()
+		}
+		acquired <- err
+	}()
+	time.Sleep(pollInterval / 2)
+	unlock()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("second lock: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("second lock was not acquired after release")
+	}
+}
+
+func TestTryLock_DoesNotWait(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "toolchains")
+	probe, err := os.Create(path + ".probe")
+	if err != nil {
+		t.Fatalf("create probe: %v", err)
+	}
+	_, probeErr := tryLockFile(probe)
+	_ = probe.Close()
+	if errors.Is(probeErr, errLocksUnsupported) {
+		t.Skip("file locks are not supported here")
+	}
+
+	unlock, err := Lock(context.Background(), path, nil)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if _, locked, err := TryLock(path); err != nil || locked {
+		t.Fatalf("expected the held lock to be busy, got locked=%v err=%v", locked, err)
+	}
+
+	unlock()
+	unlockAgain, locked, err := TryLock(path)
+	if err != nil || !locked {
+		t.Fatalf("expected the released lock to be taken, got locked=%v err=%v", locked, err)
+	}
+	unlockAgain()
+}
@@ -0,0 +1,120 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// StrictHostsEnv enables the redirect host allowlist when set to a true value.
+const StrictHostsEnv = "GOSWITCHER_STRICT_HOSTS"
+
+// InsecureEnv requests InsecureSkipVerify downloads when set to a true value.
+const InsecureEnv = "GOSWITCHER_INSECURE"
+
+const maxRedirects = 10
+
+// StatusError reports a response with an unexpected HTTP status.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
+// AllowedHosts are trusted redirect targets in strict mode. Subdomains of an
+// entry are allowed too. The host of the original request, such as a
+// configured mirror, is always allowed.
+var AllowedHosts = []string{
+	"go.dev",
+	"dl.google.com",
+	"storage.googleapis.com",
+	"github.com",
+	"githubusercontent.com",
+}
+
+// New returns an HTTP client with timeout. Redirects are restricted to
+// AllowedHosts when StrictHostsEnv is enabled.
+func New(timeout time.Duration) *http.Client {
+	return NewWithOptions(Options{Timeout: timeout})
+}
+
+type Options struct {
+	Timeout time.Duration
+	// InsecureSkipVerify disables TLS certificate verification. It is
+	// ignored when strict hosts mode is enabled.
+	InsecureSkipVerify bool
+	// Clock times rate limit retries. Nil means SystemClock.
+	Clock Clock
+}
+
+// NewWithOptions returns a client that sends UserAgent and retries rate
+// limited requests as well.
+func NewWithOptions(opts Options) *http.Client {
+	client := &http.Client{Timeout: opts.Timeout}
+	strict := StrictHostsEnabled()
+	if strict {
+		client.CheckRedirect = RedirectPolicy(AllowedHosts)
+	}
+	base := http.DefaultTransport
+	if opts.InsecureSkipVerify && !strict {
+		insecure := http.DefaultTransport.(*http.Transport).Clone()
+		insecure.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		base = insecure
+	}
+	rt := newTransport(base)
+	if opts.Clock != nil {
+		rt.now = opts.Clock.Now
+		rt.sleep = opts.Clock.Sleep
+	}
+	client.Transport = rt
+	return client
+}
+
+func StrictHostsEnabled() bool {
+	return EnvEnabled(StrictHostsEnv)
+}
+
+// InsecureRequested reports whether InsecureEnv is set to a true value.
+func InsecureRequested() bool {
+	return EnvEnabled(InsecureEnv)
+}
+
+// EnvEnabled reports whether the environment variable name is set to a true
+// value: 1, true, yes or on, in any case.
+func EnvEnabled(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+	case "1", "true", "yes", "on":
+		return true
+	default:
+		return false
+	}
+}
+
+// RedirectPolicy returns a CheckRedirect func that only follows redirects to
+// the original request host or a host in allowed.
+func RedirectPolicy(allowed []string) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+
+		host := strings.ToLower(req.URL.Hostname())
+		if len(via) > 0 && strings.EqualFold(host, via[0].URL.Hostname()) {
+			return nil
+		}
+		for _, allowedHost := range allowed {
+			allowedHost = strings.ToLower(allowedHost)
+			if host == allowedHost || strings.HasSuffix(host, "."+allowedHost) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("redirect to untrusted host %q blocked (%s is set)", host, StrictHostsEnv)
+	}
+}
@@ -0,0 +1,68 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRedirectPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := RedirectPolicy([]string{"go.dev", "googleapis.com"})
+	original := &http.Request{URL: &url.URL{Scheme: "https", Host: "mirror.example.com"}}
+
+	tests := []struct {
+		target  string
+		allowed bool
+	}{
+		{target: "https://go.dev/dl/go1.24.2.tar.gz", allowed: true},
+		{target: "https://storage.googleapis.com/golang/go1.24.2.tar.gz", allowed: true},
+		{target: "https://mirror.example.com:8443/go1.24.2.tar.gz", allowed: true},
+		{target: "https://evil.example.net/go1.24.2.tar.gz", allowed: false},
+		{target: "https://notgo.dev/go1.24.2.tar.gz", allowed: false},
+	}
+
+	for _, tc := range tests {
+		target, err := url.Parse(tc.target)
+		if err != nil {
+			t.Fatalf("parse %s: %v", tc.target, err)
+		}
+		err = policy(&http.Request{URL: target}, []*http.Request{original})
+		if tc.allowed && err != nil {
+			t.Fatalf("%s: expected redirect to be allowed, got %v", tc.target, err)
+		}
+		if !tc.allowed && err == nil {
+			t.Fatalf("%s: expected redirect to be blocked", tc.target)
+		}
+	}
+}
+
+func TestNewWithOptionsInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	get := func(client *http.Client) error {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	if err := get(NewWithOptions(Options{Timeout: 5 * time.Second})); err == nil {
+		t.Fatalf("expected default client to reject self-signed certificate")
+	}
+	if err := get(NewWithOptions(Options{Timeout: 5 * time.Second, InsecureSkipVerify: true})); err != nil {
+		t.Fatalf("expected insecure client to succeed, got %v", err)
+	}
+
+	t.Setenv(StrictHostsEnv, "1")
+	if err := get(NewWithOptions(Options{Timeout: 5 * time.Second, InsecureSkipVerify: true})); err == nil {
+		t.Fatalf("expected strict hosts mode to ignore InsecureSkipVerify")
+	}
+}
@@ -0,0 +1,28 @@
+package httpclient
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the time source for retry backoff and anything built on top of
+// the client, such as cache expiry. Tests substitute a clock they advance by
+// hand so nothing actually sleeps.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d or until ctx is done, whichever comes first.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// SystemClock is the real wall clock.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	return sleepContext(ctx, d)
+}
@@ -0,0 +1,265 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/progress"
+)
+
+// PartialDir holds downloads in progress. It sits next to the finished files
+// so they are renamed within one filesystem and never show up in the cache
+// half-written.
+const PartialDir = ".partial"
+
+// Download downloads url to destination and returns the hex SHA256 of the
+// downloaded bytes, hashed as they are written. The download is kept in
+// PartialDir until it completes, so an interrupted one is resumed with a
+// Range request next time when the server supports it. Callers sharing a
+// cache should hold filelock.Lock for destination.
+func Download(ctx context.Context, client *http.Client, url string, destination string, reporter progress.Reporter, stage string, label string) (string, error) {
+	tmpDir := filepath.Join(filepath.Dir(destination), PartialDir)
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return "", fmt.Errorf("create download directory: %w", err)
+	}
+	partPath := filepath.Join(tmpDir, filepath.Base(destination)+".part")
+	validatorPath := partPath + ".validator"
+
+	offset, validator := partialDownload(partPath, validatorPath)
+	resp, err := openDownloadFrom(ctx, client, url, offset, validator)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	partFile, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("create partial file: %w", err)
+	}
+	discard := func() {
+		_ = partFile.Close()
+		_ = os.Remove(partPath)
+		_ = os.Remove(validatorPath)
+	}
+
+	hasher := sha256.New()
+	total := resp.ContentLength
+	if ResumesAt(resp, offset) {
+		if _, err := io.CopyN(hasher, partFile, offset); err != nil {
+			discard()
+			return "", fmt.Errorf("read partial file: %w", err)
+		}
+		if total >= 0 {
+			total += offset
+		}
+		progress.Emit(reporter, stage, fmt.Sprintf("Resuming download of %s at %s", label, progress.FormatBytes(offset)), offset, total)
+	} else {
+		offset = 0
+		if err := partFile.Truncate(0); err != nil {
+			discard()
+			return "", fmt.Errorf("truncate partial file: %w", err)
+		}
+		if err := recordValidator(validatorPath, Validator(resp)); err != nil {
+			discard()
+			return "", err
+		}
+		progress.Emit(reporter, stage, fmt.Sprintf("Downloading %s", label), 0, total)
+	}
+
+	progressWriter := &DownloadProgress{
+		Reporter: reporter,
+		Stage:    stage,
+		Label:    label,
+		Total:    total,
+		Current:  offset,
+	}
+
+	if _, err := io.Copy(io.MultiWriter(partFile, hasher), io.TeeReader(resp.Body, progressWriter)); err != nil {
+		// Keep what arrived so the next attempt can pick up from here.
+		_ = partFile.Close()
+		return "", fmt.Errorf("write response body: %w", err)
+	}
+	progressWriter.Flush()
+
+	if err := partFile.Close(); err != nil {
+		discard()
+		return "", fmt.Errorf("close partial file: %w", err)
+	}
+
+	if err := moveFile(partPath, destination); err != nil {
+		discard()
+		return "", fmt.Errorf("finalize download: %w", err)
+	}
+	_ = os.Remove(validatorPath)
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// partialDownload returns the size of an earlier, interrupted download at
+// partPath and the validator recorded for it. Without a validator the bytes
+// cannot be trusted to belong to the current file, and offset is 0.
+func partialDownload(partPath string, validatorPath string) (int64, string) {
+	info, err := os.Stat(partPath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+		return 0, ""
+	}
+	data, err := os.ReadFile(validatorPath)
+	if err != nil {
+		return 0, ""
+	}
+	validator := strings.TrimSpace(string(data))
+	if validator == "" {
+		return 0, ""
+	}
+	return info.Size(), validator
+}
+
+// recordValidator stores validator next to a download that is starting, or
+// removes a stale one when the server gave none.
+func recordValidator(validatorPath string, validator string) error {
+	if validator == "" {
+		if err := os.Remove(validatorPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove download validator: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(validatorPath, []byte(validator+"\n"), 0o644); err != nil {
+		return fmt.Errorf("record download validator: %w", err)
+	}
+	return nil
+}
+
+// OpenDownload requests url and returns the 200 response. Any other status
+// is returned as a *StatusError.
+func OpenDownload(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	return openDownloadFrom(ctx, client, url, 0, "")
+}
+
+// openDownloadFrom requests url, asking for the bytes from offset on when a
+// validator for an earlier partial download is given. The response is either
+// 200 with the whole file or 206 with the rest of it.
+func openDownloadFrom(ctx context.Context, client *http.Client, url string, offset int64, validator string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	ranged := offset > 0 && validator != ""
+	if ranged {
+		SetRange(req, offset, validator)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("perform request: %w", err)
+	}
+
+	if ranged && resp.StatusCode != http.StatusOK {
+		if ResumesAt(resp, offset) {
+			return resp, nil
+		}
+		// The partial download cannot be continued, e.g. 416 Range Not
+		// Satisfiable, so ask for the whole file.
+		_ = resp.Body.Close()
+		return openDownloadFrom(ctx, client, url, 0, "")
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// DownloadProgress is an io.Writer that counts the bytes of a download and
+// reports them to Reporter at most every 250ms.
+type DownloadProgress struct {
+	Reporter progress.Reporter
+	Stage    string
+	Label    string
+	Total    int64
+	Current  int64
+	lastEmit time.Time
+	meter    progress.RateMeter
+}
+
+func (w *DownloadProgress) Write(p []byte) (int, error) {
+	w.Current += int64(len(p))
+	w.emit(false)
+	return len(p), nil
+}
+
+// Flush reports the current count regardless of when it was last reported.
+func (w *DownloadProgress) Flush() {
+	w.emit(true)
+}
+
+func (w *DownloadProgress) emit(force bool) {
+	if w.Reporter == nil {
+		return
+	}
+	if !force && time.Since(w.lastEmit) < 250*time.Millisecond {
+		return
+	}
+
+	now := time.Now()
+	w.meter.Observe(w.Current, now)
+	transfer := progress.FormatTransferRate(w.Current, w.Total, w.meter.Rate(now))
+	progress.Emit(w.Reporter, w.Stage, fmt.Sprintf("Downloading %s %s", w.Label, transfer), w.Current, w.Total)
+	w.lastEmit = now
+}
+
+// moveFile renames from to to. When they are on different filesystems,
+// which rename cannot cross, the file is copied next to to and renamed
+// into place instead, so readers still never see a partial file.
+func moveFile(from string, to string) error {
+	err := os.Rename(from, to)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	source, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = source.Close()
+	}()
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(to), ".move-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	cleanup := func() {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+	}
+	if _, err := io.Copy(tmpFile, source); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		cleanup()
+		return err
+	}
+	if err := os.Rename(tmpPath, to); err != nil {
+		cleanup()
+		return err
+	}
+	return os.Remove(from)
+}
@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownload_ReturnsChecksum(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("archive bytes"))
+	}))
+	defer server.Close()
+
+	destination := filepath.Join(t.TempDir(), "archive.tar.gz")
+	sum, err := Download(context.Background(), server.Client(), server.URL, destination, nil, "go-download", "archive")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if sum != sha256Hex("archive bytes") {
+		t.Fatalf("expected checksum of the downloaded bytes, got %s", sum)
+	}
+}
+
+// BenchmarkDownloadChecksum compares hashing while downloading with reading
+// the finished download back to hash it.
+func BenchmarkDownloadChecksum(b *testing.B) {
+	payload := bytes.Repeat([]byte("go-switcher "), 8<<20/12)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(payload)
+	}))
+	defer server.Close()
+	destination := filepath.Join(b.TempDir(), "archive.tar.gz")
+
+	b.Run("single-pass", func(b *testing.B) {
+		b.SetBytes(int64(len(payload)))
+		for i := 0; i < b.N; i++ {
+			if _, err := Download(context.Background(), server.Client(), server.URL, destination, nil, "go-download", "archive"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("two-pass", func(b *testing.B) {
+		b.SetBytes(int64(len(payload)))
+		for i := 0; i < b.N; i++ {
+			if _, err := Download(context.Background(), server.Client(), server.URL, destination, nil, "go-download", "archive"); err != nil {
+				b.Fatal(err)
+			}
+			file, err := os.Open(destination)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_, err = io.Copy(sha256.New(), file)
+			_ = file.Close()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDownload_ResumesPartialDownload(t *testing.T) {
+	t.Parallel()
+
+	const content = "0123456789abcdefghij"
+	tests := []struct {
+		name      string
+		etag      string
+		wantRange bool
+	}{
+		{name: "same file resumes", etag: `"v1"`, wantRange: true},
+		{name: "changed file restarts", etag: `"v2"`, wantRange: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var partial atomic.Bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				partial.Store(r.Header.Get("Range") != "" && r.Header.Get("If-Range") == tt.etag)
+				w.Header().Set("ETag", tt.etag)
+				http.ServeContent(w, r, "archive.tar.gz", time.Time{}, strings.NewReader(content))
+			}))
+			defer server.Close()
+
+			cacheDir := t.TempDir()
+			destination := filepath.Join(cacheDir, "archive.tar.gz")
+			partPath := filepath.Join(cacheDir, PartialDir, "archive.tar.gz.part")
+			if err := os.MkdirAll(filepath.Dir(partPath), 0o755); err != nil {
+				t.Fatalf("mkdir: %v", err)
+			}
+			if err := os.WriteFile(partPath, []byte(content[:8]), 0o644); err != nil {
+				t.Fatalf("write partial: %v", err)
+			}
+			if err := os.WriteFile(partPath+".validator", []byte(`"v1"`+"\n"), 0o644); err != nil {
+				t.Fatalf("write validator: %v", err)
+			}
+
+			sum, err := Download(context.Background(), server.Client(), server.URL, destination, nil, "go-download", "archive")
+			if err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+			if partial.Load() != tt.wantRange {
+				t.Fatalf("expected ranged response %v, got %v", tt.wantRange, partial.Load())
+			}
+			if sum != sha256Hex(content) {
+				t.Fatalf("expected checksum of the whole file, got %s", sum)
+			}
+			if data, err := os.ReadFile(destination); err != nil || string(data) != content {
+				t.Fatalf("expected whole file, got %q (%v)", data, err)
+			}
+			if entries, _ := os.ReadDir(filepath.Dir(partPath)); len(entries) != 0 {
+				t.Fatalf("expected no partial files left, got %v", entries)
+			}
+		})
+	}
+}
+
+func TestDownload_KeepsInterruptedDownload(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", "100")
+		_, _ = w.Write([]byte("first bytes"))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	destination := filepath.Join(cacheDir, "archive.tar.gz")
+	if _, err := Download(context.Background(), server.Client(), server.URL, destination, nil, "go-download", "archive"); err == nil {
+		t.Fatalf("expected an error for a truncated response")
+	}
+
+	partPath := filepath.Join(cacheDir, PartialDir, "archive.tar.gz.part")
+	if data, err := os.ReadFile(partPath); err != nil || string(data) != "first bytes" {
+		t.Fatalf("expected the received bytes to be kept, got %q (%v)", data, err)
+	}
+	offset, validator := partialDownload(partPath, partPath+".validator")
+	if offset != int64(len("first bytes")) || validator != `"v1"` {
+		t.Fatalf("expected resumable partial download, got offset=%d validator=%q", offset, validator)
+	}
+	if _, err := os.Stat(destination); !os.IsNotExist(err) {
+		t.Fatalf("expected no file at %s", destination)
+	}
+}
+
+func TestDownload_KeepsPartialFilesOutOfCache(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("archive bytes"))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	destination := filepath.Join(cacheDir, "go1.24.2.linux-amd64.tar.gz")
+	if _, err := Download(context.Background(), server.Client(), server.URL, destination, nil, "go-download", "archive"); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+
+	partial, err := os.ReadDir(filepath.Join(cacheDir, PartialDir))
+	if err != nil || len(partial) != 0 {
+		t.Fatalf("expected an empty %s directory, got %v (%v)", PartialDir, partial, err)
+	}
+	if data, err := os.ReadFile(destination); err != nil || string(data) != "archive bytes" {
+		t.Fatalf("expected downloaded archive, got %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SetRange asks for the bytes of the resource from offset on. validator is
+// sent as If-Range, so a server whose copy has changed since it was recorded
+// answers with the whole resource instead.
+func SetRange(req *http.Request, offset int64, validator string) {
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	req.Header.Set("If-Range", validator)
+}
+
+// Validator returns the value a download of resp can later be resumed with:
+// its strong ETag or, without one, its Last-Modified date. It returns "" when
+// the server does not support range requests for the resource.
+func Validator(resp *http.Response) string {
+	if strings.EqualFold(strings.TrimSpace(resp.Header.Get("Accept-Ranges")), "none") {
+		return ""
+	}
+	if etag := strings.TrimSpace(resp.Header.Get("ETag")); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return strings.TrimSpace(resp.Header.Get("Last-Modified"))
+}
+
+// ResumesAt reports whether resp is a 206 Partial Content response whose
+// body starts at offset.
+func ResumesAt(resp *http.Response, offset int64) bool {
+	if resp.StatusCode != http.StatusPartialContent {
+		return false
+	}
+	contentRange, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return false
+	}
+	start, _, ok := strings.Cut(contentRange, "-")
+	if !ok {
+		return false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	return err == nil && n == offset
+}
@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestValidator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{name: "strong etag", header: http.Header{"Etag": {`"abc"`}, "Last-Modified": {"Mon, 02 Jan 2006 15:04:05 GMT"}}, want: `"abc"`},
+		{name: "weak etag uses last modified", header: http.Header{"Etag": {`W/"abc"`}, "Last-Modified": {"Mon, 02 Jan 2006 15:04:05 GMT"}}, want: "Mon, 02 Jan 2006 15:04:05 GMT"},
+		{name: "ranges unsupported", header: http.Header{"Etag": {`"abc"`}, "Accept-Ranges": {"none"}}, want: ""},
+		{name: "no validator", header: http.Header{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Validator(&http.Response{Header: tt.header}); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResumesAt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		status       int
+		contentRange string
+		want         bool
+	}{
+		{name: "partial at offset", status: http.StatusPartialContent, contentRange: "bytes 100-199/200", want: true},
+		{name: "partial elsewhere", status: http.StatusPartialContent, contentRange: "bytes 0-199/200", want: false},
+		{name: "full response", status: http.StatusOK, contentRange: "", want: false},
+		{name: "malformed range", status: http.StatusPartialContent, contentRange: "100-199/200", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{"Content-Range": {tt.contentRange}}}
+			if got := ResumesAt(resp, 100); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserAgent identifies switcher on every request made by clients from this
+// package.
+var UserAgent = "go-switcher/" + buildVersion()
+
+const (
+	// maxRateLimitRetries bounds how often a 429 response is retried.
+	maxRateLimitRetries = 2
+	// maxRetryAfter caps the wait requested by a Retry-After header.
+	maxRetryAfter = 20 * time.Second
+)
+
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// transport sets UserAgent and retries requests rejected with 429 Too Many
+// Requests after the delay the server asked for.
+type transport struct {
+	base  http.RoundTripper
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+func newTransport(base http.RoundTripper) *transport {
+	return &transport{base: base, now: time.Now, sleep: sleepContext}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= maxRateLimitRetries {
+			return resp, err
+		}
+		wait, ok := retryAfter(resp.Header.Get("Retry-After"), t.now())
+		if !ok || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		if err := t.sleep(req.Context(), min(wait, maxRetryAfter)); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport_SetsUserAgentAndRetriesRateLimits(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("User-Agent"); got != UserAgent {
+			t.Errorf("expected User-Agent %q, got %q", UserAgent, got)
+		}
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	rt := newTransport(http.DefaultTransport)
+	rt.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	client := &http.Client{Transport: rt}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Fatalf("expected success on the second request, got %d after %d requests", resp.StatusCode, requests.Load())
+	}
+	if len(waits) != 1 || waits[0] != maxRetryAfter {
+		t.Fatalf("expected one wait capped at %s, got %v", maxRetryAfter, waits)
+	}
+}
+
+func TestTransport_GivesUpOnPersistentRateLimit(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	resp, err := NewWithOptions(Options{Timeout: 5 * time.Second}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || requests.Load() != maxRateLimitRetries+1 {
+		t.Fatalf("expected 429 after %d requests, got %d after %d", maxRateLimitRetries+1, resp.StatusCode, requests.Load())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "7", want: 7 * time.Second, wantOK: true},
+		{value: "-3", want: 0, wantOK: true},
+		{value: "Tue, 01 Apr 2025 12:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{value: "Tue, 01 Apr 2025 11:59:00 GMT", want: 0, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+	for _, tc := range tests {
+		got, ok := retryAfter(tc.value, now)
+		if got != tc.want || ok != tc.wantOK {
+			t.Fatalf("%q: expected %s/%v, got %s/%v", tc.value, tc.want, tc.wantOK, got, ok)
+		}
+	}
+}
+
+// fakeClock never sleeps; Sleep advances the clock and records the wait.
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) error {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func TestNewWithOptions_ClockTimesRetries(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// An HTTP date is only meaningful relative to the injected clock.
+			w.Header().Set("Retry-After", clock.now.Add(7*time.Second).Format(http.TimeFormat))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp, err := NewWithOptions(Options{Timeout: 5 * time.Second, Clock: clock}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected success after the retry, got %d", resp.StatusCode)
+	}
+	if len(clock.waits) != 1 || clock.waits[0] != 7*time.Second {
+		t.Fatalf("expected a single 7s wait on the fake clock, got %v", clock.waits)
+	}
+}
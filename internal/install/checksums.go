@@ -0,0 +1,91 @@
+package install
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
+)
+
+// maxChecksumsSize bounds a downloaded checksum list; real lists are a few
+// kilobytes.
+const maxChecksumsSize = 1 << 20
+
+// NormalizeSHA256 lowercases a hex SHA256 and rejects anything else.
+func NormalizeSHA256(raw string) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	if decoded, err := hex.DecodeString(value); err != nil || len(decoded) != 32 {
+		return "", fmt.Errorf("invalid sha256 %q (expected 64 hex characters)", raw)
+	}
+	return value, nil
+}
+
+// LoadChecksum returns the SHA256 listed for filename in source, an http(s)
+// URL or a local path. Lines have the "<hex>  <filename>" form written by
+// sha256sum; a file holding a single bare hash, like the <archive>.sha256
+// files on go.dev, applies to filename as well.
+func LoadChecksum(ctx context.Context, source string, filename string, insecure bool) (string, error) {
+	data, err := readChecksums(ctx, source, insecure)
+	if err != nil {
+		return "", err
+	}
+	sum, ok := parseChecksums(data, filename)
+	if !ok {
+		return "", fmt.Errorf("no sha256 for %s in %s", filename, source)
+	}
+	return NormalizeSHA256(sum)
+}
+
+func readChecksums(ctx context.Context, source string, insecure bool) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("read checksums %s: %w", source, err)
+		}
+		return data, nil
+	}
+
+	client := httpclient.NewWithOptions(httpclient.Options{Timeout: 30 * time.Second, InsecureSkipVerify: insecure})
+	resp, err := httpclient.OpenDownload(ctx, client, source)
+	if err != nil {
+		return nil, fmt.Errorf("download checksums %s: %w", source, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChecksumsSize))
+	if err != nil {
+		return nil, fmt.Errorf("download checksums %s: %w", source, err)
+	}
+	return data, nil
+}
+
+func parseChecksums(data []byte, filename string) (string, bool) {
+	var bare []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1:
+			bare = append(bare, fields[0])
+		case len(fields) >= 2:
+			// sha256sum marks binary mode with a leading '*'.
+			name := strings.TrimPrefix(fields[len(fields)-1], "*")
+			if path.Base(name) == filename {
+				return fields[0], true
+			}
+		}
+	}
+	if len(bare) == 1 {
+		return bare[0], true
+	}
+	return "", false
+}
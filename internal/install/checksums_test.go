@@ -0,0 +1,78 @@
+package install
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testSumA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	testSumB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func TestParseChecksums(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		data   string
+		want   string
+		wantOK bool
+	}{
+		{name: "sha256sum list", data: testSumA + "  go1.24.2.darwin-arm64.tar.gz\n" + testSumB + "  go1.24.2.linux-amd64.tar.gz\n", want: testSumB, wantOK: true},
+		{name: "binary mode and directory", data: testSumB + " *dl/go1.24.2.linux-amd64.tar.gz\n", want: testSumB, wantOK: true},
+		{name: "bare hash", data: testSumA + "\n", want: testSumA, wantOK: true},
+		{name: "missing filename", data: testSumA + "  go1.24.2.darwin-arm64.tar.gz\n", wantOK: false},
+		{name: "several bare hashes", data: testSumA + "\n" + testSumB + "\n", wantOK: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseChecksums([]byte(tc.data), "go1.24.2.linux-amd64.tar.gz")
+			if got != tc.want || ok != tc.wantOK {
+				t.Fatalf("expected (%q, %v), got (%q, %v)", tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestLoadChecksum(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/SHA256SUMS" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(strings.ToUpper(testSumB) + "  go1.24.2.linux-amd64.tar.gz\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	listPath := filepath.Join(t.TempDir(), "SHA256SUMS")
+	if err := os.WriteFile(listPath, []byte(testSumA+"  go1.24.2.linux-amd64.tar.gz\n"), 0o644); err != nil {
+		t.Fatalf("write checksums: %v", err)
+	}
+
+	ctx := context.Background()
+	if got, err := LoadChecksum(ctx, server.URL+"/SHA256SUMS", "go1.24.2.linux-amd64.tar.gz", false); err != nil || got != testSumB {
+		t.Fatalf("expected %s from URL, got %q (%v)", testSumB, got, err)
+	}
+	if got, err := LoadChecksum(ctx, listPath, "go1.24.2.linux-amd64.tar.gz", false); err != nil || got != testSumA {
+		t.Fatalf("expected %s from file, got %q (%v)", testSumA, got, err)
+	}
+	if _, err := LoadChecksum(ctx, listPath, "go1.24.2.darwin-arm64.tar.gz", false); err == nil || !strings.Contains(err.Error(), "no sha256 for go1.24.2.darwin-arm64.tar.gz") {
+		t.Fatalf("expected missing checksum error, got %v", err)
+	}
+	if _, err := LoadChecksum(ctx, server.URL+"/missing", "go1.24.2.linux-amd64.tar.gz", false); err == nil {
+		t.Fatalf("expected error for missing checksum list")
+	}
+	if _, err := NormalizeSHA256("abc"); err == nil {
+		t.Fatalf("expected short sha256 to be rejected")
+	}
+}
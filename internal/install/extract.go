@@ -0,0 +1,435 @@
+package install
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/progress"
+)
+
+// Extractor unpacks one archive format of a Go distribution. Entries are
+// written to dir without the top-level go/ directory; onEntry is called once
+// per extracted entry.
+type Extractor interface {
+	Extract(r io.Reader, dir string, onEntry func()) error
+}
+
+// entryCounter is implemented by extractors that can count their entries in
+// a separate pass, which lets extraction progress show a total.
+type entryCounter interface {
+	CountEntries(r io.Reader) (int64, error)
+}
+
+// extractors maps archive filename suffixes to their Extractor and the
+// leading bytes every such file starts with. Supporting a new format means
+// adding an entry here. Formats that need random access, like zip with its
+// central directory at the end, cannot be streamed.
+var extractors = []struct {
+	suffix       string
+	magic        []byte
+	extractor    Extractor
+	randomAccess bool
+}{
+	{suffix: ".tar.gz", magic: []byte{0x1f, 0x8b}, extractor: tarGzExtractor{}},
+	{suffix: ".zip", magic: []byte("PK\x03\x04"), extractor: zipExtractor{}, randomAccess: true},
+}
+
+// errNotStreamable is returned when a random access format is handed a plain
+// stream instead of the cached file.
+var errNotStreamable = errors.New("zip archives cannot be streamed; install without --no-cache")
+
+// streamable reports whether filename can be extracted while downloading.
+func streamable(filename string) bool {
+	for _, entry := range extractors {
+		if strings.HasSuffix(filename, entry.suffix) {
+			return !entry.randomAccess
+		}
+	}
+	return true
+}
+
+func extractorFor(filename string) (Extractor, error) {
+	for _, entry := range extractors {
+		if strings.HasSuffix(filename, entry.suffix) {
+			return entry.extractor, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported archive format for %s", filepath.Base(filename))
+}
+
+// hasArchiveMagic reports whether the file at path starts with the magic
+// bytes of its format. Files of unknown formats are not rejected here.
+func hasArchiveMagic(path string) bool {
+	for _, entry := range extractors {
+		if !strings.HasSuffix(path, entry.suffix) {
+			continue
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return false
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		header := make([]byte, len(entry.magic))
+		if _, err := io.ReadFull(file, header); err != nil {
+			return false
+		}
+		return bytes.Equal(header, entry.magic)
+	}
+	return true
+}
+
+func extractGoArchive(archivePath string, targetDir string, reporter progress.Reporter) error {
+	extractor, err := extractorFor(archivePath)
+	if err != nil {
+		return err
+	}
+
+	archiveFile, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("open archive %s: %w", archivePath, err)
+	}
+	defer func() {
+		_ = archiveFile.Close()
+	}()
+
+	var counter *extractProgress
+	if reporter != nil {
+		counter = &extractProgress{reporter: reporter}
+		// A counting pass costs one extra decompression but lets the
+		// progress show a total; it is skipped when nobody is listening.
+		if entries, ok := extractor.(entryCounter); ok {
+			total, err := entries.CountEntries(archiveFile)
+			if err != nil {
+				return err
+			}
+			if _, err := archiveFile.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("rewind archive %s: %w", archivePath, err)
+			}
+			counter.total = total
+		}
+	}
+
+	return extractToolchain(extractor, archiveFile, targetDir, nil, counter)
+}
+
+// extractToolchain extracts a Go archive stream into a temporary directory
+// and moves it to targetDir. beforePromote, when set, runs after extraction
+// and can reject the result. counter, when set, reports the entries
+// extracted.
+func extractToolchain(extractor Extractor, r io.Reader, targetDir string, beforePromote func() error, counter *extractProgress) error {
+	tmpParent := filepath.Dir(targetDir)
+	if err := os.MkdirAll(tmpParent, 0o755); err != nil {
+		return fmt.Errorf("create target parent %s: %w", tmpParent, err)
+	}
+
+	tmpDir, err := os.MkdirTemp(tmpParent, extractionTempPrefix)
+	if err != nil {
+		return fmt.Errorf("create temp extraction dir: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	if err := extractor.Extract(r, tmpDir, counter.add); err != nil {
+		return err
+	}
+	counter.finish()
+
+	if beforePromote != nil {
+		if err := beforePromote(); err != nil {
+			return err
+		}
+	}
+
+	return defaultPromoter.promote(tmpDir, targetDir)
+}
+
+type tarGzExtractor struct{}
+
+func (tarGzExtractor) Extract(r io.Reader, dir string, onEntry func()) error {
+	gzReader, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("create gzip reader: %w", err)
+	}
+	defer func() {
+		_ = gzReader.Close()
+	}()
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar entry: %w", err)
+		}
+
+		relativePath, err := stripGoRootPrefix(header.Name)
+		if err != nil {
+			return err
+		}
+		if relativePath == "" {
+			continue
+		}
+
+		targetPath := filepath.Join(dir, relativePath)
+		if err := ensureSafePath(dir, targetPath); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(targetPath, os.FileMode(header.Mode)); err != nil {
+				return fmt.Errorf("create directory %s: %w", targetPath, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0o755); err != nil {
+				return fmt.Errorf("create parent directory for %s: %w", targetPath, err)
+			}
+			outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
+			if err != nil {
+				return fmt.Errorf("create file %s: %w", targetPath, err)
+			}
+			if _, err := io.Copy(outFile, tarReader); err != nil {
+				_ = outFile.Close()
+				return fmt.Errorf("write file %s: %w", targetPath, err)
+			}
+			if err := outFile.Close(); err != nil {
+				return fmt.Errorf("close file %s: %w", targetPath, err)
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0o755); err != nil {
+				return fmt.Errorf("create symlink parent for %s: %w", targetPath, err)
+			}
+			if err := os.Symlink(header.Linkname, targetPath); err != nil {
+				return fmt.Errorf("create symlink %s -> %s: %w", targetPath, header.Linkname, err)
+			}
+		default:
+			continue
+		}
+		onEntry()
+	}
+}
+
+// CountEntries counts the entries Extract would extract.
+func (tarGzExtractor) CountEntries(r io.Reader) (int64, error) {
+	gzReader, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("create gzip reader: %w", err)
+	}
+	defer func() {
+		_ = gzReader.Close()
+	}()
+
+	var count int64
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read tar entry: %w", err)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeReg, tar.TypeSymlink:
+			if relativePath, err := stripGoRootPrefix(header.Name); err == nil && relativePath != "" {
+				count++
+			}
+		}
+	}
+}
+
+// zipExtractor reads the Windows distributions. It needs the archive as an
+// *os.File, or anything else that can report its size and read at offsets.
+type zipExtractor struct{}
+
+type sizedReaderAt interface {
+	io.ReaderAt
+	Stat() (fs.FileInfo, error)
+}
+
+func openZip(r io.Reader) (*zip.Reader, error) {
+	file, ok := r.(sizedReaderAt)
+	if !ok {
+		return nil, errNotStreamable
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat zip archive: %w", err)
+	}
+	zipReader, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("open zip archive: %w", err)
+	}
+	return zipReader, nil
+}
+
+func (zipExtractor) Extract(r io.Reader, dir string, onEntry func()) error {
+	zipReader, err := openZip(r)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range zipReader.File {
+		relativePath, err := stripGoRootPrefix(entry.Name)
+		if err != nil {
+			return err
+		}
+		if relativePath == "" {
+			continue
+		}
+
+		targetPath := filepath.Join(dir, relativePath)
+		if err := ensureSafePath(dir, targetPath); err != nil {
+			return err
+		}
+
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(targetPath, 0o755); err != nil {
+				return fmt.Errorf("create directory %s: %w", targetPath, err)
+			}
+		case mode.IsRegular():
+			if err := extractZipFile(entry, targetPath); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		onEntry()
+	}
+	return nil
+}
+
+func extractZipFile(entry *zip.File, targetPath string) error {
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0o755); err != nil {
+		return fmt.Errorf("create parent directory for %s: %w", targetPath, err)
+	}
+	source, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("read zip entry %s: %w", entry.Name, err)
+	}
+	defer func() {
+		_ = source.Close()
+	}()
+
+	// Archives written on Windows often carry no permission bits at all.
+	perm := entry.Mode().Perm()
+	if perm == 0 {
+		perm = 0o644
+	}
+	outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return fmt.Errorf("create file %s: %w", targetPath, err)
+	}
+	if _, err := io.Copy(outFile, source); err != nil {
+		_ = outFile.Close()
+		return fmt.Errorf("write file %s: %w", targetPath, err)
+	}
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf("close file %s: %w", targetPath, err)
+	}
+	return nil
+}
+
+// CountEntries counts the entries Extract would extract.
+func (zipExtractor) CountEntries(r io.Reader) (int64, error) {
+	zipReader, err := openZip(r)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, entry := range zipReader.File {
+		mode := entry.Mode()
+		if !mode.IsDir() && !mode.IsRegular() {
+			continue
+		}
+		if relativePath, err := stripGoRootPrefix(entry.Name); err == nil && relativePath != "" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// extractProgress reports extracted entries at the same rate as download
+// progress. A nil *extractProgress is a no-op.
+type extractProgress struct {
+	reporter progress.Reporter
+	total    int64
+	current  int64
+	lastEmit time.Time
+}
+
+func (p *extractProgress) add() {
+	if p == nil {
+		return
+	}
+	p.current++
+	p.emit(false)
+}
+
+func (p *extractProgress) finish() {
+	if p == nil {
+		return
+	}
+	p.emit(true)
+}
+
+func (p *extractProgress) emit(force bool) {
+	if !force && time.Since(p.lastEmit) < 250*time.Millisecond {
+		return
+	}
+
+	message := fmt.Sprintf("Extracting... %d files", p.current)
+	if p.total > 0 {
+		message = fmt.Sprintf("Extracting... %d/%d files", p.current, p.total)
+	}
+	progress.EmitFiles(p.reporter, "go-extract", message, p.current, p.total)
+	p.lastEmit = time.Now()
+}
+
+// stripGoRootPrefix removes the top-level go/ directory from an archive
+// entry name. It and ensureSafePath are shared by all extractors.
+func stripGoRootPrefix(path string) (string, error) {
+	clean := filepath.Clean(path)
+	parts := strings.Split(clean, string(filepath.Separator))
+	if len(parts) == 0 {
+		return "", nil
+	}
+	if parts[0] != "go" {
+		return "", fmt.Errorf("unexpected archive root for %s", path)
+	}
+	if len(parts) == 1 {
+		return "", nil
+	}
+	return filepath.Join(parts[1:]...), nil
+}
+
+func ensureSafePath(baseDir string, targetPath string) error {
+	base := filepath.Clean(baseDir)
+	target := filepath.Clean(targetPath)
+	if target == base {
+		return nil
+	}
+	prefix := base + string(filepath.Separator)
+	if !strings.HasPrefix(target, prefix) {
+		return fmt.Errorf("unsafe archive path %s", targetPath)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package install
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/progress"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+func TestExtractorFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filename string
+		want     Extractor
+	}{
+		{filename: "go1.24.2.linux-amd64.tar.gz", want: tarGzExtractor{}},
+		{filename: "/cache/go1.24.2.darwin-arm64.tar.gz", want: tarGzExtractor{}},
+		{filename: "go1.24.2.windows-amd64.zip", want: zipExtractor{}},
+		{filename: "go1.24.2.darwin-arm64.pkg"},
+	}
+	for _, tc := range tests {
+		got, err := extractorFor(tc.filename)
+		if tc.want == nil {
+			if err == nil {
+				t.Fatalf("%s: expected unsupported format error, got %T", tc.filename, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Fatalf("%s: expected %T, got %T (%v)", tc.filename, tc.want, got, err)
+		}
+	}
+}
+
+func TestExtractGoArchive_ReportsFileCount(t *testing.T) {
+	t.Parallel()
+
+	paths := testPaths(t)
+	archivePath := filepath.Join(t.TempDir(), "go1.24.2.tar.gz")
+	if err := os.WriteFile(archivePath, goArchive(t), 0o644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+
+	var events []progress.Event
+	reporter := func(event progress.Event) {
+		events = append(events, event)
+	}
+	if err := extractGoArchive(archivePath, switcher.ToolchainDir(paths, "go1.24.2"), reporter); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatalf("expected extract progress events")
+	}
+	last := events[len(events)-1]
+	if last.Unit != progress.UnitFiles || last.Current != 2 || last.Total != 2 || last.Message != "Extracting... 2/2 files" {
+		t.Fatalf("unexpected final event %+v", last)
+	}
+}
+
+func zipArchive(t *testing.T, names ...string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if !strings.HasSuffix(name, "/") {
+			if _, err := w.Write([]byte(name)); err != nil {
+				t.Fatalf("write %s: %v", name, err)
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractGoArchive_Zip(t *testing.T) {
+	t.Parallel()
+
+	paths := testPaths(t)
+	archivePath := filepath.Join(t.TempDir(), "go1.24.2.windows-amd64.zip")
+	if err := os.WriteFile(archivePath, zipArchive(t, "go/", "go/VERSION", "go/bin/", "go/bin/go.exe"), 0o644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	if !hasArchiveMagic(archivePath) {
+		t.Fatalf("expected zip magic to match")
+	}
+
+	var events []progress.Event
+	targetDir := switcher.ToolchainDir(paths, "go1.24.2")
+	if err := extractGoArchive(archivePath, targetDir, func(event progress.Event) { events = append(events, event) }); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(targetDir, "bin", "go.exe"))
+	if err != nil || string(content) != "go/bin/go.exe" {
+		t.Fatalf("expected bin/go.exe to be extracted, got %q (%v)", content, err)
+	}
+	if last := events[len(events)-1]; last.Current != 3 || last.Total != 3 {
+		t.Fatalf("expected 3 of 3 entries, got %+v", last)
+	}
+}
+
+func TestExtractGoArchive_ZipRejectsUnsafePaths(t *testing.T) {
+	t.Parallel()
+
+	paths := testPaths(t)
+	archivePath := filepath.Join(t.TempDir(), "evil.zip")
+	if err := os.WriteFile(archivePath, zipArchive(t, "go/VERSION", "go/../../escape"), 0o644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	targetDir := switcher.ToolchainDir(paths, "go1.24.2")
+	if err := extractGoArchive(archivePath, targetDir, nil); err == nil {
+		t.Fatalf("expected unsafe entry to be rejected")
+	}
+	if _, err := os.Stat(targetDir); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be promoted, got %v", err)
+	}
+}
+
+func TestZipExtractor_NeedsFile(t *testing.T) {
+	t.Parallel()
+
+	err := zipExtractor{}.Extract(bytes.NewReader(zipArchive(t, "go/VERSION")), t.TempDir(), func() {})
+	if !errors.Is(err, errNotStreamable) {
+		t.Fatalf("expected errNotStreamable for a plain stream, got %v", err)
+	}
+	if streamable("go1.24.2.windows-amd64.zip") || !streamable("go1.24.2.linux-amd64.tar.gz") {
+		t.Fatalf("expected only tar.gz archives to be streamable")
+	}
+}
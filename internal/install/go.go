@@ -1,11 +1,10 @@
 package install
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mrtuuro/go-switcher/internal/filelock"
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
 	"github.com/mrtuuro/go-switcher/internal/progress"
 	"github.com/mrtuuro/go-switcher/internal/releases"
 	"github.com/mrtuuro/go-switcher/internal/switcher"
@@ -22,8 +23,32 @@ import (
 
 const goDownloadBaseURL = "https://go.dev/dl"
 
+// maxDownloadAttempts bounds re-downloads when the fetched archive keeps
+// failing checksum verification, e.g. a corrupt upstream file.
+const maxDownloadAttempts = 2
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 type InstallOptions struct {
 	Reporter progress.Reporter
+	// BaseURL overrides the location Go archives are downloaded from.
+	BaseURL string
+	// Mirrors are alternative base URLs tried in order when BaseURL is
+	// empty; a failed download moves on to the next mirror.
+	Mirrors []string
+	// ProbeMirrors orders Mirrors fastest-first with a latency probe before
+	// downloading.
+	ProbeMirrors bool
+	// TargetDir overrides the extraction directory, e.g. for cross-platform
+	// toolchains that must not be registered as host toolchains.
+	TargetDir string
+	// NoCache streams the download straight into extraction instead of
+	// keeping the archive in the cache. The checksum is verified while
+	// streaming and the extracted toolchain is discarded on mismatch.
+	NoCache bool
+	// InsecureSkipVerify disables TLS certificate verification for the
+	// archive download. It has no effect in strict hosts mode.
+	InsecureSkipVerify bool
 }
 
 func InstallGoArchive(ctx context.Context, paths switcher.Paths, version string, archive releases.File) error {
@@ -43,298 +68,261 @@ func InstallGoArchiveWithOptions(ctx context.Context, paths switcher.Paths, vers
 	}
 
 	targetDir := switcher.ToolchainDir(paths, normalized)
-	if _, err := os.Stat(filepath.Join(targetDir, "bin", "go")); err == nil {
+	if opts.TargetDir != "" {
+		targetDir = opts.TargetDir
+	}
+	if _, err := os.Stat(switcher.GoBinaryPath(targetDir)); err == nil {
 		progress.Emit(opts.Reporter, "go-install", fmt.Sprintf("%s is already installed", normalized), 0, 0)
 		return nil
 	}
 
-	cachePath := filepath.Join(paths.CacheDir, archive.Filename)
-	if err := ensureArchiveInCache(ctx, archive, cachePath, opts.Reporter); err != nil {
-		return err
+	client := httpclient.NewWithOptions(httpclient.Options{
+		Timeout:            120 * time.Second,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	})
+	baseURLs := downloadBaseURLs(opts)
+	if opts.ProbeMirrors && len(baseURLs) > 1 {
+		progress.Emit(opts.Reporter, "go-mirror", fmt.Sprintf("Probing %d mirrors...", len(baseURLs)), 0, 0)
+		baseURLs = OrderMirrorsByLatency(ctx, client, baseURLs)
 	}
 
-	if strings.TrimSpace(archive.SHA256) != "" {
-		progress.Emit(opts.Reporter, "go-checksum", fmt.Sprintf("Verifying checksum for %s", archive.Filename), 0, 0)
-		ok, err := verifySHA256(cachePath, archive.SHA256)
-		if err != nil {
-			return fmt.Errorf("verify checksum for %s: %w", archive.Filename, err)
+	for i, baseURL := range baseURLs {
+		if len(baseURLs) > 1 {
+			progress.Emit(opts.Reporter, "go-mirror", fmt.Sprintf("Using mirror %s", baseURL), 0, 0)
 		}
-		if !ok {
-			return fmt.Errorf("checksum mismatch for %s", archive.Filename)
+		err = fetchGoArchive(ctx, client, paths, archive, targetDir, baseURL, opts)
+		if err == nil {
+			break
 		}
+		if ctx.Err() != nil || i == len(baseURLs)-1 {
+			return err
+		}
+		progress.Emit(opts.Reporter, "go-mirror", fmt.Sprintf("Mirror %s failed: %v", baseURL, err), 0, 0)
 	}
 
-	progress.Emit(opts.Reporter, "go-extract", fmt.Sprintf("Extracting %s", archive.Filename), 0, 0)
-	if err := extractGoArchive(cachePath, targetDir); err != nil {
-		return err
-	}
-
-	if _, err := os.Stat(filepath.Join(targetDir, "bin", "go")); err != nil {
+	if _, err := os.Stat(switcher.GoBinaryPath(targetDir)); err != nil {
 		return fmt.Errorf("installed toolchain %s is missing bin/go", normalized)
 	}
+	if err := writeToolchainManifest(targetDir, normalized); err != nil {
+		return err
+	}
 
 	progress.Emit(opts.Reporter, "go-install", fmt.Sprintf("Installed %s", normalized), 0, 0)
 
 	return nil
 }
 
-func ensureArchiveInCache(ctx context.Context, archive releases.File, cachePath string, reporter progress.Reporter) error {
-	if _, err := os.Stat(cachePath); err == nil {
-		if strings.TrimSpace(archive.SHA256) == "" {
-			progress.Emit(reporter, "go-download", fmt.Sprintf("Using cached archive %s", archive.Filename), 0, 0)
-			return nil
-		}
-		ok, verifyErr := verifySHA256(cachePath, archive.SHA256)
-		if verifyErr == nil && ok {
-			progress.Emit(reporter, "go-download", fmt.Sprintf("Using cached archive %s", archive.Filename), 0, 0)
-			return nil
-		}
-		if removeErr := os.Remove(cachePath); removeErr != nil && !os.IsNotExist(removeErr) {
-			return fmt.Errorf("remove bad cached archive %s: %w", cachePath, removeErr)
+// downloadBaseURLs lists the base URLs to try in order: BaseURL when set,
+// otherwise the configured mirrors, falling back to go.dev.
+func downloadBaseURLs(opts InstallOptions) []string {
+	if baseURL := strings.TrimSpace(opts.BaseURL); baseURL != "" {
+		return []string{baseURL}
+	}
+	baseURLs := make([]string, 0, len(opts.Mirrors))
+	for _, mirror := range opts.Mirrors {
+		if mirror = strings.TrimSpace(mirror); mirror != "" {
+			baseURLs = append(baseURLs, mirror)
 		}
 	}
-
-	url := fmt.Sprintf("%s/%s", goDownloadBaseURL, archive.Filename)
-	if err := downloadToFile(ctx, url, cachePath, reporter, "go-download", archive.Filename); err != nil {
-		return fmt.Errorf("download %s: %w", archive.Filename, err)
+	if len(baseURLs) == 0 {
+		return []string{goDownloadBaseURL}
 	}
-
-	return nil
+	return baseURLs
 }
 
-func downloadToFile(ctx context.Context, url string, destination string, reporter progress.Reporter, stage string, label string) error {
-	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
-		return fmt.Errorf("create destination parent: %w", err)
+func fetchGoArchive(ctx context.Context, client *http.Client, paths switcher.Paths, archive releases.File, targetDir string, baseURL string, opts InstallOptions) error {
+	if opts.NoCache {
+		unlockExtraction, err := lockExtraction(ctx, paths, opts.Reporter)
+		if err != nil {
+			return err
+		}
+		defer unlockExtraction()
+		return streamGoArchive(ctx, client, archive, targetDir, baseURL, opts.Reporter)
 	}
 
-	tmpFile, err := os.CreateTemp(filepath.Dir(destination), ".download-*")
+	cachePath := filepath.Join(paths.CacheDir, archive.Filename)
+	unlock, err := filelock.Lock(ctx, cachePath, func() {
+		progress.Emit(opts.Reporter, "go-download", fmt.Sprintf("Waiting for another download of %s to finish", archive.Filename), 0, 0)
+	})
 	if err != nil {
-		return fmt.Errorf("create temporary file: %w", err)
-	}
-	tmpPath := tmpFile.Name()
-
-	cleanup := func() {
-		_ = tmpFile.Close()
-		_ = os.Remove(tmpPath)
+		return err
 	}
+	defer unlock()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		cleanup()
-		return fmt.Errorf("create request: %w", err)
+	if err := ensureArchiveInCache(ctx, client, archive, cachePath, baseURL, opts.Reporter); err != nil {
+		return err
 	}
 
-	client := &http.Client{Timeout: 120 * time.Second}
-	resp, err := client.Do(req)
+	unlockExtraction, err := lockExtraction(ctx, paths, opts.Reporter)
 	if err != nil {
-		cleanup()
-		return fmt.Errorf("perform request: %w", err)
+		return err
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+	defer unlockExtraction()
 
-	if resp.StatusCode != http.StatusOK {
-		cleanup()
-		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
-	}
+	progress.Emit(opts.Reporter, "go-extract", fmt.Sprintf("Extracting %s", archive.Filename), 0, 0)
+	return extractGoArchive(cachePath, targetDir, opts.Reporter)
+}
 
-	total := resp.ContentLength
-	progress.Emit(reporter, stage, fmt.Sprintf("Downloading %s", label), 0, total)
+// lockExtraction takes the lock held while a temporary extraction
+// directory exists under paths.ToolchainsDir, so that
+// RecoverInterruptedExtractions never mistakes it for an orphan.
+func lockExtraction(ctx context.Context, paths switcher.Paths, reporter progress.Reporter) (func(), error) {
+	return filelock.Lock(ctx, paths.ToolchainsDir, func() {
+		progress.Emit(reporter, "go-extract", "Waiting for another install to finish extracting", 0, 0)
+	})
+}
 
-	progressWriter := &downloadProgressWriter{
-		reporter: reporter,
-		stage:    stage,
-		label:    label,
-		total:    total,
+// ensureArchiveInCache leaves a checksum-verified archive at cachePath,
+// reusing a valid cached copy and re-downloading at most maxDownloadAttempts
+// times before giving up with ErrChecksumMismatch.
+func ensureArchiveInCache(ctx context.Context, client *http.Client, archive releases.File, cachePath string, baseURL string, reporter progress.Reporter) error {
+	expected := strings.TrimSpace(archive.SHA256)
+	if info, err := os.Stat(cachePath); err == nil {
+		problem := cachedArchiveProblem(cachePath, info.Size(), archive)
+		switch {
+		case problem != "":
+			progress.Emit(reporter, "go-download", fmt.Sprintf("Cached archive %s is %s; downloading again", archive.Filename, problem), 0, 0)
+		case expected == "":
+			progress.Emit(reporter, "go-download", fmt.Sprintf("Using cached archive %s", archive.Filename), 0, 0)
+			return nil
+		default:
+			ok, verifyErr := verifySHA256(cachePath, expected)
+			if verifyErr == nil && ok {
+				progress.Emit(reporter, "go-download", fmt.Sprintf("Using cached archive %s", archive.Filename), 0, 0)
+				return nil
+			}
+		}
+		if removeErr := os.Remove(cachePath); removeErr != nil && !os.IsNotExist(removeErr) {
+			return fmt.Errorf("remove bad cached archive %s: %w", cachePath, removeErr)
+		}
 	}
 
-	if _, err := io.Copy(tmpFile, io.TeeReader(resp.Body, progressWriter)); err != nil {
-		cleanup()
-		return fmt.Errorf("write response body: %w", err)
+	downloadURL, err := archiveURL(baseURL, archive.Filename)
+	if err != nil {
+		return err
 	}
-	progressWriter.emit(true)
+	for attempt := 1; attempt <= maxDownloadAttempts; attempt++ {
+		// The checksum is computed while downloading, so a fresh download
+		// is not read back from disk.
+		actual, err := httpclient.Download(ctx, client, downloadURL, cachePath, reporter, "go-download", archive.Filename)
+		if err != nil {
+			return fmt.Errorf("download %s: %w", archive.Filename, err)
+		}
+		if expected == "" {
+			return nil
+		}
 
-	if err := tmpFile.Close(); err != nil {
-		cleanup()
-		return fmt.Errorf("close temporary file: %w", err)
-	}
+		progress.Emit(reporter, "go-checksum", fmt.Sprintf("Verifying checksum for %s", archive.Filename), 0, 0)
+		if actual == strings.ToLower(expected) {
+			return nil
+		}
 
-	if err := os.Rename(tmpPath, destination); err != nil {
-		cleanup()
-		return fmt.Errorf("finalize download: %w", err)
+		if err := os.Remove(cachePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove bad download %s: %w", cachePath, err)
+		}
+		if attempt < maxDownloadAttempts {
+			progress.Emit(reporter, "go-checksum", fmt.Sprintf("Checksum mismatch for %s; retrying download", archive.Filename), 0, 0)
+		}
 	}
 
-	return nil
-}
-
-type downloadProgressWriter struct {
-	reporter progress.Reporter
-	stage    string
-	label    string
-	total    int64
-	current  int64
-	lastEmit time.Time
-}
-
-func (w *downloadProgressWriter) Write(p []byte) (int, error) {
-	w.current += int64(len(p))
-	w.emit(false)
-	return len(p), nil
+	return fmt.Errorf("%w for %s after %d download attempts", ErrChecksumMismatch, archive.Filename, maxDownloadAttempts)
 }
 
-func (w *downloadProgressWriter) emit(force bool) {
-	if w.reporter == nil {
-		return
+// cachedArchiveProblem describes why a cached archive cannot be reused
+// without looking at its checksum, e.g. a file left empty by a crash, or
+// returns "" when it looks plausible. Without a checksum to verify, the
+// file must also start with its format's magic bytes.
+func cachedArchiveProblem(cachePath string, size int64, archive releases.File) string {
+	switch {
+	case size == 0:
+		return "empty"
+	case archive.Size > 0 && size != archive.Size:
+		return fmt.Sprintf("%d bytes instead of %d", size, archive.Size)
+	case strings.TrimSpace(archive.SHA256) == "" && !hasArchiveMagic(cachePath):
+		return "not a valid archive"
+	default:
+		return ""
 	}
-	if !force && time.Since(w.lastEmit) < 250*time.Millisecond {
-		return
-	}
-
-	transfer := progress.FormatTransfer(w.current, w.total)
-	progress.Emit(w.reporter, w.stage, fmt.Sprintf("Downloading %s %s", w.label, transfer), w.current, w.total)
-	w.lastEmit = time.Now()
 }
 
-func verifySHA256(filePath string, expectedHex string) (bool, error) {
-	file, err := os.Open(filePath)
+// streamGoArchive downloads and extracts archive in one pass without writing
+// it to the cache. The SHA256 is computed while streaming; the extraction is
+// only promoted to targetDir when it matches.
+func streamGoArchive(ctx context.Context, client *http.Client, archive releases.File, targetDir string, baseURL string, reporter progress.Reporter) error {
+	extractor, err := extractorFor(archive.Filename)
 	if err != nil {
-		return false, fmt.Errorf("open file: %w", err)
-	}
-	defer func() {
-		_ = file.Close()
-	}()
-
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		return false, fmt.Errorf("hash file: %w", err)
+		return err
 	}
-
-	actual := hex.EncodeToString(hasher.Sum(nil))
-	expected := strings.ToLower(strings.TrimSpace(expectedHex))
-	return actual == expected, nil
-}
-
-func extractGoArchive(archivePath string, targetDir string) error {
-	if err := os.RemoveAll(targetDir); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("remove pre-existing target dir %s: %w", targetDir, err)
+	if !streamable(archive.Filename) {
+		return fmt.Errorf("%s: %w", archive.Filename, errNotStreamable)
 	}
-
-	tmpParent := filepath.Dir(targetDir)
-	if err := os.MkdirAll(tmpParent, 0o755); err != nil {
-		return fmt.Errorf("create target parent %s: %w", tmpParent, err)
-	}
-
-	tmpDir, err := os.MkdirTemp(tmpParent, ".tmp-toolchain-")
+	downloadURL, err := archiveURL(baseURL, archive.Filename)
 	if err != nil {
-		return fmt.Errorf("create temp extraction dir: %w", err)
+		return err
 	}
-	defer func() {
-		_ = os.RemoveAll(tmpDir)
-	}()
-
-	archiveFile, err := os.Open(archivePath)
+	resp, err := httpclient.OpenDownload(ctx, client, downloadURL)
 	if err != nil {
-		return fmt.Errorf("open archive %s: %w", archivePath, err)
+		return fmt.Errorf("download %s: %w", archive.Filename, err)
 	}
 	defer func() {
-		_ = archiveFile.Close()
+		_ = resp.Body.Close()
 	}()
 
-	gzReader, err := gzip.NewReader(archiveFile)
-	if err != nil {
-		return fmt.Errorf("create gzip reader: %w", err)
+	total := resp.ContentLength
+	progress.Emit(reporter, "go-download", fmt.Sprintf("Streaming %s", archive.Filename), 0, total)
+	progressWriter := &httpclient.DownloadProgress{
+		Reporter: reporter,
+		Stage:    "go-download",
+		Label:    archive.Filename,
+		Total:    total,
 	}
-	defer func() {
-		_ = gzReader.Close()
-	}()
 
-	tarReader := tar.NewReader(gzReader)
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("read tar entry: %w", err)
-		}
+	hasher := sha256.New()
+	body := io.TeeReader(resp.Body, io.MultiWriter(hasher, progressWriter))
 
-		relativePath, err := stripGoRootPrefix(header.Name)
-		if err != nil {
-			return err
-		}
-		if relativePath == "" {
-			continue
+	verify := func() error {
+		// Hash any bytes the tar reader did not need, e.g. trailing padding.
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return fmt.Errorf("download %s: %w", archive.Filename, err)
 		}
+		progressWriter.Flush()
 
-		targetPath := filepath.Join(tmpDir, relativePath)
-		if err := ensureSafePath(tmpDir, targetPath); err != nil {
-			return err
+		expected := strings.ToLower(strings.TrimSpace(archive.SHA256))
+		if expected == "" {
+			return nil
 		}
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(targetPath, os.FileMode(header.Mode)); err != nil {
-				return fmt.Errorf("create directory %s: %w", targetPath, err)
-			}
-		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(targetPath), 0o755); err != nil {
-				return fmt.Errorf("create parent directory for %s: %w", targetPath, err)
-			}
-			outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
-			if err != nil {
-				return fmt.Errorf("create file %s: %w", targetPath, err)
-			}
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				_ = outFile.Close()
-				return fmt.Errorf("write file %s: %w", targetPath, err)
-			}
-			if err := outFile.Close(); err != nil {
-				return fmt.Errorf("close file %s: %w", targetPath, err)
-			}
-		case tar.TypeSymlink:
-			if err := os.MkdirAll(filepath.Dir(targetPath), 0o755); err != nil {
-				return fmt.Errorf("create symlink parent for %s: %w", targetPath, err)
-			}
-			if err := os.Symlink(header.Linkname, targetPath); err != nil {
-				return fmt.Errorf("create symlink %s -> %s: %w", targetPath, header.Linkname, err)
-			}
-		default:
-			continue
+		progress.Emit(reporter, "go-checksum", fmt.Sprintf("Verifying checksum for %s", archive.Filename), 0, 0)
+		if hex.EncodeToString(hasher.Sum(nil)) != expected {
+			return fmt.Errorf("%w for %s; discarded streamed toolchain", ErrChecksumMismatch, archive.Filename)
 		}
+		return nil
 	}
 
-	if err := os.Rename(tmpDir, targetDir); err != nil {
-		return fmt.Errorf("finalize extraction to %s: %w", targetDir, err)
-	}
-
-	return nil
+	// The download progress already tracks the streamed extraction.
+	return extractToolchain(extractor, body, targetDir, verify, nil)
 }
 
-func stripGoRootPrefix(path string) (string, error) {
-	clean := filepath.Clean(path)
-	parts := strings.Split(clean, string(filepath.Separator))
-	if len(parts) == 0 {
-		return "", nil
-	}
-	if parts[0] != "go" {
-		return "", fmt.Errorf("unexpected archive root for %s", path)
-	}
-	if len(parts) == 1 {
-		return "", nil
+func verifySHA256(filePath string, expectedHex string) (bool, error) {
+	actual, err := fileSHA256(filePath)
+	if err != nil {
+		return false, err
 	}
-	return filepath.Join(parts[1:]...), nil
+	expected := strings.ToLower(strings.TrimSpace(expectedHex))
+	return actual == expected, nil
 }
 
-func ensureSafePath(baseDir string, targetPath string) error {
-	base := filepath.Clean(baseDir)
-	target := filepath.Clean(targetPath)
-	if target == base {
-		return nil
+func fileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
 	}
-	prefix := base + string(filepath.Separator)
-	if !strings.HasPrefix(target, prefix) {
-		return fmt.Errorf("unsafe archive path %s", targetPath)
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("hash file: %w", err)
 	}
-	return nil
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
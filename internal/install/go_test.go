@@ -0,0 +1,209 @@
+package install
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/releases"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+func TestEnsureArchiveInCache_GivesUpAfterRepeatedChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte("corrupt upstream bytes"))
+	}))
+	defer server.Close()
+
+	archive := releases.File{Filename: "go1.24.2.linux-amd64.tar.gz", SHA256: sha256Hex("expected bytes")}
+	cachePath := filepath.Join(t.TempDir(), archive.Filename)
+
+	err := ensureArchiveInCache(context.Background(), server.Client(), archive, cachePath, server.URL, nil)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+	if got := requests.Load(); got != maxDownloadAttempts {
+		t.Fatalf("expected %d download attempts, got %d", maxDownloadAttempts, got)
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Fatalf("expected bad archive to be removed from cache")
+	}
+}
+
+func TestEnsureArchiveInCache_ReusesVerifiedCache(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	archive := releases.File{Filename: "go1.24.2.linux-amd64.tar.gz", SHA256: sha256Hex("cached bytes")}
+	cachePath := filepath.Join(t.TempDir(), archive.Filename)
+	if err := os.WriteFile(cachePath, []byte("cached bytes"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if err := ensureArchiveInCache(context.Background(), server.Client(), archive, cachePath, server.URL, nil); err != nil {
+		t.Fatalf("ensureArchiveInCache: %v", err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Fatalf("expected no downloads, got %d", got)
+	}
+}
+
+func TestEnsureArchiveInCache_RedownloadsBrokenCacheWithoutChecksum(t *testing.T) {
+	t.Parallel()
+
+	upstream := goArchive(t)
+	tests := []struct {
+		name   string
+		cached []byte
+		size   int64
+	}{
+		{name: "zero-byte", cached: []byte{}},
+		{name: "not gzip", cached: []byte("<html>gateway timeout</html>")},
+		{name: "truncated", cached: upstream[:10], size: int64(len(upstream))},
+	}
+	for _, tc := range tests {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			_, _ = w.Write(upstream)
+		}))
+
+		archive := releases.File{Filename: "go1.24.2.linux-amd64.tar.gz", Size: tc.size}
+		cachePath := filepath.Join(t.TempDir(), archive.Filename)
+		if err := os.WriteFile(cachePath, tc.cached, 0o644); err != nil {
+			t.Fatalf("%s: WriteFile: %v", tc.name, err)
+		}
+
+		err := ensureArchiveInCache(context.Background(), server.Client(), archive, cachePath, server.URL, nil)
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: ensureArchiveInCache: %v", tc.name, err)
+		}
+		if got := requests.Load(); got != 1 {
+			t.Fatalf("%s: expected one download, got %d", tc.name, got)
+		}
+		content, err := os.ReadFile(cachePath)
+		if err != nil || !bytes.Equal(content, upstream) {
+			t.Fatalf("%s: expected cache to hold the downloaded archive (%v)", tc.name, err)
+		}
+	}
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestInstallGoArchiveWithOptions_NoCacheStreams(t *testing.T) {
+	t.Parallel()
+
+	archiveBytes := goArchive(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archiveBytes)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		sha256    string
+		wantErr   error
+		installed bool
+	}{
+		{name: "matching checksum", sha256: sha256Hex(string(archiveBytes)), installed: true},
+		{name: "checksum mismatch", sha256: sha256Hex("other bytes"), wantErr: ErrChecksumMismatch},
+	}
+
+	for _, tc := range tests {
+		paths := testPaths(t)
+		archive := releases.File{Filename: "go1.24.2.linux-amd64.tar.gz", SHA256: tc.sha256}
+
+		err := InstallGoArchiveWithOptions(context.Background(), paths, "go1.24.2", archive, InstallOptions{BaseURL: server.URL, NoCache: true})
+		if tc.wantErr != nil {
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+			}
+		} else if err != nil {
+			t.Fatalf("%s: install: %v", tc.name, err)
+		}
+
+		if got := switcher.ToolchainExists(paths, "go1.24.2"); got != tc.installed {
+			t.Fatalf("%s: expected installed=%v, got %v", tc.name, tc.installed, got)
+		}
+		entries, err := os.ReadDir(paths.CacheDir)
+		if err != nil {
+			t.Fatalf("%s: read cache dir: %v", tc.name, err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("%s: expected empty cache, found %d entries", tc.name, len(entries))
+		}
+		entries, err = os.ReadDir(paths.ToolchainsDir)
+		if err != nil {
+			t.Fatalf("%s: read toolchains dir: %v", tc.name, err)
+		}
+		if !tc.installed && len(entries) != 0 {
+			t.Fatalf("%s: expected discarded extraction, found %d entries", tc.name, len(entries))
+		}
+	}
+}
+
+func testPaths(t *testing.T) switcher.Paths {
+	t.Helper()
+	tmp := t.TempDir()
+	return switcher.Paths{
+		BaseDir:       filepath.Join(tmp, ".switcher"),
+		ToolchainsDir: filepath.Join(tmp, ".switcher", "toolchains"),
+		ToolsDir:      filepath.Join(tmp, ".switcher", "tools"),
+		BinDir:        filepath.Join(tmp, ".switcher", "bin"),
+		CacheDir:      filepath.Join(tmp, ".switcher", "cache"),
+		ConfigFile:    filepath.Join(tmp, ".switcher", "config.json"),
+	}
+}
+
+func goArchive(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	entries := []struct {
+		name    string
+		content string
+	}{
+		{name: "go/VERSION", content: "go1.24.2"},
+		{name: "go/bin/go", content: "#!/bin/sh\n"},
+	}
+	for _, entry := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0o755, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("write content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
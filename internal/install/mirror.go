@@ -0,0 +1,118 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const mirrorProbeTimeout = 3 * time.Second
+
+// mirrorOrderCache keeps probe results for the process lifetime, keyed by
+// the configured mirror list.
+var mirrorOrderCache = struct {
+	sync.Mutex
+	orders map[string][]string
+}{orders: map[string][]string{}}
+
+// OrderMirrorsByLatency returns mirrors sorted fastest-first by a HEAD probe
+// of each base URL. Unreachable mirrors keep their relative order at the end.
+// Results are cached, so each mirror list is probed at most once per process.
+func OrderMirrorsByLatency(ctx context.Context, client *http.Client, mirrors []string) []string {
+	if len(mirrors) < 2 {
+		return mirrors
+	}
+
+	key := strings.Join(mirrors, "\n")
+	mirrorOrderCache.Lock()
+	cached, ok := mirrorOrderCache.orders[key]
+	mirrorOrderCache.Unlock()
+	if ok {
+		return append([]string(nil), cached...)
+	}
+
+	latencies := make([]time.Duration, len(mirrors))
+	var wg sync.WaitGroup
+	for i, mirror := range mirrors {
+		wg.Add(1)
+		go func(i int, mirror string) {
+			defer wg.Done()
+			latencies[i] = probeMirror(ctx, client, mirror)
+		}(i, mirror)
+	}
+	wg.Wait()
+
+	indexes := make([]int, len(mirrors))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a int, b int) bool {
+		la, lb := latencies[indexes[a]], latencies[indexes[b]]
+		if la < 0 || lb < 0 {
+			return lb < 0 && la >= 0
+		}
+		return la < lb
+	})
+
+	ordered := make([]string, 0, len(mirrors))
+	for _, i := range indexes {
+		ordered = append(ordered, mirrors[i])
+	}
+
+	mirrorOrderCache.Lock()
+	mirrorOrderCache.orders[key] = ordered
+	mirrorOrderCache.Unlock()
+	return append([]string(nil), ordered...)
+}
+
+// probeMirror returns the round-trip time of a HEAD request to the mirror
+// base, or -1 when it cannot be reached. Any HTTP response counts as
+// reachable since many mirrors do not serve their base path.
+func probeMirror(ctx context.Context, client *http.Client, mirror string) time.Duration {
+	ctx, cancel := context.WithTimeout(ctx, mirrorProbeTimeout)
+	defer cancel()
+
+	base, err := parseBaseURL(mirror)
+	if err != nil {
+		return -1
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, base.JoinPath("/").String(), nil)
+	if err != nil {
+		return -1
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return -1
+	}
+	_ = resp.Body.Close()
+	return time.Since(start)
+}
+
+// archiveURL joins filename onto a download base URL. Trailing slashes and
+// path prefixes are handled, and a query string (e.g. an access token) is
+// kept on the resulting URL.
+func archiveURL(baseURL string, filename string) (string, error) {
+	base, err := parseBaseURL(baseURL)
+	if err != nil {
+		return "", err
+	}
+	return base.JoinPath(filename).String(), nil
+}
+
+func parseBaseURL(raw string) (*url.URL, error) {
+	base, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid download URL %q: %w", raw, err)
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid download URL %q: expected an absolute URL such as https://go.dev/dl", raw)
+	}
+	base.Fragment = ""
+	return base, nil
+}
@@ -0,0 +1,118 @@
+package install
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/releases"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+func TestOrderMirrorsByLatency(t *testing.T) {
+	t.Parallel()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	mirrors := []string{down.URL, slow.URL, fast.URL}
+	got := OrderMirrorsByLatency(context.Background(), http.DefaultClient, mirrors)
+	want := []string{fast.URL, slow.URL, down.URL}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if mirrors[0] != down.URL {
+		t.Fatalf("expected input slice to be left untouched, got %v", mirrors)
+	}
+}
+
+func TestInstallGoArchiveWithOptions_FallsBackToNextMirror(t *testing.T) {
+	t.Parallel()
+
+	archiveBytes := goArchive(t)
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer broken.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archiveBytes)
+	}))
+	defer working.Close()
+
+	paths := testPaths(t)
+	archive := releases.File{Filename: "go1.24.2.linux-amd64.tar.gz", SHA256: sha256Hex(string(archiveBytes))}
+	opts := InstallOptions{Mirrors: []string{broken.URL, working.URL}}
+	if err := InstallGoArchiveWithOptions(context.Background(), paths, "go1.24.2", archive, opts); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if !switcher.ToolchainExists(paths, "go1.24.2") {
+		t.Fatalf("expected toolchain installed from the second mirror")
+	}
+}
+
+func TestArchiveURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		base    string
+		want    string
+		wantErr bool
+	}{
+		{base: "https://go.dev/dl", want: "https://go.dev/dl/go1.24.2.linux-amd64.tar.gz"},
+		{base: "https://mirror.example/go/dl/", want: "https://mirror.example/go/dl/go1.24.2.linux-amd64.tar.gz"},
+		{base: "https://mirror.example", want: "https://mirror.example/go1.24.2.linux-amd64.tar.gz"},
+		{base: " https://mirror.example/ ", want: "https://mirror.example/go1.24.2.linux-amd64.tar.gz"},
+		{base: "https://mirror.example/go?token=a%2Fb&x=1", want: "https://mirror.example/go/go1.24.2.linux-amd64.tar.gz?token=a%2Fb&x=1"},
+		{base: "https://mirror.example/go/?token=abc#frag", want: "https://mirror.example/go/go1.24.2.linux-amd64.tar.gz?token=abc"},
+		{base: "http://127.0.0.1:8080/dl//", want: "http://127.0.0.1:8080/dl/go1.24.2.linux-amd64.tar.gz"},
+		{base: "mirror.example/go", wantErr: true},
+		{base: "://bad", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := archiveURL(tc.base, "go1.24.2.linux-amd64.tar.gz")
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("archiveURL(%q): expected error, got %q", tc.base, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("archiveURL(%q): %v", tc.base, err)
+		}
+		if got != tc.want {
+			t.Fatalf("archiveURL(%q) = %q, want %q", tc.base, got, tc.want)
+		}
+	}
+}
+
+func TestInstallGoArchiveWithOptions_MirrorWithQuery(t *testing.T) {
+	t.Parallel()
+
+	archiveBytes := goArchive(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/go/dl/go1.24.2.linux-amd64.tar.gz" || r.URL.Query().Get("token") != "secret" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(archiveBytes)
+	}))
+	defer server.Close()
+
+	paths := testPaths(t)
+	archive := releases.File{Filename: "go1.24.2.linux-amd64.tar.gz", SHA256: sha256Hex(string(archiveBytes))}
+	opts := InstallOptions{Mirrors: []string{server.URL + "/go/dl/?token=secret"}}
+	if err := InstallGoArchiveWithOptions(context.Background(), paths, "go1.24.2", archive, opts); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package install
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// ErrToolchainInUse is returned when an installed toolchain cannot be
+// replaced because a running process holds files in it open, which Windows
+// enforces with file locks.
+var ErrToolchainInUse = errors.New("toolchain is in use; close running processes and try again")
+
+// errorSharingViolation is the Windows ERROR_SHARING_VIOLATION code.
+const errorSharingViolation = syscall.Errno(32)
+
+// toolchainPromoter swaps a freshly extracted toolchain into place. The
+// previous toolchain is moved aside rather than deleted first, so a failed
+// swap leaves it intact.
+type toolchainPromoter struct {
+	rename func(from string, to string) error
+	inUse  func(error) bool
+	// retryDelays are the waits between rename attempts while the target
+	// is in use; running processes often exit moments later.
+	retryDelays []time.Duration
+}
+
+var defaultPromoter = toolchainPromoter{
+	rename:      os.Rename,
+	inUse:       isToolchainInUse,
+	retryDelays: []time.Duration{100 * time.Millisecond, 500 * time.Millisecond, 2 * time.Second},
+}
+
+func isToolchainInUse(err error) bool {
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	return errors.Is(err, fs.ErrPermission) || errors.Is(err, errorSharingViolation)
+}
+
+func (p toolchainPromoter) promote(tmpDir string, targetDir string) error {
+	backupDir := ""
+	if _, err := os.Lstat(targetDir); err == nil {
+		backupDir = filepath.Join(filepath.Dir(targetDir), fmt.Sprintf("%sold-%s-%d", extractionTempPrefix, filepath.Base(targetDir), time.Now().UnixNano()))
+		if err := p.renameWithRetry(targetDir, backupDir); err != nil {
+			return fmt.Errorf("move aside existing toolchain %s: %w", targetDir, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("stat target dir %s: %w", targetDir, err)
+	}
+
+	if err := p.renameWithRetry(tmpDir, targetDir); err != nil {
+		if backupDir != "" {
+			_ = p.rename(backupDir, targetDir)
+		}
+		return fmt.Errorf("finalize extraction to %s: %w", targetDir, err)
+	}
+	if backupDir != "" {
+		_ = os.RemoveAll(backupDir)
+	}
+	return nil
+}
+
+func (p toolchainPromoter) renameWithRetry(from string, to string) error {
+	err := p.rename(from, to)
+	for _, delay := range p.retryDelays {
+		if err == nil || !p.inUse(err) {
+			return err
+		}
+		time.Sleep(delay)
+		err = p.rename(from, to)
+	}
+	if err != nil && p.inUse(err) {
+		return fmt.Errorf("%w (%v)", ErrToolchainInUse, err)
+	}
+	return err
+}
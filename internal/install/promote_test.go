@@ -0,0 +1,79 @@
+package install
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestToolchainPromoter_KeepsOriginalWhenInUse(t *testing.T) {
+	t.Parallel()
+
+	parent := t.TempDir()
+	targetDir := filepath.Join(parent, "go1.24.2")
+	tmpDir := filepath.Join(parent, extractionTempPrefix+"1")
+	for _, dir := range []string{targetDir, tmpDir} {
+		if err := os.MkdirAll(filepath.Join(dir, "bin"), 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "bin", "go"), []byte(dir), 0o755); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	locked := errors.New("sharing violation")
+	attempts := 0
+	promoter := toolchainPromoter{
+		rename: func(from string, to string) error {
+			if from == targetDir {
+				attempts++
+				return &os.LinkError{Op: "rename", Old: from, New: to, Err: locked}
+			}
+			return os.Rename(from, to)
+		},
+		inUse:       func(err error) bool { return errors.Is(err, locked) },
+		retryDelays: []time.Duration{time.Millisecond, time.Millisecond},
+	}
+
+	err := promoter.promote(tmpDir, targetDir)
+	if !errors.Is(err, ErrToolchainInUse) {
+		t.Fatalf("expected ErrToolchainInUse, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 rename attempts, got %d", attempts)
+	}
+	content, err := os.ReadFile(filepath.Join(targetDir, "bin", "go"))
+	if err != nil || string(content) != targetDir {
+		t.Fatalf("expected original toolchain to survive, got %q (%v)", content, err)
+	}
+}
+
+func TestToolchainPromoter_ReplacesExistingToolchain(t *testing.T) {
+	t.Parallel()
+
+	parent := t.TempDir()
+	targetDir := filepath.Join(parent, "go1.24.2")
+	tmpDir := filepath.Join(parent, extractionTempPrefix+"1")
+	for _, dir := range []string{targetDir, tmpDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte(dir), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	if err := defaultPromoter.promote(tmpDir, targetDir); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(targetDir, "VERSION"))
+	if err != nil || string(content) != tmpDir {
+		t.Fatalf("expected new toolchain in place, got %q (%v)", content, err)
+	}
+	entries, err := os.ReadDir(parent)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected only the toolchain dir to remain, got %v (%v)", entries, err)
+	}
+}
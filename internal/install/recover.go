@@ -0,0 +1,157 @@
+package install
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/filelock"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+	"github.com/mrtuuro/go-switcher/internal/versionutil"
+)
+
+const extractionTempPrefix = ".tmp-toolchain-"
+
+// interruptedExtractionAge is how long a temporary extraction directory must
+// be left untouched before it is treated as orphaned. The extraction lock
+// already rules out concurrent installs; the age only matters where the
+// filesystem has no lock support.
+const interruptedExtractionAge = 10 * time.Minute
+
+// RecoveredExtraction describes an orphaned temporary extraction directory
+// handled by RecoverInterruptedExtractions.
+type RecoveredExtraction struct {
+	TempDir string
+	// Version is set when the directory was promoted to its toolchain.
+	Version string
+	Target  string
+}
+
+func (r RecoveredExtraction) String() string {
+	if r.Version == "" {
+		return fmt.Sprintf("removed incomplete extraction %s", r.TempDir)
+	}
+	return fmt.Sprintf("restored %s from interrupted extraction %s", r.Version, r.TempDir)
+}
+
+// RecoverInterruptedExtractions finds temporary extraction directories left
+// behind when an install was killed before the new toolchain was renamed
+// into place. A directory with bin/go and a readable VERSION file is renamed
+// to its toolchain directory, unless a complete toolchain is already there;
+// anything else is removed. Nothing is done while another process holds the
+// extraction lock, since its temporary directory is still in use.
+func RecoverInterruptedExtractions(paths switcher.Paths) ([]RecoveredExtraction, error) {
+	unlock, locked, err := filelock.TryLock(paths.ToolchainsDir)
+	if err != nil || !locked {
+		return nil, err
+	}
+	defer unlock()
+
+	dirs, err := interruptedExtractionDirs(paths)
+	var recovered []RecoveredExtraction
+	for _, dir := range dirs {
+		result, err := recoverExtraction(dir)
+		if err != nil {
+			return recovered, err
+		}
+		recovered = append(recovered, result)
+	}
+	return recovered, err
+}
+
+// InterruptedExtractions lists the temporary extraction directories that
+// RecoverInterruptedExtractions would handle, without changing anything.
+// It does not take the extraction lock, so a directory an install has left
+// untouched for a long time may be listed while that install still runs.
+func InterruptedExtractions(paths switcher.Paths) ([]string, error) {
+	return interruptedExtractionDirs(paths)
+}
+
+// interruptedExtractionDirs lists temporary extraction directories older
+// than interruptedExtractionAge under the toolchains dir and each cross
+// toolchain dir.
+func interruptedExtractionDirs(paths switcher.Paths) ([]string, error) {
+	parents := []string{paths.ToolchainsDir}
+	crossDirs, err := filepath.Glob(filepath.Join(paths.ToolchainsDir, "cross", "*"))
+	if err != nil {
+		return nil, err
+	}
+	parents = append(parents, crossDirs...)
+
+	var dirs []string
+	for _, parent := range parents {
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return dirs, fmt.Errorf("read %s: %w", parent, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || !strings.HasPrefix(entry.Name(), extractionTempPrefix) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < interruptedExtractionAge {
+				continue
+			}
+			dirs = append(dirs, filepath.Join(parent, entry.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+func recoverExtraction(tmpDir string) (RecoveredExtraction, error) {
+	result := RecoveredExtraction{TempDir: tmpDir}
+	version, ok := extractedVersion(tmpDir)
+	if ok {
+		target := filepath.Join(filepath.Dir(tmpDir), version)
+		if _, err := os.Stat(switcher.GoBinaryPath(target)); err != nil {
+			if err := os.RemoveAll(target); err != nil {
+				return result, fmt.Errorf("remove partial toolchain dir %s: %w", target, err)
+			}
+			if err := os.Rename(tmpDir, target); err != nil {
+				return result, fmt.Errorf("restore %s to %s: %w", tmpDir, target, err)
+			}
+			if err := writeToolchainManifest(target, version); err != nil {
+				return result, err
+			}
+			result.Version = version
+			result.Target = target
+			return result, nil
+		}
+	}
+
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return result, fmt.Errorf("remove incomplete extraction %s: %w", tmpDir, err)
+	}
+	return result, nil
+}
+
+// extractedVersion reports the Go version of a temporary extraction when it
+// looks complete enough to promote.
+func extractedVersion(dir string) (string, bool) {
+	if _, err := os.Stat(switcher.GoBinaryPath(dir)); err != nil {
+		return "", false
+	}
+	file, err := os.Open(filepath.Join(dir, "VERSION"))
+	if err != nil {
+		return "", false
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return "", false
+	}
+	version, err := versionutil.NormalizeGoVersion(strings.TrimSpace(scanner.Text()))
+	if err != nil {
+		return "", false
+	}
+	return version, true
+}
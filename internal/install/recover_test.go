@@ -0,0 +1,105 @@
+package install
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+func TestRecoverInterruptedExtractions(t *testing.T) {
+	t.Parallel()
+
+	paths := testPaths(t)
+	stale := time.Now().Add(-time.Hour)
+	writeTemp := func(name string, files map[string]string, modTime time.Time) string {
+		t.Helper()
+		dir := filepath.Join(paths.ToolchainsDir, name)
+		for file, content := range files {
+			target := filepath.Join(dir, file)
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				t.Fatalf("MkdirAll: %v", err)
+			}
+			if err := os.WriteFile(target, []byte(content), 0o755); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+		}
+		if err := os.Chtimes(dir, modTime, modTime); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+		return dir
+	}
+
+	// Killed after RemoveAll(targetDir) but before the rename.
+	complete := writeTemp(".tmp-toolchain-1", map[string]string{"bin/go": "#!/bin/sh\n", "VERSION": "go1.24.2\ntime 2025-04-01\n"}, stale)
+	partial := writeTemp(".tmp-toolchain-2", map[string]string{"src/fmt/print.go": "package fmt\n"}, stale)
+	active := writeTemp(".tmp-toolchain-3", map[string]string{"bin/go": "#!/bin/sh\n", "VERSION": "go1.25.0\n"}, time.Now())
+
+	recovered, err := RecoverInterruptedExtractions(paths)
+	if err != nil {
+		t.Fatalf("RecoverInterruptedExtractions: %v", err)
+	}
+	if len(recovered) != 2 {
+		t.Fatalf("expected 2 recovered extractions, got %v", recovered)
+	}
+	if recovered[0].Version != "go1.24.2" || recovered[1].Version != "" {
+		t.Fatalf("unexpected recovery results %v", recovered)
+	}
+
+	if !switcher.ToolchainExists(paths, "go1.24.2") {
+		t.Fatalf("expected go1.24.2 to be restored")
+	}
+	if _, err := os.Stat(filepath.Join(switcher.ToolchainDir(paths, "go1.24.2"), ManifestFile)); err != nil {
+		t.Fatalf("expected manifest for restored toolchain: %v", err)
+	}
+	for _, dir := range []string{complete, partial} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be gone", dir)
+		}
+	}
+	if _, err := os.Stat(active); err != nil {
+		t.Fatalf("expected recent extraction to be left alone: %v", err)
+	}
+}
+
+func TestRecoverInterruptedExtractions_LeavesLockedExtractionAlone(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("no file locks on windows")
+	}
+
+	paths := testPaths(t)
+	// A slow install in another process, extracting for longer than the
+	// orphan age.
+	tmpDir := filepath.Join(paths.ToolchainsDir, ".tmp-toolchain-slow")
+	if err := os.MkdirAll(filepath.Join(tmpDir, "src"), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	stale := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(tmpDir, stale, stale); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	unlock, err := lockExtraction(context.Background(), paths, nil)
+	if err != nil {
+		t.Fatalf("lockExtraction: %v", err)
+	}
+
+	recovered, err := RecoverInterruptedExtractions(paths)
+	if err != nil || len(recovered) != 0 {
+		t.Fatalf("expected nothing recovered while the lock is held, got %v (%v)", recovered, err)
+	}
+	if _, err := os.Stat(tmpDir); err != nil {
+		t.Fatalf("expected the in-use extraction to be left alone: %v", err)
+	}
+
+	unlock()
+	recovered, err = RecoverInterruptedExtractions(paths)
+	if err != nil || len(recovered) != 1 {
+		t.Fatalf("expected the extraction to be recovered once the lock is free, got %v (%v)", recovered, err)
+	}
+}
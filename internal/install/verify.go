@@ -0,0 +1,176 @@
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/filelock"
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
+	"github.com/mrtuuro/go-switcher/internal/releases"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+// ManifestFile is written into each toolchain directory at install time and
+// records the sizes of a few key files for later integrity checks.
+const ManifestFile = ".switcher-manifest.json"
+
+type toolchainManifest struct {
+	Version string           `json:"version"`
+	Files   map[string]int64 `json:"files"`
+}
+
+// manifestKeyFiles are checked when present; pkg/tool binaries are matched
+// by glob because their directory is named after the platform.
+var manifestKeyFiles = []string{
+	"VERSION",
+	"bin/go",
+	"bin/gofmt",
+	"pkg/tool/*/compile",
+	"pkg/tool/*/link",
+}
+
+func writeToolchainManifest(toolchainDir string, version string) error {
+	manifest := toolchainManifest{Version: version, Files: map[string]int64{}}
+	for _, pattern := range manifestKeyFiles {
+		matches, err := filepath.Glob(filepath.Join(toolchainDir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return fmt.Errorf("match %s: %w", pattern, err)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			rel, err := filepath.Rel(toolchainDir, match)
+			if err != nil {
+				return err
+			}
+			manifest.Files[filepath.ToSlash(rel)] = info.Size()
+		}
+	}
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode toolchain manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(toolchainDir, ManifestFile), append(encoded, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write toolchain manifest: %w", err)
+	}
+	return nil
+}
+
+// VerifyToolchain runs the toolchain's own `go version` and, when a manifest
+// from install time exists, compares key file sizes against it. It is
+// read-only and returns one message per problem found.
+func VerifyToolchain(ctx context.Context, toolchainDir string, version string) (problems []string, hasManifest bool) {
+	goBinary := switcher.GoBinaryPath(toolchainDir)
+	runCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(runCtx, goBinary, "version").CombinedOutput()
+	switch {
+	case err != nil:
+		problems = append(problems, fmt.Sprintf("go version failed: %v", err))
+	case !strings.Contains(string(output), version+" ") && !strings.Contains(string(output), version+"\n"):
+		problems = append(problems, fmt.Sprintf("go version reported %q, expected %s", strings.TrimSpace(string(output)), version))
+	}
+
+	raw, err := os.ReadFile(filepath.Join(toolchainDir, ManifestFile))
+	if err != nil {
+		return problems, false
+	}
+	var manifest toolchainManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return append(problems, fmt.Sprintf("manifest is unreadable: %v", err)), true
+	}
+
+	files := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		info, err := os.Stat(filepath.Join(toolchainDir, filepath.FromSlash(name)))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s is missing", name))
+			continue
+		}
+		if info.Size() != manifest.Files[name] {
+			problems = append(problems, fmt.Sprintf("%s is %d bytes, expected %d", name, info.Size(), manifest.Files[name]))
+		}
+	}
+
+	return problems, true
+}
+
+// ArchiveCheck is the result of VerifyArchive.
+type ArchiveCheck struct {
+	Path       string
+	Expected   string
+	Actual     string
+	Downloaded bool
+}
+
+func (c ArchiveCheck) Match() bool {
+	return c.Expected != "" && c.Expected == c.Actual
+}
+
+// VerifyArchive hashes the cached archive for archive, downloading it into
+// the cache first when absent. Nothing is extracted or installed, and a
+// mismatching archive is left in place for inspection.
+func VerifyArchive(ctx context.Context, paths switcher.Paths, archive releases.File, opts InstallOptions) (ArchiveCheck, error) {
+	check := ArchiveCheck{
+		Path:     filepath.Join(paths.CacheDir, archive.Filename),
+		Expected: strings.ToLower(strings.TrimSpace(archive.SHA256)),
+	}
+
+	if _, err := os.Stat(check.Path); err != nil {
+		if !os.IsNotExist(err) {
+			return ArchiveCheck{}, fmt.Errorf("stat cached archive %s: %w", check.Path, err)
+		}
+		if err := switcher.EnsureLayout(paths); err != nil {
+			return ArchiveCheck{}, err
+		}
+		unlock, err := filelock.Lock(ctx, check.Path, nil)
+		if err != nil {
+			return ArchiveCheck{}, err
+		}
+		defer unlock()
+		client := httpclient.NewWithOptions(httpclient.Options{
+			Timeout:            120 * time.Second,
+			InsecureSkipVerify: opts.InsecureSkipVerify,
+		})
+		var downloadErr error
+		for _, baseURL := range downloadBaseURLs(opts) {
+			var downloadURL string
+			if downloadURL, downloadErr = archiveURL(baseURL, archive.Filename); downloadErr != nil {
+				break
+			}
+			if check.Actual, downloadErr = httpclient.Download(ctx, client, downloadURL, check.Path, opts.Reporter, "go-download", archive.Filename); downloadErr == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if downloadErr != nil {
+			return ArchiveCheck{}, fmt.Errorf("download %s: %w", archive.Filename, downloadErr)
+		}
+		check.Downloaded = true
+		return check, nil
+	}
+
+	actual, err := fileSHA256(check.Path)
+	if err != nil {
+		return ArchiveCheck{}, fmt.Errorf("hash %s: %w", check.Path, err)
+	}
+	check.Actual = actual
+	return check, nil
+}
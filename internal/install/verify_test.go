@@ -0,0 +1,89 @@
+package install
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/releases"
+)
+
+func TestVerifyToolchain(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	binDir := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	script := "#!/bin/sh\necho go version go1.24.2 linux/amd64\n"
+	if err := os.WriteFile(filepath.Join(binDir, "go"), []byte(script), 0o755); err != nil {
+		t.Fatalf("write go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "gofmt"), []byte("gofmt"), 0o755); err != nil {
+		t.Fatalf("write gofmt: %v", err)
+	}
+
+	if problems, hasManifest := VerifyToolchain(context.Background(), dir, "go1.24.2"); len(problems) != 0 || hasManifest {
+		t.Fatalf("expected healthy toolchain without manifest, got %v (manifest=%v)", problems, hasManifest)
+	}
+
+	if err := writeToolchainManifest(dir, "go1.24.2"); err != nil {
+		t.Fatalf("writeToolchainManifest: %v", err)
+	}
+	if problems, hasManifest := VerifyToolchain(context.Background(), dir, "go1.24.2"); len(problems) != 0 || !hasManifest {
+		t.Fatalf("expected healthy toolchain with manifest, got %v (manifest=%v)", problems, hasManifest)
+	}
+
+	if err := os.WriteFile(filepath.Join(binDir, "gofmt"), []byte("gof"), 0o755); err != nil {
+		t.Fatalf("truncate gofmt: %v", err)
+	}
+	problems, _ := VerifyToolchain(context.Background(), dir, "go1.25.0")
+	if len(problems) != 2 {
+		t.Fatalf("expected version and size problems, got %v", problems)
+	}
+	if !strings.Contains(problems[0], "expected go1.25.0") || !strings.Contains(problems[1], "bin/gofmt") {
+		t.Fatalf("unexpected problems %v", problems)
+	}
+}
+
+func TestVerifyArchive(t *testing.T) {
+	t.Parallel()
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		_, _ = w.Write([]byte("archive"))
+	}))
+	defer server.Close()
+
+	paths := testPaths(t)
+	archive := releases.File{Filename: "go1.24.2.linux-amd64.tar.gz", SHA256: strings.ToUpper(sha256Hex("archive"))}
+	opts := InstallOptions{Mirrors: []string{server.URL}}
+
+	check, err := VerifyArchive(context.Background(), paths, archive, opts)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !check.Downloaded || !check.Match() || downloads != 1 {
+		t.Fatalf("expected a downloaded, matching archive, got %+v after %d downloads", check, downloads)
+	}
+
+	if err := os.WriteFile(check.Path, []byte("tampered"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	check, err = VerifyArchive(context.Background(), paths, archive, opts)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if check.Downloaded || check.Match() || check.Actual != sha256Hex("tampered") || downloads != 1 {
+		t.Fatalf("expected the cached archive to be hashed as-is, got %+v after %d downloads", check, downloads)
+	}
+	if _, err := os.Stat(check.Path); err != nil {
+		t.Fatalf("expected mismatching archive to be kept: %v", err)
+	}
+}
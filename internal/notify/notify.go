@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// sendTimeout bounds how long a notifier may block the command that
+// requested the notification.
+const sendTimeout = 5 * time.Second
+
+// Send shows a desktop notification. It does nothing when the platform has
+// no supported notifier or the notifier is not installed.
+func Send(ctx context.Context, title string, message string) error {
+	name, args, ok := Command(runtime.GOOS, title, message)
+	if !ok {
+		return nil
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	if output, err := exec.CommandContext(ctx, path, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Command returns the program and arguments that show a notification on
+// goos: osascript on macOS, notify-send on Linux and a PowerShell toast on
+// Windows. ok is false for other platforms.
+func Command(goos string, title string, message string) (name string, args []string, ok bool) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return "osascript", []string{"-e", script}, true
+	case "linux":
+		return "notify-send", []string{title, message}, true
+	case "windows":
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, message)}, true
+	default:
+		return "", nil, false
+	}
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func windowsToastScript(title string, message string) string {
+	return strings.Join([]string{
+		"$ErrorActionPreference = 'Stop'",
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+		"$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+		"$text = $template.GetElementsByTagName('text')",
+		"$text.Item(0).AppendChild($template.CreateTextNode(" + powerShellString(title) + ")) > $null",
+		"$text.Item(1).AppendChild($template.CreateTextNode(" + powerShellString(message) + ")) > $null",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('switcher').Show([Windows.UI.Notifications.ToastNotification]::new($template))",
+	}, "; ")
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		goos     string
+		wantName string
+		wantArgs []string
+		contains []string
+	}{
+		{
+			goos:     "darwin",
+			wantName: "osascript",
+			wantArgs: []string{"-e", `display notification "Installed \"go1.25.0\" from C:\\cache" with title "switcher"`},
+		},
+		{
+			goos:     "linux",
+			wantName: "notify-send",
+			wantArgs: []string{"switcher", `Installed "go1.25.0" from C:\cache`},
+		},
+		{
+			goos:     "windows",
+			wantName: "powershell",
+			contains: []string{"CreateTextNode('switcher')", `CreateTextNode('Installed "go1.25.0" from C:\cache')`, "ToastText02"},
+		},
+	}
+	for _, tc := range tests {
+		name, args, ok := Command(tc.goos, "switcher", `Installed "go1.25.0" from C:\cache`)
+		if !ok || name != tc.wantName {
+			t.Fatalf("%s: expected %s, got %q (ok=%v)", tc.goos, tc.wantName, name, ok)
+		}
+		if tc.wantArgs != nil && !slices.Equal(args, tc.wantArgs) {
+			t.Fatalf("%s: expected args %q, got %q", tc.goos, tc.wantArgs, args)
+		}
+		for _, want := range tc.contains {
+			if !strings.Contains(strings.Join(args, " "), want) {
+				t.Fatalf("%s: expected %q in args %q", tc.goos, want, args)
+			}
+		}
+	}
+
+	if _, args, _ := Command("windows", "it's", "done"); !strings.Contains(args[len(args)-1], "CreateTextNode('it''s')") {
+		t.Fatalf("expected single quotes to be doubled for PowerShell, got %q", args)
+	}
+	if _, _, ok := Command("plan9", "switcher", "done"); ok {
+		t.Fatalf("expected no notifier for plan9")
+	}
+}
@@ -2,11 +2,32 @@ package progress
 
 import "fmt"
 
+// Unit is what Event.Current and Event.Total count.
+type Unit int
+
+const (
+	UnitBytes Unit = iota
+	UnitFiles
+)
+
+// Level tells informational events apart from warnings about problems that
+// do not stop the operation.
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelWarning
+)
+
 type Event struct {
 	Stage   string
 	Message string
 	Current int64
 	Total   int64
+	Unit    Unit
+	Level   Level
+	// Err is the problem behind a LevelWarning event, when there is one.
+	Err error
 }
 
 type Reporter func(Event)
@@ -24,6 +45,34 @@ func Emit(reporter Reporter, stage string, message string, current int64, total
 	})
 }
 
+// EmitFiles reports progress counted in files rather than bytes.
+func EmitFiles(reporter Reporter, stage string, message string, current int64, total int64) {
+	if reporter == nil {
+		return
+	}
+
+	reporter(Event{
+		Stage:   stage,
+		Message: message,
+		Current: current,
+		Total:   total,
+		Unit:    UnitFiles,
+	})
+}
+
+// EmitWarning reports a non-fatal problem; Message is err's text.
+func EmitWarning(reporter Reporter, stage string, err error) {
+	if reporter == nil {
+		return
+	}
+	reporter(Event{
+		Stage:   stage,
+		Message: err.Error(),
+		Level:   LevelWarning,
+		Err:     err,
+	})
+}
+
 func FormatBytes(bytes int64) string {
 	const (
 		kb = 1024
@@ -0,0 +1,88 @@
+package progress
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// rateSmoothing is the time constant of RateMeter's moving average: a
+	// change in throughput is mostly reflected after this long.
+	rateSmoothing = 3 * time.Second
+	// rateStallAfter is how long RateMeter waits for a new sample before it
+	// treats the transfer as stalled and lets the rate fall.
+	rateStallAfter = time.Second
+)
+
+// RateMeter turns a stream of byte counts into a smoothed transfer rate. It
+// uses an exponentially weighted moving average over time, so bursty reads
+// do not make the rate and ETA jump around. The zero value is ready to use.
+type RateMeter struct {
+	rate      float64
+	lastAt    time.Time
+	lastBytes int64
+	samples   int
+}
+
+// Observe records that current bytes had been transferred at at. A count
+// lower than the previous one starts the measurement over.
+func (m *RateMeter) Observe(current int64, at time.Time) {
+	if m.samples == 0 || current < m.lastBytes {
+		*m = RateMeter{lastAt: at, lastBytes: current, samples: 1}
+		return
+	}
+	elapsed := at.Sub(m.lastAt)
+	if elapsed <= 0 {
+		return
+	}
+
+	instant := float64(current-m.lastBytes) / elapsed.Seconds()
+	if m.samples == 1 {
+		m.rate = instant
+	} else {
+		weight := 1 - math.Exp(-elapsed.Seconds()/rateSmoothing.Seconds())
+		m.rate += weight * (instant - m.rate)
+	}
+	m.lastAt = at
+	m.lastBytes = current
+	m.samples++
+}
+
+// Rate returns the smoothed rate in bytes per second as of at, or 0 before
+// two samples were observed. When no sample arrived for longer than
+// rateStallAfter, the rate decays as if nothing was transferred since.
+func (m *RateMeter) Rate(at time.Time) float64 {
+	if m.samples < 2 {
+		return 0
+	}
+	rate := m.rate
+	if quiet := at.Sub(m.lastAt) - rateStallAfter; quiet > 0 {
+		rate *= math.Exp(-quiet.Seconds() / rateSmoothing.Seconds())
+	}
+	return rate
+}
+
+// FormatTransferRate extends FormatTransfer with a rate in bytes per second
+// and, when the total is known, the estimated time left, for example
+// "45.20 MB / 150.00 MB (30%) • 8.10 MB/s • ETA 13s". A rate of 0 means it
+// is not known yet and only the transfer size is shown.
+func FormatTransferRate(current int64, total int64, bytesPerSecond float64) string {
+	line := FormatTransfer(current, total)
+	if bytesPerSecond <= 0 {
+		return line
+	}
+
+	line += " • " + FormatRate(bytesPerSecond)
+	if total > current {
+		remaining := time.Duration(float64(total-current) / bytesPerSecond * float64(time.Second))
+		line += " • ETA " + remaining.Round(time.Second).String()
+	}
+	return line
+}
+
+func FormatRate(bytesPerSecond float64) string {
+	if bytesPerSecond < 0 {
+		bytesPerSecond = 0
+	}
+	return FormatBytes(int64(bytesPerSecond)) + "/s"
+}
@@ -0,0 +1,72 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateMeter(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(0, 0)
+	var m RateMeter
+	m.Observe(0, start)
+	if got := m.Rate(start); got != 0 {
+		t.Fatalf("expected no rate from one sample, got %v", got)
+	}
+
+	m.Observe(1000, start.Add(time.Second))
+	if got := m.Rate(start.Add(time.Second)); got != 1000 {
+		t.Fatalf("expected the first interval's rate, got %v", got)
+	}
+
+	// A burst moves the rate towards the new throughput without jumping to it.
+	m.Observe(11000, start.Add(2*time.Second))
+	burst := m.Rate(start.Add(2 * time.Second))
+	if burst <= 1000 || burst >= 10000 {
+		t.Fatalf("expected a smoothed rate between 1000 and 10000, got %v", burst)
+	}
+
+	// Without samples the rate holds briefly, then decays.
+	if got := m.Rate(start.Add(2*time.Second + rateStallAfter)); got != burst {
+		t.Fatalf("expected the rate to hold until the stall threshold, got %v", got)
+	}
+	if got := m.Rate(start.Add(10 * time.Second)); got >= burst/2 {
+		t.Fatalf("expected a stalled transfer's rate to decay, got %v", got)
+	}
+
+	// A lower count, e.g. a restarted download, starts over.
+	m.Observe(10, start.Add(11*time.Second))
+	if got := m.Rate(start.Add(11 * time.Second)); got != 0 {
+		t.Fatalf("expected the rate to reset, got %v", got)
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	t.Parallel()
+
+	if got := FormatRate(2 * 1024 * 1024); got != "2.00 MB/s" {
+		t.Fatalf("unexpected rate %q", got)
+	}
+}
+
+func TestFormatTransferRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		current int64
+		total   int64
+		rate    float64
+		want    string
+	}{
+		{current: 0, total: 100, rate: 0, want: "0 B / 100 B (0%)"},
+		{current: 2 * 1024 * 1024, total: 10 * 1024 * 1024, rate: 1024 * 1024, want: "2.00 MB / 10.00 MB (20%) • 1.00 MB/s • ETA 8s"},
+		{current: 1024, total: 0, rate: 1024, want: "1.00 KB downloaded • 1.00 KB/s"},
+		{current: 100, total: 100, rate: 100, want: "100 B / 100 B (100%) • 100 B/s"},
+	}
+	for _, tc := range tests {
+		if got := FormatTransferRate(tc.current, tc.total, tc.rate); got != tc.want {
+			t.Fatalf("FormatTransferRate(%d, %d, %v) = %q, want %q", tc.current, tc.total, tc.rate, got, tc.want)
+		}
+	}
+}
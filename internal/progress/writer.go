@@ -0,0 +1,89 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nonTTYInterval limits how often byte-level updates are written when the
+// output is not a terminal, so logs are not flooded with download lines.
+const nonTTYInterval = 2 * time.Second
+
+// WriterReporter renders events to w as single-line updates. On a terminal,
+// byte-level events overwrite the current line; otherwise every update is
+// written on its own line.
+func WriterReporter(w io.Writer) Reporter {
+	r := &writerReporter{w: w, tty: isTerminal(w), now: time.Now}
+	return r.report
+}
+
+type writerReporter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	tty bool
+	now func() time.Time
+
+	lastWrite time.Time
+	inPlace   int
+}
+
+func (r *writerReporter) report(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if event.Level == LevelWarning {
+		r.writeLine("warning: " + event.Message)
+		return
+	}
+	if event.Current <= 0 {
+		r.writeLine(event.Message)
+		return
+	}
+
+	line := event.Message
+	done := event.Total > 0 && event.Current >= event.Total
+	if r.tty {
+		padding := ""
+		if r.inPlace > len(line) {
+			padding = strings.Repeat(" ", r.inPlace-len(line))
+		}
+		fmt.Fprintf(r.w, "\r%s%s", line, padding)
+		r.inPlace = len(line)
+		if done {
+			fmt.Fprintln(r.w)
+			r.inPlace = 0
+		}
+		return
+	}
+
+	if !done && !r.lastWrite.IsZero() && now.Sub(r.lastWrite) < nonTTYInterval {
+		return
+	}
+	r.lastWrite = now
+	fmt.Fprintln(r.w, line)
+}
+
+func (r *writerReporter) writeLine(line string) {
+	if r.inPlace > 0 {
+		fmt.Fprintln(r.w)
+		r.inPlace = 0
+	}
+	fmt.Fprintln(r.w, line)
+}
+
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,97 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterReporter_NonTTYThrottlesByteEvents(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	clock := time.Unix(0, 0)
+	r := &writerReporter{w: &out, now: func() time.Time { return clock }}
+
+	r.report(Event{Stage: "go-download", Message: "Downloading go.tar.gz", Total: 100})
+	clock = clock.Add(time.Second)
+	r.report(Event{Stage: "go-download", Message: "Downloading 10", Current: 10, Total: 100})
+	clock = clock.Add(time.Second)
+	r.report(Event{Stage: "go-download", Message: "Downloading 20", Current: 20, Total: 100})
+	clock = clock.Add(time.Second)
+	r.report(Event{Stage: "go-download", Message: "Downloading 100", Current: 100, Total: 100})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", out.String())
+	}
+	if strings.Contains(out.String(), "\r") {
+		t.Fatalf("expected no carriage returns for non-tty output, got %q", out.String())
+	}
+	if lines[2] != "Downloading 100" {
+		t.Fatalf("expected final line as emitted, got %q", lines[2])
+	}
+}
+
+func TestWriterReporter_TTYRewritesLineInPlace(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	clock := time.Unix(0, 0)
+	r := &writerReporter{w: &out, tty: true, now: func() time.Time { return clock }}
+
+	r.report(Event{Stage: "go-download", Message: "Downloading 10", Current: 10, Total: 100})
+	clock = clock.Add(time.Second)
+	r.report(Event{Stage: "go-download", Message: "Downloading 50", Current: 50, Total: 100})
+	r.report(Event{Stage: "go-extract", Message: "Extracting"})
+
+	got := out.String()
+	if strings.Count(got, "\r") != 2 {
+		t.Fatalf("expected two in-place updates, got %q", got)
+	}
+	if !strings.HasSuffix(got, "Downloading 50\nExtracting\n") {
+		t.Fatalf("expected in-place line to be terminated before next stage, got %q", got)
+	}
+}
+
+func TestWriterReporter_FileEventsOmitRate(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	clock := time.Unix(0, 0)
+	r := &writerReporter{w: &out, now: func() time.Time { return clock }}
+
+	r.report(Event{Stage: "go-extract", Message: "Extracting... 1/2 files", Current: 1, Total: 2, Unit: UnitFiles})
+	clock = clock.Add(time.Second)
+	r.report(Event{Stage: "go-extract", Message: "Extracting... 2/2 files", Current: 2, Total: 2, Unit: UnitFiles})
+
+	if got := out.String(); got != "Extracting... 1/2 files\nExtracting... 2/2 files\n" {
+		t.Fatalf("expected file counts without a rate, got %q", got)
+	}
+}
+
+func TestWriterReporter_Warnings(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	clock := time.Unix(0, 0)
+	r := &writerReporter{w: &out, tty: true, now: func() time.Time { return clock }}
+
+	var events []Event
+	reporter := func(event Event) {
+		events = append(events, event)
+		r.report(event)
+	}
+	Emit(reporter, "download", "Downloading go", 10, 100)
+	syncErr := errors.New("golangci-lint v2.9.0 has no release")
+	EmitWarning(reporter, "lint-sync", syncErr)
+
+	if got := out.String(); got != "\rDownloading go\nwarning: golangci-lint v2.9.0 has no release\n" {
+		t.Fatalf("expected warning on its own line, got %q", got)
+	}
+	if last := events[len(events)-1]; last.Level != LevelWarning || !errors.Is(last.Err, syncErr) {
+		t.Fatalf("expected warning event carrying the error, got %+v", last)
+	}
+}
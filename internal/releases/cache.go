@@ -0,0 +1,178 @@
+package releases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
+)
+
+// DefaultCacheTTL is how long a cached release list is used before go.dev
+// is asked whether it changed.
+const DefaultCacheTTL = time.Hour
+
+// CacheFile is the name of the release list cache in the cache directory.
+const CacheFile = "releases.json"
+
+// cachedList is the on-disk form of a release list. ETag and LastModified
+// make revalidation a conditional request that go.dev answers with 304 Not
+// Modified when nothing changed.
+type cachedList struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Releases     []Release `json:"releases"`
+}
+
+// fetchList returns the release list and when it was fetched. fresh reports
+// that it came from the cache without contacting go.dev.
+func (c *Client) fetchList(ctx context.Context, opts FetchOptions) (all []Release, fetchedAt time.Time, fresh bool, err error) {
+	url := c.listURL()
+	if c.CachePath == "" {
+		all, err := c.fetchURL(ctx, url)
+		if err != nil {
+			return nil, time.Time{}, false, err
+		}
+		return all, c.clock().Now(), false, nil
+	}
+
+	now := c.clock().Now()
+	cached, ok := readCachedList(c.CachePath, url)
+	if ok && !opts.Refresh && now.Sub(cached.FetchedAt) < c.cacheTTL() {
+		return cached.Releases, cached.FetchedAt, true, nil
+	}
+
+	all, err = c.revalidate(ctx, url, cached, ok, now)
+	if err != nil {
+		// A stale list beats none when go.dev cannot be reached.
+		if ok && ctx.Err() == nil {
+			return cached.Releases, cached.FetchedAt, false, nil
+		}
+		return nil, time.Time{}, false, err
+	}
+	return all, now, false, nil
+}
+
+// revalidate fetches url, conditionally when a cached list is present, and
+// stores the result in CachePath.
+func (c *Client) revalidate(ctx context.Context, url string, cached cachedList, ok bool, now time.Time) ([]Release, error) {
+	header := http.Header{}
+	if ok {
+		if cached.ETag != "" {
+			header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := c.get(ctx, url, header)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		cached.FetchedAt = now
+	case resp.StatusCode == http.StatusOK:
+		all, err := decodeReleases(resp)
+		if err != nil {
+			return nil, err
+		}
+		cached = cachedList{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			FetchedAt:    now,
+			Releases:     all,
+		}
+	default:
+		return nil, fmt.Errorf("fetch releases: %w", &httpclient.StatusError{StatusCode: resp.StatusCode})
+	}
+
+	// The cache only saves time; failing to write it is not an error.
+	_ = writeCachedList(c.CachePath, cached)
+	return cached.Releases, nil
+}
+
+// fetchVersionCached looks normalized up in the cached release list. A fresh
+// list may predate the release, so a miss revalidates it once.
+func (c *Client) fetchVersionCached(ctx context.Context, normalized string) (Release, error) {
+	all, _, fresh, err := c.fetchList(ctx, FetchOptions{})
+	if err != nil {
+		return Release{}, err
+	}
+	if release, ok := findRelease(all, normalized); ok {
+		return release, nil
+	}
+	if fresh {
+		if all, _, _, err = c.fetchList(ctx, FetchOptions{Refresh: true}); err != nil {
+			return Release{}, err
+		}
+		if release, ok := findRelease(all, normalized); ok {
+			return release, nil
+		}
+	}
+	return Release{}, fmt.Errorf("go release %s %w", normalized, ErrNotFound)
+}
+
+func (c *Client) cacheTTL() time.Duration {
+	if c.CacheTTL == 0 {
+		return DefaultCacheTTL
+	}
+	return c.CacheTTL
+}
+
+// readCachedList returns the list cached at path for url. A missing,
+// unreadable or foreign cache is reported as absent.
+func readCachedList(path string, url string) (cachedList, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cachedList{}, false
+	}
+	var cached cachedList
+	if err := json.Unmarshal(data, &cached); err != nil || cached.URL != url || len(cached.Releases) == 0 {
+		return cachedList{}, false
+	}
+	return cached, true
+}
+
+// writeCachedList replaces the cache at path through a temporary file, so
+// concurrent readers never see a partial list.
+func writeCachedList(path string, cached cachedList) error {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".releases-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package releases
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchDated_CachesOnDisk(t *testing.T) {
+	t.Parallel()
+
+	var requests, notModified atomic.Int32
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"version":"go1.25.0","stable":true,"files":[]}]`))
+	}))
+	defer server.Close()
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	client := &Client{URL: server.URL, Clock: clock, CachePath: filepath.Join(t.TempDir(), CacheFile)}
+
+	fetch := func(opts FetchOptions) time.Time {
+		t.Helper()
+		all, fetchedAt, err := client.FetchDatedWithOptions(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("fetch: %v", err)
+		}
+		if len(all) != 1 || all[0].Version != "go1.25.0" {
+			t.Fatalf("unexpected releases %+v", all)
+		}
+		return fetchedAt
+	}
+
+	if got := fetch(FetchOptions{}); !got.Equal(start) || requests.Load() != 1 {
+		t.Fatalf("expected a live fetch, got fetchedAt=%v after %d requests", got, requests.Load())
+	}
+
+	clock.now = start.Add(DefaultCacheTTL / 2)
+	if got := fetch(FetchOptions{}); !got.Equal(start) || requests.Load() != 1 {
+		t.Fatalf("expected the fresh cache, got fetchedAt=%v after %d requests", got, requests.Load())
+	}
+
+	clock.now = start.Add(DefaultCacheTTL + time.Minute)
+	if got := fetch(FetchOptions{}); !got.Equal(clock.now) || notModified.Load() != 1 {
+		t.Fatalf("expected a 304 revalidation, got fetchedAt=%v after %d not modified", got, notModified.Load())
+	}
+	revalidated := clock.now
+
+	clock.now = revalidated.Add(time.Minute)
+	if fetch(FetchOptions{Refresh: true}); notModified.Load() != 2 {
+		t.Fatalf("expected refresh to revalidate a fresh cache, got %d not modified", notModified.Load())
+	}
+	refreshed := clock.now
+
+	down.Store(true)
+	clock.now = refreshed.Add(2 * DefaultCacheTTL)
+	if got := fetch(FetchOptions{}); !got.Equal(refreshed) {
+		t.Fatalf("expected the stale cache while go.dev is down, got fetchedAt=%v", got)
+	}
+
+	client.CachePath = filepath.Join(t.TempDir(), CacheFile)
+	if _, _, err := client.FetchDated(context.Background()); err == nil {
+		t.Fatalf("expected an error without a cache while go.dev is down")
+	}
+}
+
+func TestFetchVersion_RevalidatesCacheOnMiss(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			_, _ = w.Write([]byte(`[{"version":"go1.25.0","stable":true,"files":[]}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"version":"go1.25.1","stable":true,"files":[]},{"version":"go1.25.0","stable":true,"files":[]}]`))
+	}))
+	defer server.Close()
+
+	client := &Client{URL: server.URL, CachePath: filepath.Join(t.TempDir(), CacheFile)}
+	if _, err := client.FetchVersion(context.Background(), "go1.25.0"); err != nil {
+		t.Fatalf("FetchVersion go1.25.0: %v", err)
+	}
+	release, err := client.FetchVersion(context.Background(), "go1.25.1")
+	if err != nil || release.Version != "go1.25.1" {
+		t.Fatalf("expected the newer release after revalidating, got %+v (%v)", release, err)
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests.Load())
+	}
+}
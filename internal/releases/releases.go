@@ -3,6 +3,7 @@ package releases
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
@@ -10,14 +11,96 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
 	"github.com/mrtuuro/go-switcher/internal/versionutil"
 )
 
 const DefaultURL = "https://go.dev/dl/?mode=json&include=all"
 
+// ErrNotFound is wrapped when a requested version is not in the release list.
+var ErrNotFound = errors.New("not found")
+
+// CurrentURL lists only the currently supported releases and is a small
+// fraction of the size of DefaultURL.
+const CurrentURL = "https://go.dev/dl/?mode=json"
+
+// Fetcher returns the Go release list. *Client is the real implementation;
+// tests can substitute canned releases.
+type Fetcher interface {
+	Fetch(ctx context.Context) ([]Release, error)
+}
+
+// datedFetcher is implemented by fetchers that may serve a cached list,
+// such as *Client, and report when it was fetched.
+type datedFetcher interface {
+	FetchDatedWithOptions(ctx context.Context, opts FetchOptions) ([]Release, time.Time, error)
+}
+
+// versionFetcher is implemented by fetchers that look up a single version
+// more cheaply than fetching the whole list, such as *Client.
+type versionFetcher interface {
+	FetchVersion(ctx context.Context, version string) (Release, error)
+}
+
+// FetchList returns the release list from f with the time it was fetched.
+// opts only apply to fetchers that keep a cache; others always fetch now.
+func FetchList(ctx context.Context, f Fetcher, opts FetchOptions) ([]Release, time.Time, error) {
+	if dated, ok := f.(datedFetcher); ok {
+		return dated.FetchDatedWithOptions(ctx, opts)
+	}
+	all, err := f.Fetch(ctx)
+	return all, time.Now(), err
+}
+
+// FetchVersion looks up one release through f, using its targeted lookup
+// when it has one and filtering the full list otherwise.
+func FetchVersion(ctx context.Context, f Fetcher, version string) (Release, error) {
+	if vf, ok := f.(versionFetcher); ok {
+		return vf.FetchVersion(ctx, version)
+	}
+
+	normalized, err := versionutil.NormalizeGoVersion(version)
+	if err != nil {
+		return Release{}, err
+	}
+	all, err := f.Fetch(ctx)
+	if err != nil {
+		return Release{}, err
+	}
+	if release, ok := findRelease(all, normalized); ok {
+		return release, nil
+	}
+	return Release{}, fmt.Errorf("go release %s %w", normalized, ErrNotFound)
+}
+
+type FetchOptions struct {
+	// Refresh revalidates a cached release list even while it is fresh.
+	Refresh bool
+}
+
+// Listing is the list of versions available for one platform together with
+// the time its release data was fetched.
+type Listing struct {
+	Versions  []string
+	FetchedAt time.Time
+}
+
 type Client struct {
-	URL        string
+	URL string
+	// CurrentURL is tried first by FetchVersion. When empty it defaults to
+	// CurrentURL only if URL is the default feed.
+	CurrentURL string
 	HTTPClient *http.Client
+	// Clock stamps fetched release lists and times retries of the default
+	// HTTP client. Nil means httpclient.SystemClock.
+	Clock httpclient.Clock
+	// CachePath, when set, keeps the release list from URL on disk. It is
+	// reused for CacheTTL, then revalidated with a conditional request, and
+	// served stale when go.dev cannot be reached.
+	CachePath string
+	// CacheTTL is how long a cached list is used without asking go.dev.
+	// Zero means DefaultCacheTTL; a negative TTL revalidates every fetch.
+	CacheTTL time.Duration
 }
 
 type Release struct {
@@ -34,54 +117,193 @@ type File struct {
 	SHA256   string `json:"sha256"`
 	Kind     string `json:"kind"`
 	Size     int64  `json:"size"`
+	// Variant names a microarchitecture build such as goarm7 or goamd64v3.
+	// go.dev never sets it; mirrors may, or may encode it in the filename.
+	Variant string `json:"variant,omitempty"`
+}
+
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// SupportedPlatforms lists the os/arch pairs switcher can install archives for.
+var SupportedPlatforms = []Platform{
+	{OS: "darwin", Arch: "amd64"},
+	{OS: "darwin", Arch: "arm64"},
+	{OS: "linux", Arch: "386"},
+	{OS: "linux", Arch: "amd64"},
+	{OS: "linux", Arch: "arm64"},
+	{OS: "linux", Arch: "armv6l"},
+	{OS: "linux", Arch: "loong64"},
+	{OS: "linux", Arch: "ppc64le"},
+	{OS: "linux", Arch: "riscv64"},
+	{OS: "linux", Arch: "s390x"},
+	{OS: "windows", Arch: "386"},
+	{OS: "windows", Arch: "amd64"},
+	{OS: "windows", Arch: "arm64"},
+}
+
+// ParsePlatform parses an os/arch pair and checks it against SupportedPlatforms.
+func ParsePlatform(raw string) (Platform, error) {
+	goos, goarch, ok := strings.Cut(strings.TrimSpace(raw), "/")
+	if !ok || goos == "" || goarch == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q (expected os/arch)", raw)
+	}
+
+	platform := Platform{OS: strings.ToLower(goos), Arch: strings.ToLower(goarch)}
+	for _, supported := range SupportedPlatforms {
+		if supported == platform {
+			return platform, nil
+		}
+	}
+
+	return Platform{}, fmt.Errorf("unsupported platform %s", platform)
 }
 
 func NewClient() *Client {
 	return &Client{
-		URL: DefaultURL,
-		HTTPClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		URL:        DefaultURL,
+		HTTPClient: httpclient.New(60 * time.Second),
 	}
 }
 
 func (c *Client) Fetch(ctx context.Context) ([]Release, error) {
-	url := c.URL
-	if strings.TrimSpace(url) == "" {
-		url = DefaultURL
+	all, _, _, err := c.fetchList(ctx, FetchOptions{})
+	return all, err
+}
+
+// FetchDated is Fetch with the time the list was fetched according to the
+// client's clock. A list served from CachePath reports when it was last
+// fetched or revalidated.
+func (c *Client) FetchDated(ctx context.Context) ([]Release, time.Time, error) {
+	return c.FetchDatedWithOptions(ctx, FetchOptions{})
+}
+
+// FetchDatedWithOptions is FetchDated with opts applied.
+func (c *Client) FetchDatedWithOptions(ctx context.Context, opts FetchOptions) ([]Release, time.Time, error) {
+	all, fetchedAt, _, err := c.fetchList(ctx, opts)
+	return all, fetchedAt, err
+}
+
+func (c *Client) listURL() string {
+	if url := strings.TrimSpace(c.URL); url != "" {
+		return url
 	}
+	return DefaultURL
+}
 
-	httpClient := c.HTTPClient
-	if httpClient == nil {
-		httpClient = &http.Client{Timeout: 60 * time.Second}
+func (c *Client) clock() httpclient.Clock {
+	if c.Clock == nil {
+		return httpclient.SystemClock
 	}
+	return c.Clock
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// FetchVersion returns the release metadata for a single version. It checks
+// the small current-releases feed first and falls back to the full list for
+// older versions or when the targeted feed is unavailable.
+func (c *Client) FetchVersion(ctx context.Context, version string) (Release, error) {
+	normalized, err := versionutil.NormalizeGoVersion(version)
 	if err != nil {
-		return nil, fmt.Errorf("create releases request: %w", err)
+		return Release{}, err
 	}
 
-	resp, err := httpClient.Do(req)
+	if c.CachePath != "" {
+		return c.fetchVersionCached(ctx, normalized)
+	}
+
+	if currentURL := c.currentURL(); currentURL != "" {
+		current, err := c.fetchURL(ctx, currentURL)
+		if err != nil && ctx.Err() != nil {
+			return Release{}, err
+		}
+		if release, ok := findRelease(current, normalized); ok {
+			return release, nil
+		}
+	}
+
+	all, err := c.Fetch(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("fetch releases: %w", err)
+		return Release{}, err
+	}
+	if release, ok := findRelease(all, normalized); ok {
+		return release, nil
+	}
+	return Release{}, fmt.Errorf("go release %s %w", normalized, ErrNotFound)
+}
+
+func (c *Client) currentURL() string {
+	if strings.TrimSpace(c.CurrentURL) != "" {
+		return c.CurrentURL
+	}
+	if url := strings.TrimSpace(c.URL); url == "" || url == DefaultURL {
+		return CurrentURL
+	}
+	return ""
+}
+
+func findRelease(all []Release, normalized string) (Release, bool) {
+	return NewIndex(all).find(normalized)
+}
+
+func (c *Client) fetchURL(ctx context.Context, url string) ([]Release, error) {
+	resp, err := c.get(ctx, url, nil)
+	if err != nil {
+		return nil, err
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fetch releases returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("fetch releases: %w", &httpclient.StatusError{StatusCode: resp.StatusCode})
 	}
+	return decodeReleases(resp)
+}
 
+// get requests url with header added and returns the response whatever its
+// status.
+func (c *Client) get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = httpclient.NewWithOptions(httpclient.Options{Timeout: 60 * time.Second, Clock: c.Clock})
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create releases request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch releases: %w", err)
+	}
+	return resp, nil
+}
+
+func decodeReleases(resp *http.Response) ([]Release, error) {
 	var all []Release
 	if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
 		return nil, fmt.Errorf("decode releases response: %w", err)
 	}
-
 	return all, nil
 }
 
 func (r Release) ArchiveFor(goos string, goarch string) (File, bool) {
+	return r.ArchiveForVariant(goos, goarch, "")
+}
+
+// ArchiveForVariant is ArchiveFor restricted to one microarchitecture
+// variant. An empty variant only matches archives without one.
+func (r Release) ArchiveForVariant(goos string, goarch string, variant string) (File, bool) {
 	for _, f := range r.Files {
 		if f.Kind != "archive" {
 			continue
@@ -89,7 +311,10 @@ func (r Release) ArchiveFor(goos string, goarch string) (File, bool) {
 		if f.OS != goos || f.Arch != goarch {
 			continue
 		}
-		if !strings.HasSuffix(f.Filename, ".tar.gz") {
+		if !strings.HasSuffix(f.Filename, ArchiveSuffix(goos)) {
+			continue
+		}
+		if f.ArchiveVariant() != variant {
 			continue
 		}
 		return f, true
@@ -98,44 +323,205 @@ func (r Release) ArchiveFor(goos string, goarch string) (File, bool) {
 	return File{}, false
 }
 
-func AvailableVersions(all []Release, goos string, goarch string) []string {
-	if strings.TrimSpace(goos) == "" {
-		goos = runtime.GOOS
+// ArchiveSuffix is the archive format switcher installs for goos: go.dev
+// ships .zip archives for Windows and .tar.gz everywhere else.
+func ArchiveSuffix(goos string) string {
+	if goos == "windows" {
+		return ".zip"
 	}
-	if strings.TrimSpace(goarch) == "" {
-		goarch = runtime.GOARCH
+	return ".tar.gz"
+}
+
+// ArchiveVariant returns the file's variant, falling back to a suffix after
+// the os-arch part of the filename, e.g. go1.24.2.linux-armv6l-goarm7.tar.gz.
+func (f File) ArchiveVariant() string {
+	if f.Variant != "" {
+		return NormalizeVariant(f.Variant)
 	}
 
-	set := map[string]struct{}{}
-	for _, r := range all {
-		if _, ok := r.ArchiveFor(goos, goarch); !ok {
-			continue
+	platform := "." + f.OS + "-" + f.Arch
+	idx := strings.Index(f.Filename, platform)
+	if idx < 0 {
+		return ""
+	}
+	rest := strings.TrimSuffix(f.Filename[idx+len(platform):], ArchiveSuffix(f.OS))
+	if rest == "" || (rest[0] != '-' && rest[0] != '.') {
+		return ""
+	}
+	return NormalizeVariant(rest[1:])
+}
+
+// NormalizeVariant turns GOARM=7 or GOAMD64=v3 style input into the compact
+// goarm7/goamd64v3 form used for matching.
+func NormalizeVariant(raw string) string {
+	normalized := strings.ToLower(strings.TrimSpace(raw))
+	return strings.ReplaceAll(normalized, "=", "")
+}
+
+// VariantFromEnv derives the variant implied by GOARM or GOAMD64 for goarch.
+func VariantFromEnv(goarch string, getenv func(string) string) string {
+	switch goarch {
+	case "amd64":
+		if value := strings.TrimSpace(getenv("GOAMD64")); value != "" {
+			return NormalizeVariant("goamd64" + value)
 		}
+	case "arm", "armv6l":
+		if value := strings.TrimSpace(getenv("GOARM")); value != "" {
+			return NormalizeVariant("goarm" + value)
+		}
+	}
+	return ""
+}
 
+// Index is a fetched release list with every version normalized once, so
+// listing and archive lookups over the same fetch share the work.
+type Index struct {
+	releases []Release
+	// versions holds the normalized form of each release version, or "" when
+	// it does not normalize.
+	versions []string
+	keys     []versionutil.VersionKey
+}
+
+func NewIndex(all []Release) *Index {
+	idx := &Index{
+		releases: all,
+		versions: make([]string, len(all)),
+		keys:     make([]versionutil.VersionKey, len(all)),
+	}
+	for i, r := range all {
 		normalized, err := versionutil.NormalizeGoVersion(r.Version)
 		if err != nil {
 			continue
 		}
-		set[normalized] = struct{}{}
+		key, ok := versionutil.ParseVersionKey(normalized)
+		if !ok {
+			continue
+		}
+		idx.versions[i] = normalized
+		idx.keys[i] = key
 	}
+	return idx
+}
+
+func (idx *Index) Releases() []Release {
+	return idx.releases
+}
 
-	versions := make([]string, 0, len(set))
-	for v := range set {
-		versions = append(versions, v)
+func (idx *Index) find(normalized string) (Release, bool) {
+	for i, v := range idx.versions {
+		if v != "" && v == normalized {
+			return idx.releases[i], true
+		}
 	}
+	return Release{}, false
+}
 
-	sort.Slice(versions, func(i int, j int) bool {
-		cmp, err := versionutil.CompareGoVersions(versions[i], versions[j])
-		if err != nil {
-			return versions[i] > versions[j]
+func AvailableVersions(all []Release, goos string, goarch string) []string {
+	return NewIndex(all).AvailableVersions(goos, goarch)
+}
+
+// AvailableVersions returns the normalized versions with an archive for
+// goos/goarch, newest first.
+func (idx *Index) AvailableVersions(goos string, goarch string) []string {
+	if strings.TrimSpace(goos) == "" {
+		goos = runtime.GOOS
+	}
+	if strings.TrimSpace(goarch) == "" {
+		goarch = runtime.GOARCH
+	}
+
+	seen := map[string]struct{}{}
+	matches := make([]int, 0, len(idx.releases))
+	for i, r := range idx.releases {
+		normalized := idx.versions[i]
+		if normalized == "" {
+			continue
+		}
+		if _, ok := seen[normalized]; ok {
+			continue
 		}
-		return cmp > 0
+		if _, ok := r.ArchiveFor(goos, goarch); !ok {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		matches = append(matches, i)
+	}
+
+	sort.Slice(matches, func(i int, j int) bool {
+		return idx.keys[matches[i]].Compare(idx.keys[matches[j]]) > 0
 	})
 
+	versions := make([]string, len(matches))
+	for i, m := range matches {
+		versions[i] = idx.versions[m]
+	}
 	return versions
 }
 
+// Channel names a moving release track that resolves to a concrete version.
+type Channel string
+
+const (
+	ChannelStable Channel = "stable"
+	ChannelRC     Channel = "rc"
+	ChannelTip    Channel = "tip"
+)
+
+func ParseChannel(raw string) (Channel, error) {
+	switch channel := Channel(strings.ToLower(strings.TrimSpace(raw))); channel {
+	case ChannelStable, ChannelRC, ChannelTip:
+		return channel, nil
+	default:
+		return "", fmt.Errorf("invalid channel %q (expected stable, rc or tip)", raw)
+	}
+}
+
+// LatestInChannel returns the newest version in channel that has an archive
+// for goos/goarch. The rc channel follows prereleases while they are newer
+// than the latest stable release and that release otherwise.
+func (idx *Index) LatestInChannel(channel Channel, goos string, goarch string) (string, error) {
+	switch channel {
+	case ChannelStable, ChannelRC:
+	case ChannelTip:
+		return "", fmt.Errorf("the tip channel is not supported: tip is not published as a release archive")
+	default:
+		return "", fmt.Errorf("invalid channel %q (expected stable, rc or tip)", channel)
+	}
+
+	stable := map[string]bool{}
+	for i, r := range idx.releases {
+		if r.Stable && idx.versions[i] != "" {
+			stable[idx.versions[i]] = true
+		}
+	}
+	for _, v := range idx.AvailableVersions(goos, goarch) {
+		if channel == ChannelRC || stable[v] {
+			return v, nil
+		}
+	}
+	if strings.TrimSpace(goos) == "" {
+		goos = runtime.GOOS
+	}
+	if strings.TrimSpace(goarch) == "" {
+		goarch = runtime.GOARCH
+	}
+	return "", fmt.Errorf("no %s release available for %s/%s", channel, goos, goarch)
+}
+
 func FindArchive(all []Release, version string, goos string, goarch string) (File, string, error) {
+	return FindArchiveVariant(all, version, goos, goarch, "")
+}
+
+func FindArchiveVariant(all []Release, version string, goos string, goarch string, variant string) (File, string, error) {
+	return NewIndex(all).FindArchiveVariant(version, goos, goarch, variant)
+}
+
+func (idx *Index) FindArchive(version string, goos string, goarch string) (File, string, error) {
+	return idx.FindArchiveVariant(version, goos, goarch, "")
+}
+
+func (idx *Index) FindArchiveVariant(version string, goos string, goarch string, variant string) (File, string, error) {
 	normalized, err := versionutil.NormalizeGoVersion(version)
 	if err != nil {
 		return File{}, "", err
@@ -148,20 +534,69 @@ func FindArchive(all []Release, version string, goos string, goarch string) (Fil
 		goarch = runtime.GOARCH
 	}
 
-	for _, r := range all {
-		releaseVersion, err := versionutil.NormalizeGoVersion(r.Version)
+	r, ok := idx.find(normalized)
+	if !ok {
+		return File{}, "", fmt.Errorf("go release %s %w", normalized, ErrNotFound)
+	}
+	if variant != "" {
+		archive, ok := r.ArchiveForVariant(goos, goarch, variant)
+		if !ok {
+			return File{}, "", fmt.Errorf("%s has no %s archive for %s/%s", normalized, variant, goos, goarch)
+		}
+		return archive, normalized, nil
+	}
+	archive, ok := r.ArchiveFor(goos, goarch)
+	if !ok {
+		return File{}, "", idx.unavailableError(r, normalized, goos, goarch)
+	}
+	return archive, normalized, nil
+}
+
+func (r Release) hasInstaller(goos string, goarch string) bool {
+	for _, f := range r.Files {
+		if f.Kind == "installer" && f.OS == goos && f.Arch == goarch && strings.HasSuffix(f.Filename, ".pkg") {
+			return true
+		}
+	}
+	return false
+}
+
+func (idx *Index) unavailableError(release Release, version string, goos string, goarch string) error {
+	if goos != "darwin" || !release.hasInstaller(goos, goarch) {
+		return fmt.Errorf("%s is not available for %s/%s", version, goos, goarch)
+	}
+
+	message := fmt.Sprintf("%s is only published as a .pkg installer for %s/%s; switcher needs a .tar.gz archive", version, goos, goarch)
+	if nearest, ok := idx.nearestWithArchive(version, goos, goarch); ok {
+		message += fmt.Sprintf(" (nearest version with an archive: %s)", nearest)
+	}
+	return errors.New(message)
+}
+
+// nearestWithArchive prefers the oldest newer release with an archive and
+// falls back to the newest older one.
+func (idx *Index) nearestWithArchive(version string, goos string, goarch string) (string, bool) {
+	newer := ""
+	older := ""
+	for _, v := range idx.AvailableVersions(goos, goarch) {
+		cmp, err := versionutil.CompareGoVersions(v, version)
 		if err != nil {
 			continue
 		}
-		if releaseVersion != normalized {
+		if cmp > 0 {
+			newer = v
 			continue
 		}
-		archive, ok := r.ArchiveFor(goos, goarch)
-		if !ok {
-			return File{}, "", fmt.Errorf("%s is not available for %s/%s", normalized, goos, goarch)
+		if cmp < 0 && older == "" {
+			older = v
 		}
-		return archive, normalized, nil
 	}
 
-	return File{}, "", fmt.Errorf("go release %s not found", normalized)
+	if newer != "" {
+		return newer, true
+	}
+	if older != "" {
+		return older, true
+	}
+	return "", false
 }
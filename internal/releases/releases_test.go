@@ -0,0 +1,387 @@
+package releases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFindArchive_DarwinInstallerOnlySuggestsNearest(t *testing.T) {
+	t.Parallel()
+
+	all := []Release{
+		{Version: "go1.9.3", Files: []File{
+			{Filename: "go1.9.3.darwin-amd64.tar.gz", OS: "darwin", Arch: "amd64", Kind: "archive"},
+		}},
+		{Version: "go1.9.2", Files: []File{
+			{Filename: "go1.9.2.darwin-amd64.pkg", OS: "darwin", Arch: "amd64", Kind: "installer"},
+		}},
+		{Version: "go1.8.7", Files: []File{
+			{Filename: "go1.8.7.darwin-amd64.tar.gz", OS: "darwin", Arch: "amd64", Kind: "archive"},
+		}},
+	}
+
+	_, _, err := FindArchive(all, "go1.9.2", "darwin", "amd64")
+	if err == nil {
+		t.Fatalf("expected error for installer-only release")
+	}
+	if !strings.Contains(err.Error(), ".pkg installer") {
+		t.Fatalf("expected .pkg installer hint, got %q", err.Error())
+	}
+	if !strings.Contains(err.Error(), "go1.9.3") {
+		t.Fatalf("expected nearest version suggestion go1.9.3, got %q", err.Error())
+	}
+}
+
+func TestFindArchive_NonDarwinKeepsGenericError(t *testing.T) {
+	t.Parallel()
+
+	all := []Release{
+		{Version: "go1.24.2", Files: []File{
+			{Filename: "go1.24.2.linux-amd64.tar.gz", OS: "linux", Arch: "amd64", Kind: "archive"},
+		}},
+	}
+
+	_, _, err := FindArchive(all, "go1.24.2", "linux", "arm64")
+	if err == nil {
+		t.Fatalf("expected error for missing platform")
+	}
+	if got := err.Error(); got != "go1.24.2 is not available for linux/arm64" {
+		t.Fatalf("unexpected error %q", got)
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw     string
+		want    Platform
+		wantErr bool
+	}{
+		{raw: "linux/amd64", want: Platform{OS: "linux", Arch: "amd64"}},
+		{raw: " Darwin/ARM64 ", want: Platform{OS: "darwin", Arch: "arm64"}},
+		{raw: "linux", wantErr: true},
+		{raw: "/amd64", wantErr: true},
+		{raw: "plan9/amd64", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParsePlatform(tc.raw)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("%q: expected error", tc.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.raw, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: expected %v, got %v", tc.raw, tc.want, got)
+		}
+	}
+}
+
+func TestFetchVersion_PrefersCurrentFeed(t *testing.T) {
+	t.Parallel()
+
+	var fullHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/current", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"version":"go1.25.0","stable":true,"files":[]}]`))
+	})
+	mux.HandleFunc("/all", func(w http.ResponseWriter, r *http.Request) {
+		fullHits.Add(1)
+		_, _ = w.Write([]byte(`[{"version":"go1.25.0","stable":true},{"version":"go1.20.1","stable":true}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &Client{URL: server.URL + "/all", CurrentURL: server.URL + "/current", HTTPClient: server.Client()}
+
+	release, err := client.FetchVersion(context.Background(), "1.25.0")
+	if err != nil {
+		t.Fatalf("FetchVersion current: %v", err)
+	}
+	if release.Version != "go1.25.0" || fullHits.Load() != 0 {
+		t.Fatalf("expected current feed hit without full fetch, got %q (full hits %d)", release.Version, fullHits.Load())
+	}
+
+	release, err = client.FetchVersion(context.Background(), "go1.20.1")
+	if err != nil {
+		t.Fatalf("FetchVersion fallback: %v", err)
+	}
+	if release.Version != "go1.20.1" || fullHits.Load() != 1 {
+		t.Fatalf("expected fallback to full feed, got %q (full hits %d)", release.Version, fullHits.Load())
+	}
+
+	if _, err := client.FetchVersion(context.Background(), "go1.19.0"); err == nil {
+		t.Fatalf("expected error for unknown release")
+	}
+}
+
+func TestFetchVersion_FallsBackWhenCurrentFeedFails(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/current", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	})
+	mux.HandleFunc("/all", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"version":"go1.25.0","stable":true}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &Client{URL: server.URL + "/all", CurrentURL: server.URL + "/current", HTTPClient: server.Client()}
+	release, err := client.FetchVersion(context.Background(), "go1.25.0")
+	if err != nil {
+		t.Fatalf("FetchVersion: %v", err)
+	}
+	if release.Version != "go1.25.0" {
+		t.Fatalf("unexpected release %q", release.Version)
+	}
+}
+
+// listFetcher is the smallest Fetcher: a canned list and nothing else.
+type listFetcher []Release
+
+func (f listFetcher) Fetch(context.Context) ([]Release, error) {
+	return f, nil
+}
+
+func TestFetchVersion_FiltersPlainFetcher(t *testing.T) {
+	t.Parallel()
+
+	f := listFetcher{{Version: "go1.25.0"}, {Version: "go1.24.2"}}
+	tests := []struct {
+		version string
+		want    string
+		wantErr error
+	}{
+		{version: "1.24.2", want: "go1.24.2"},
+		{version: "go1.25.0", want: "go1.25.0"},
+		{version: "go1.23.0", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		release, err := FetchVersion(context.Background(), f, tt.version)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%s: expected %v, got %v", tt.version, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || release.Version != tt.want {
+			t.Fatalf("%s: expected %s, got %+v (%v)", tt.version, tt.want, release, err)
+		}
+	}
+}
+
+func TestFetchList_PlainFetcherIsFetchedNow(t *testing.T) {
+	t.Parallel()
+
+	before := time.Now()
+	all, fetchedAt, err := FetchList(context.Background(), listFetcher{{Version: "go1.25.0"}}, FetchOptions{Refresh: true})
+	if err != nil || len(all) != 1 {
+		t.Fatalf("FetchList: %v (%v)", all, err)
+	}
+	if fetchedAt.Before(before) {
+		t.Fatalf("expected a plain fetcher to report the current time, got %v", fetchedAt)
+	}
+}
+
+func TestArchiveForVariant(t *testing.T) {
+	t.Parallel()
+
+	release := Release{Version: "go1.24.2", Files: []File{
+		{Filename: "go1.24.2.linux-armv6l.tar.gz", OS: "linux", Arch: "armv6l", Kind: "archive"},
+		{Filename: "go1.24.2.linux-armv6l-goarm7.tar.gz", OS: "linux", Arch: "armv6l", Kind: "archive"},
+		{Filename: "go1.24.2.linux-amd64.tar.gz", OS: "linux", Arch: "amd64", Kind: "archive", Variant: "GOAMD64=v3"},
+	}}
+
+	tests := []struct {
+		goarch  string
+		variant string
+		want    string
+	}{
+		{goarch: "armv6l", variant: "", want: "go1.24.2.linux-armv6l.tar.gz"},
+		{goarch: "armv6l", variant: "goarm7", want: "go1.24.2.linux-armv6l-goarm7.tar.gz"},
+		{goarch: "amd64", variant: "goamd64v3", want: "go1.24.2.linux-amd64.tar.gz"},
+		{goarch: "amd64", variant: "", want: ""},
+		{goarch: "armv6l", variant: "goarm6", want: ""},
+	}
+
+	for _, tc := range tests {
+		got, ok := release.ArchiveForVariant("linux", tc.goarch, tc.variant)
+		if tc.want == "" {
+			if ok {
+				t.Fatalf("%s/%q: expected no archive, got %s", tc.goarch, tc.variant, got.Filename)
+			}
+			continue
+		}
+		if !ok || got.Filename != tc.want {
+			t.Fatalf("%s/%q: expected %s, got %s (ok=%v)", tc.goarch, tc.variant, tc.want, got.Filename, ok)
+		}
+	}
+}
+
+func TestArchiveFor_WindowsZip(t *testing.T) {
+	t.Parallel()
+
+	release := Release{Version: "go1.24.2", Files: []File{
+		{Filename: "go1.24.2.windows-amd64.msi", OS: "windows", Arch: "amd64", Kind: "installer"},
+		{Filename: "go1.24.2.windows-amd64.zip", OS: "windows", Arch: "amd64", Kind: "archive"},
+		{Filename: "go1.24.2.linux-amd64.zip", OS: "linux", Arch: "amd64", Kind: "archive"},
+	}}
+
+	if got, ok := release.ArchiveFor("windows", "amd64"); !ok || got.Filename != "go1.24.2.windows-amd64.zip" {
+		t.Fatalf("expected the windows zip, got %q (ok=%v)", got.Filename, ok)
+	}
+	if got, ok := release.ArchiveFor("linux", "amd64"); ok {
+		t.Fatalf("expected zip archives to be ignored outside windows, got %s", got.Filename)
+	}
+	if _, err := ParsePlatform("windows/arm64"); err != nil {
+		t.Fatalf("expected windows/arm64 to be supported: %v", err)
+	}
+}
+
+func TestVariantFromEnv(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"GOARM": "7", "GOAMD64": "v3"}
+	getenv := func(key string) string { return env[key] }
+
+	if got := VariantFromEnv("armv6l", getenv); got != "goarm7" {
+		t.Fatalf("expected goarm7, got %q", got)
+	}
+	if got := VariantFromEnv("amd64", getenv); got != "goamd64v3" {
+		t.Fatalf("expected goamd64v3, got %q", got)
+	}
+	if got := VariantFromEnv("arm64", getenv); got != "" {
+		t.Fatalf("expected no variant for arm64, got %q", got)
+	}
+}
+
+func TestIndex_AvailableVersionsAndFind(t *testing.T) {
+	t.Parallel()
+
+	linux := []File{{Filename: "x.tar.gz", OS: "linux", Arch: "amd64", Kind: "archive"}}
+	idx := NewIndex([]Release{
+		{Version: "go1.9.2", Files: linux},
+		{Version: "go1.25rc1", Files: linux},
+		{Version: "go1.10", Files: linux},
+		{Version: "go1.10.0", Files: linux},
+		{Version: "go1.24.1"},
+	})
+
+	got := idx.AvailableVersions("linux", "amd64")
+	want := []string{"go1.25rc1", "go1.10.0", "go1.9.2"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if _, normalized, err := idx.FindArchive("1.10", "linux", "amd64"); err != nil || normalized != "go1.10.0" {
+		t.Fatalf("expected go1.10.0, got %q (%v)", normalized, err)
+	}
+	if _, normalized, err := idx.FindArchive("1.25rc1", "linux", "amd64"); err != nil || normalized != "go1.25rc1" {
+		t.Fatalf("expected go1.25rc1, got %q (%v)", normalized, err)
+	}
+	if _, _, err := idx.FindArchive("go1.24.1", "linux", "amd64"); err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Fatalf("expected unavailable error, got %v", err)
+	}
+}
+
+// benchmarkReleases builds a list shaped like the include=all feed: ~400
+// releases with an archive per common platform plus an rc release without
+// archives per minor line.
+func benchmarkReleases() []Release {
+	platforms := []Platform{
+		{OS: "linux", Arch: "amd64"}, {OS: "linux", Arch: "arm64"}, {OS: "linux", Arch: "386"},
+		{OS: "linux", Arch: "armv6l"}, {OS: "darwin", Arch: "amd64"}, {OS: "darwin", Arch: "arm64"},
+		{OS: "windows", Arch: "amd64"}, {OS: "windows", Arch: "386"}, {OS: "freebsd", Arch: "amd64"},
+	}
+	var all []Release
+	for minor := 25; minor >= 0; minor-- {
+		for patch := 15; patch >= 0; patch-- {
+			version := fmt.Sprintf("go1.%d.%d", minor, patch)
+			release := Release{Version: version, Stable: true}
+			for _, p := range platforms {
+				release.Files = append(release.Files, File{Filename: fmt.Sprintf("%s.%s-%s.tar.gz", version, p.OS, p.Arch), OS: p.OS, Arch: p.Arch, Kind: "archive"})
+			}
+			all = append(all, release)
+		}
+		all = append(all, Release{Version: fmt.Sprintf("go1.%drc1", minor)})
+	}
+	return all
+}
+
+// BenchmarkListAndFind mirrors a command that lists remote versions and then
+// looks up archives for several platforms from the same fetch, on a list of
+// about 400 releases.
+func BenchmarkListAndFind(b *testing.B) {
+	all := benchmarkReleases()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		idx := NewIndex(all)
+		idx.AvailableVersions("linux", "amd64")
+		for _, platform := range []string{"amd64", "arm64", "386"} {
+			if _, _, err := idx.FindArchive("go1.3.2", "linux", platform); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) error {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func TestFetchDated_UsesClock(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"version":"go1.25.0","stable":true}]`))
+	}))
+	defer server.Close()
+
+	start := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	client := &Client{URL: server.URL, Clock: clock}
+
+	all, fetchedAt, err := client.FetchDated(context.Background())
+	if err != nil {
+		t.Fatalf("FetchDated: %v", err)
+	}
+	if len(all) != 1 || all[0].Version != "go1.25.0" {
+		t.Fatalf("unexpected releases %+v", all)
+	}
+	if len(clock.waits) != 1 || clock.waits[0] != 20*time.Second {
+		t.Fatalf("expected the capped rate limit wait on the fake clock, got %v", clock.waits)
+	}
+	if want := start.Add(20 * time.Second); !fetchedAt.Equal(want) {
+		t.Fatalf("expected fetch time %s, got %s", want, fetchedAt)
+	}
+}
@@ -1,33 +1,194 @@
 package switcher
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mrtuuro/go-switcher/internal/filelock"
 )
 
 type Config struct {
 	GlobalVersion    string            `json:"global_version,omitempty"`
 	GolangCILintByGo map[string]string `json:"golangci_lint_by_go,omitempty"`
+	// GolangCILintPinned lists Go versions whose GolangCILintByGo entry was
+	// pinned with `use --lint` and is never upgraded automatically.
+	GolangCILintPinned []string `json:"golangci_lint_pinned,omitempty"`
+	// GolangCILintDefault is used for every Go version without a pin,
+	// instead of the recommended version.
+	GolangCILintDefault string `json:"golangci_lint_default,omitempty"`
+	// RememberScope opts in to persisting the TUI scope toggle as DefaultScope.
+	RememberScope bool   `json:"remember_scope,omitempty"`
+	DefaultScope  string `json:"default_scope,omitempty"`
+	// GorootManaged records that `use --set-goroot` persisted GOROOT with
+	// `go env -w`, so the next switch knows to clear it.
+	GorootManaged bool `json:"goroot_managed,omitempty"`
+	// Mirrors are Go archive download base URLs tried in order. Empty uses
+	// go.dev.
+	Mirrors []string `json:"mirrors,omitempty"`
+	// ProbeMirrors opts in to ordering Mirrors by a latency probe.
+	ProbeMirrors bool `json:"probe_mirrors,omitempty"`
+	// GoTelemetry is the default `go telemetry` mode applied after installs.
+	GoTelemetry string `json:"go_telemetry,omitempty"`
+	// RecordHistory logs every `use` to history.jsonl, like `use --record`.
+	RecordHistory bool `json:"record_history,omitempty"`
+	// PostSwitchHook is a shell command run after a successful `use`, with
+	// {{.Version}} and {{.Scope}} placeholders.
+	PostSwitchHook string `json:"post_switch_hook,omitempty"`
+	// GlobalHistory holds previous global versions, most recent first, for
+	// `use -N`. GlobalHistoryLimit caps its length.
+	GlobalHistory      []string `json:"global_history,omitempty"`
+	GlobalHistoryLimit int      `json:"global_history_limit,omitempty"`
+	// ReleaseCacheTTL is how long the cached go.dev release list is used
+	// before it is revalidated, as a Go duration such as "30m". "0"
+	// revalidates on every fetch; empty uses the default of one hour.
+	ReleaseCacheTTL string `json:"release_cache_ttl,omitempty"`
+	// GoModFallback opts in to using the go directive of the nearest go.mod
+	// as the local version when no .switcher-version is found.
+	GoModFallback bool `json:"go_mod_fallback,omitempty"`
+
+	// localSnapshot is the merged config as ReadConfig returned it, kept
+	// when config.local.json was merged in so WriteConfig can tell local
+	// values from changes made since.
+	localSnapshot map[string]any
+}
+
+// ReleaseCacheDuration parses ReleaseCacheTTL. set is false when it is empty.
+func (c Config) ReleaseCacheDuration() (ttl time.Duration, set bool, err error) {
+	raw := strings.TrimSpace(c.ReleaseCacheTTL)
+	if raw == "" {
+		return 0, false, nil
+	}
+	ttl, err = time.ParseDuration(raw)
+	if err != nil || ttl < 0 {
+		return 0, false, fmt.Errorf("invalid release_cache_ttl %q (expected a duration such as 30m or 2h)", c.ReleaseCacheTTL)
+	}
+	return ttl, true, nil
+}
+
+type ConfigOptions struct {
+	// Strict makes a corrupt config file or an invalid setting a hard error
+	// instead of a warning.
+	Strict bool
 }
 
+// ReadConfig reads the config leniently: problems are repaired or ignored
+// without a report. Commands check the config once up front with
+// ReadConfigWithOptions, which reports them.
 func ReadConfig(paths Paths) (Config, error) {
-	if err := EnsureLayout(paths); err != nil {
-		return Config{}, err
+	cfg, _, err := ReadConfigWithOptions(paths, ConfigOptions{})
+	return cfg, err
+}
+
+// ReadConfigWithOptions reads the config and returns a warning for every
+// problem it repaired or ignored.
+func ReadConfigWithOptions(paths Paths, opts ConfigOptions) (Config, []string, error) {
+	if err := paths.Validate(); err != nil {
+		return Config{}, nil, err
 	}
 
+	var warnings []string
+	cfg := Config{GolangCILintByGo: map[string]string{}}
 	raw, err := os.ReadFile(paths.ConfigFile)
+	switch {
+	case err == nil:
+		cfg, err = ParseConfig(raw)
+		if err != nil {
+			if opts.Strict {
+				return Config{}, nil, fmt.Errorf("decode config %s: %w", paths.ConfigFile, err)
+			}
+			var warning string
+			if cfg, warning, err = recoverCorruptConfig(paths, err); err != nil {
+				return Config{}, nil, err
+			}
+			warnings = append(warnings, warning)
+		}
+	case !os.IsNotExist(err):
+		return Config{}, nil, fmt.Errorf("read config %s: %w", paths.ConfigFile, err)
+	}
+
+	cfg, merged, warning, err := applyLocalConfig(paths, cfg, opts)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return Config{GolangCILintByGo: map[string]string{}}, nil
+		return Config{}, nil, err
+	}
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+	cfg, warning, err = normalizeConfigScopes(paths, cfg, opts)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+	if merged {
+		cfg.localSnapshot, _ = toJSONObject(cfg)
+	}
+	return cfg, warnings, nil
+}
+
+// normalizeConfigScopes passes every scope stored in config through
+// ParseScope, so callers never see an invalid one. An invalid value falls
+// back to global with a warning, or is an error in strict mode.
+func normalizeConfigScopes(paths Paths, cfg Config, opts ConfigOptions) (Config, string, error) {
+	if cfg.DefaultScope == "" {
+		return cfg, "", nil
+	}
+	scope, err := ParseScope(cfg.DefaultScope)
+	if err == nil {
+		cfg.DefaultScope = string(scope)
+		return cfg, "", nil
+	}
+	if opts.Strict {
+		return Config{}, "", fmt.Errorf("config %s: default_scope: %w", paths.ConfigFile, err)
+	}
+	cfg.DefaultScope = string(ScopeGlobal)
+	return cfg, fmt.Sprintf("config default_scope: %v; using %s", err, ScopeGlobal), nil
+}
+
+// applyLocalConfig merges config.local.json over cfg and reports whether
+// it did. An unreadable local file is an error in strict mode and is
+// otherwise ignored with a warning; unlike the base config it is never moved
+// aside.
+func applyLocalConfig(paths Paths, cfg Config, opts ConfigOptions) (Config, bool, string, error) {
+	local, err := readLocalConfig(paths)
+	if err == nil && local == nil {
+		return cfg, false, "", nil
+	}
+
+	merged := cfg
+	if err == nil {
+		var base map[string]any
+		base, err = toJSONObject(cfg)
+		if err == nil {
+			var encoded []byte
+			encoded, err = json.Marshal(mergeConfigJSON(base, local))
+			if err == nil {
+				merged, err = ParseConfig(encoded)
+				if err != nil {
+					err = fmt.Errorf("decode local config %s: %w", LocalConfigFile(paths), err)
+				}
+			}
+		}
+	}
+	if err != nil {
+		if opts.Strict {
+			return Config{}, false, "", err
 		}
-		return Config{}, fmt.Errorf("read config %s: %w", paths.ConfigFile, err)
+		return cfg, false, fmt.Sprintf("ignoring local config overrides: %v", err), nil
 	}
+	return merged, true, "", nil
+}
 
+// ParseConfig decodes config file contents the same way ReadConfig does.
+func ParseConfig(raw []byte) (Config, error) {
 	var cfg Config
 	if err := json.Unmarshal(raw, &cfg); err != nil {
-		return Config{}, fmt.Errorf("decode config %s: %w", paths.ConfigFile, err)
+		return Config{}, err
 	}
 
 	if cfg.GolangCILintByGo == nil {
@@ -37,6 +198,17 @@ func ReadConfig(paths Paths) (Config, error) {
 	return cfg, nil
 }
 
+// recoverCorruptConfig moves a config file that cannot be decoded aside and
+// returns defaults; the next WriteConfig writes a fresh file.
+func recoverCorruptConfig(paths Paths, decodeErr error) (Config, string, error) {
+	backupPath := fmt.Sprintf("%s.corrupt-%s", paths.ConfigFile, time.Now().UTC().Format("20060102T150405Z"))
+	if err := os.Rename(paths.ConfigFile, backupPath); err != nil {
+		return Config{}, "", fmt.Errorf("decode config %s: %w (backup failed: %v)", paths.ConfigFile, decodeErr, err)
+	}
+	warning := fmt.Sprintf("config %s is corrupt (%v); moved it to %s and continuing with defaults", paths.ConfigFile, decodeErr, backupPath)
+	return Config{GolangCILintByGo: map[string]string{}}, warning, nil
+}
+
 func WriteConfig(paths Paths, cfg Config) error {
 	if err := EnsureLayout(paths); err != nil {
 		return err
@@ -45,6 +217,7 @@ func WriteConfig(paths Paths, cfg Config) error {
 	if cfg.GolangCILintByGo == nil {
 		cfg.GolangCILintByGo = map[string]string{}
 	}
+	cfg = withoutLocalOverrides(paths, cfg)
 
 	encoded, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
@@ -59,6 +232,42 @@ func WriteConfig(paths Paths, cfg Config) error {
 	return nil
 }
 
+// UpdateConfig reads the config, applies update and writes it back while
+// holding the config lock, so concurrent switcher processes do not overwrite
+// each other's changes. Nothing is written when update returns false. Every
+// read-modify-write of the config goes through it.
+func UpdateConfig(paths Paths, update func(cfg *Config) bool) error {
+	if err := EnsureLayout(paths); err != nil {
+		return err
+	}
+	unlock, err := filelock.Lock(context.Background(), paths.ConfigFile, nil)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := ReadConfig(paths)
+	if err != nil {
+		return err
+	}
+	if !update(&cfg) {
+		return nil
+	}
+	return WriteConfig(paths, cfg)
+}
+
+// WriteConfigData atomically replaces the config file with raw, e.g. a
+// hand-edited copy. raw is rejected unless ParseConfig accepts it.
+func WriteConfigData(paths Paths, raw []byte) error {
+	if _, err := ParseConfig(raw); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := writeFileAtomically(paths.ConfigFile, raw, 0o644); err != nil {
+		return fmt.Errorf("write config %s: %w", paths.ConfigFile, err)
+	}
+	return nil
+}
+
 func writeFileAtomically(path string, content []byte, perm os.FileMode) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return fmt.Errorf("create parent directory: %w", err)
@@ -0,0 +1,142 @@
+package switcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LocalConfigFileName is an optional per-machine override next to the main
+// config. It is deep-merged over the base config by ReadConfig and never
+// written by WriteConfig.
+const LocalConfigFileName = "config.local.json"
+
+func LocalConfigFile(paths Paths) string {
+	return filepath.Join(filepath.Dir(paths.ConfigFile), LocalConfigFileName)
+}
+
+// readLocalConfig returns the raw local override, or nil when there is none.
+func readLocalConfig(paths Paths) (map[string]any, error) {
+	localFile := LocalConfigFile(paths)
+	raw, err := os.ReadFile(localFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read local config %s: %w", localFile, err)
+	}
+
+	var local map[string]any
+	if err := json.Unmarshal(raw, &local); err != nil {
+		return nil, fmt.Errorf("decode local config %s: %w", localFile, err)
+	}
+	return local, nil
+}
+
+// mergeConfigJSON deep-merges local over base. Objects such as
+// golangci_lint_by_go are merged key by key; any other value in local
+// replaces the base value.
+func mergeConfigJSON(base map[string]any, local map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(local))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range local {
+		localObject, localIsObject := value.(map[string]any)
+		baseObject, baseIsObject := merged[key].(map[string]any)
+		if localIsObject && baseIsObject {
+			merged[key] = mergeConfigJSON(baseObject, localObject)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// unmergeConfigJSON reverses mergeConfigJSON for a config about to be
+// written: values of local keys that are unchanged since the config was
+// read, as recorded in snapshot, are replaced by the base value, or dropped
+// when the base had none, so local settings never leak into the base file.
+// Values that were changed are kept, even when they equal the local value.
+func unmergeConfigJSON(merged map[string]any, base map[string]any, local map[string]any, snapshot map[string]any) {
+	for key, localValue := range local {
+		current, ok := merged[key]
+		if !ok {
+			continue
+		}
+		read, ok := snapshot[key]
+		if !ok {
+			continue
+		}
+		localObject, localIsObject := localValue.(map[string]any)
+		currentObject, currentIsObject := current.(map[string]any)
+		readObject, readIsObject := read.(map[string]any)
+		if localIsObject && currentIsObject && readIsObject {
+			baseObject, _ := base[key].(map[string]any)
+			unmergeConfigJSON(currentObject, baseObject, localObject, readObject)
+			continue
+		}
+		if !jsonEqual(current, read) {
+			continue
+		}
+		if baseValue, ok := base[key]; ok {
+			merged[key] = baseValue
+		} else {
+			delete(merged, key)
+		}
+	}
+}
+
+func jsonEqual(a any, b any) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+// withoutLocalOverrides strips values that came from config.local.json out
+// of cfg before it is written to the base config file. A config that was not
+// read with a local override merged in is written as is.
+func withoutLocalOverrides(paths Paths, cfg Config) Config {
+	if cfg.localSnapshot == nil {
+		return cfg
+	}
+	local, err := readLocalConfig(paths)
+	if err != nil || local == nil {
+		return cfg
+	}
+
+	base := map[string]any{}
+	if raw, err := os.ReadFile(paths.ConfigFile); err == nil {
+		_ = json.Unmarshal(raw, &base)
+	}
+	current, err := toJSONObject(cfg)
+	if err != nil {
+		return cfg
+	}
+	unmergeConfigJSON(current, base, local, cfg.localSnapshot)
+
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return cfg
+	}
+	stripped, err := ParseConfig(encoded)
+	if err != nil {
+		return cfg
+	}
+	return stripped
+}
+
+// toJSONObject round-trips v through JSON into a generic object.
+func toJSONObject(v any) (map[string]any, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	object := map[string]any{}
+	if err := json.Unmarshal(encoded, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
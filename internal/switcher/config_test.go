@@ -0,0 +1,286 @@
+package switcher
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadConfigWithOptions_RecoversCorruptConfig(t *testing.T) {
+	t.Parallel()
+
+	paths := testConfigPaths(t)
+	if err := os.WriteFile(paths.ConfigFile, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, warnings, err := ReadConfigWithOptions(paths, ConfigOptions{})
+	if err != nil {
+		t.Fatalf("ReadConfigWithOptions: %v", err)
+	}
+	if cfg.GlobalVersion != "" || cfg.GolangCILintByGo == nil {
+		t.Fatalf("expected default config, got %+v", cfg)
+	}
+	if !strings.Contains(strings.Join(warnings, "\n"), "corrupt") {
+		t.Fatalf("expected corrupt config warning, got %q", strings.Join(warnings, "\n"))
+	}
+
+	backups, err := filepath.Glob(paths.ConfigFile + ".corrupt-*")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected one backup, got %v (%v)", backups, err)
+	}
+	if _, err := os.Stat(paths.ConfigFile); !os.IsNotExist(err) {
+		t.Fatalf("expected corrupt config to be moved aside, got %v", err)
+	}
+
+	if err := WriteConfig(paths, Config{GlobalVersion: "go1.25.0"}); err != nil {
+		t.Fatalf("WriteConfig: %v", err)
+	}
+	healed, _, err := ReadConfigWithOptions(paths, ConfigOptions{Strict: true})
+	if err != nil || healed.GlobalVersion != "go1.25.0" {
+		t.Fatalf("expected healed config, got %+v (%v)", healed, err)
+	}
+}
+
+func TestReadConfigWithOptions_StrictFailsOnCorruptConfig(t *testing.T) {
+	t.Parallel()
+
+	paths := testConfigPaths(t)
+	if err := os.WriteFile(paths.ConfigFile, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	if _, _, err := ReadConfigWithOptions(paths, ConfigOptions{Strict: true}); err == nil {
+		t.Fatalf("expected strict mode to fail")
+	}
+	if _, err := os.Stat(paths.ConfigFile); err != nil {
+		t.Fatalf("expected config to be left in place, got %v", err)
+	}
+}
+
+func testConfigPaths(t *testing.T) Paths {
+	t.Helper()
+	tmp := t.TempDir()
+	paths := Paths{
+		BaseDir:       filepath.Join(tmp, ".switcher"),
+		ToolchainsDir: filepath.Join(tmp, ".switcher", "toolchains"),
+		ToolsDir:      filepath.Join(tmp, ".switcher", "tools"),
+		BinDir:        filepath.Join(tmp, ".switcher", "bin"),
+		CacheDir:      filepath.Join(tmp, ".switcher", "cache"),
+		ConfigFile:    filepath.Join(tmp, ".switcher", "config.json"),
+	}
+	if err := EnsureLayout(paths); err != nil {
+		t.Fatalf("EnsureLayout: %v", err)
+	}
+	return paths
+}
+
+func TestReadConfig_MergesLocalOverrides(t *testing.T) {
+	t.Parallel()
+
+	paths := testConfigPaths(t)
+	base := `{
+  "global_version": "go1.24.2",
+  "golangci_lint_by_go": {"go1.24.2": "v1.60.3", "go1.23.4": "v1.59.1"},
+  "mirrors": ["https://mirror.example.com/golang"]
+}`
+	local := `{
+  "default_scope": "local",
+  "golangci_lint_by_go": {"go1.24.2": "v1.64.8"},
+  "mirrors": ["https://office-cache.example.com/golang"]
+}`
+	if err := os.WriteFile(paths.ConfigFile, []byte(base), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := os.WriteFile(LocalConfigFile(paths), []byte(local), 0o644); err != nil {
+		t.Fatalf("write local config: %v", err)
+	}
+
+	cfg, _, err := ReadConfigWithOptions(paths, ConfigOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("ReadConfigWithOptions: %v", err)
+	}
+	if cfg.GlobalVersion != "go1.24.2" || cfg.DefaultScope != "local" {
+		t.Fatalf("expected base and local scalars to merge, got %+v", cfg)
+	}
+	if cfg.GolangCILintByGo["go1.24.2"] != "v1.64.8" || cfg.GolangCILintByGo["go1.23.4"] != "v1.59.1" {
+		t.Fatalf("expected lint mappings merged key by key, got %v", cfg.GolangCILintByGo)
+	}
+	if len(cfg.Mirrors) != 1 || cfg.Mirrors[0] != "https://office-cache.example.com/golang" {
+		t.Fatalf("expected local mirrors to replace the base list, got %v", cfg.Mirrors)
+	}
+
+	cfg.GlobalVersion = "go1.25.0"
+	cfg.GolangCILintByGo["go1.25.0"] = "v1.64.8"
+	if err := WriteConfig(paths, cfg); err != nil {
+		t.Fatalf("WriteConfig: %v", err)
+	}
+
+	localAfter, err := os.ReadFile(LocalConfigFile(paths))
+	if err != nil || string(localAfter) != local {
+		t.Fatalf("expected local config to be untouched, got %q (%v)", string(localAfter), err)
+	}
+	written, err := os.ReadFile(paths.ConfigFile)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	baseCfg, err := ParseConfig(written)
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	if baseCfg.GlobalVersion != "go1.25.0" || baseCfg.DefaultScope != "" {
+		t.Fatalf("expected only base values in config.json, got %+v", baseCfg)
+	}
+	if baseCfg.GolangCILintByGo["go1.24.2"] != "v1.60.3" || baseCfg.GolangCILintByGo["go1.25.0"] != "v1.64.8" {
+		t.Fatalf("expected base lint mappings without local overrides, got %v", baseCfg.GolangCILintByGo)
+	}
+	if len(baseCfg.Mirrors) != 1 || baseCfg.Mirrors[0] != "https://mirror.example.com/golang" {
+		t.Fatalf("expected base mirrors to be kept, got %v", baseCfg.Mirrors)
+	}
+}
+
+func TestWriteConfig_StripsOnlyLocalValuesUnchangedSinceRead(t *testing.T) {
+	t.Parallel()
+
+	t.Run("normalized local value", func(t *testing.T) {
+		t.Parallel()
+		paths := testConfigPaths(t)
+		if err := os.WriteFile(LocalConfigFile(paths), []byte(`{"default_scope": " Local "}`), 0o644); err != nil {
+			t.Fatalf("write local config: %v", err)
+		}
+		cfg, err := ReadConfig(paths)
+		if err != nil {
+			t.Fatalf("ReadConfig: %v", err)
+		}
+		cfg.GlobalVersion = "go1.25.0"
+		if err := WriteConfig(paths, cfg); err != nil {
+			t.Fatalf("WriteConfig: %v", err)
+		}
+		assertBaseDefaultScope(t, paths, "")
+	})
+
+	t.Run("set to the local value after the read", func(t *testing.T) {
+		t.Parallel()
+		paths := testConfigPaths(t)
+		cfg, err := ReadConfig(paths)
+		if err != nil {
+			t.Fatalf("ReadConfig: %v", err)
+		}
+		if err := os.WriteFile(LocalConfigFile(paths), []byte(`{"default_scope": "local"}`), 0o644); err != nil {
+			t.Fatalf("write local config: %v", err)
+		}
+		cfg.DefaultScope = "local"
+		if err := WriteConfig(paths, cfg); err != nil {
+			t.Fatalf("WriteConfig: %v", err)
+		}
+		assertBaseDefaultScope(t, paths, "local")
+	})
+}
+
+func assertBaseDefaultScope(t *testing.T, paths Paths, want string) {
+	t.Helper()
+	raw, err := os.ReadFile(paths.ConfigFile)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	base, err := ParseConfig(raw)
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	if base.DefaultScope != want {
+		t.Fatalf("expected default_scope %q in config.json, got %q", want, base.DefaultScope)
+	}
+}
+
+func TestReadConfigWithOptions_IgnoresCorruptLocalConfig(t *testing.T) {
+	t.Parallel()
+
+	paths := testConfigPaths(t)
+	if err := WriteConfig(paths, Config{GlobalVersion: "go1.24.2"}); err != nil {
+		t.Fatalf("WriteConfig: %v", err)
+	}
+	if err := os.WriteFile(LocalConfigFile(paths), []byte("{oops"), 0o644); err != nil {
+		t.Fatalf("write local config: %v", err)
+	}
+
+	cfg, warnings, err := ReadConfigWithOptions(paths, ConfigOptions{})
+	if err != nil || cfg.GlobalVersion != "go1.24.2" {
+		t.Fatalf("expected base config, got %+v (%v)", cfg, err)
+	}
+	if !strings.Contains(strings.Join(warnings, "\n"), "local config") {
+		t.Fatalf("expected local config warning, got %q", strings.Join(warnings, "\n"))
+	}
+	if _, _, err := ReadConfigWithOptions(paths, ConfigOptions{Strict: true}); err == nil {
+		t.Fatalf("expected strict mode to fail on corrupt local config")
+	}
+}
+
+func TestReadConfig_NormalizesDefaultScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		stored      string
+		want        string
+		wantWarning bool
+	}{
+		{name: "unset", stored: "", want: ""},
+		{name: "canonical", stored: "local", want: "local"},
+		{name: "mixed case", stored: " Local ", want: "local"},
+		{name: "invalid", stored: "project", want: "global", wantWarning: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			paths := testConfigPaths(t)
+			raw, err := json.Marshal(map[string]string{"default_scope": tc.stored})
+			if err != nil {
+				t.Fatalf("encode config: %v", err)
+			}
+			if err := os.WriteFile(paths.ConfigFile, raw, 0o644); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+
+			cfg, warnings, err := ReadConfigWithOptions(paths, ConfigOptions{})
+			if err != nil {
+				t.Fatalf("ReadConfigWithOptions: %v", err)
+			}
+			if cfg.DefaultScope != tc.want {
+				t.Fatalf("expected default scope %q, got %q", tc.want, cfg.DefaultScope)
+			}
+			if got := strings.Contains(strings.Join(warnings, "\n"), "default_scope"); got != tc.wantWarning {
+				t.Fatalf("expected warning=%v, got %q", tc.wantWarning, strings.Join(warnings, "\n"))
+			}
+			if _, _, err := ReadConfigWithOptions(paths, ConfigOptions{Strict: true}); (err != nil) != tc.wantWarning {
+				t.Fatalf("expected strict error=%v, got %v", tc.wantWarning, err)
+			}
+		})
+	}
+}
+
+func TestConfig_ReleaseCacheDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw     string
+		want    time.Duration
+		wantSet bool
+		wantErr bool
+	}{
+		{raw: "", want: 0, wantSet: false},
+		{raw: "30m", want: 30 * time.Minute, wantSet: true},
+		{raw: "0", want: 0, wantSet: true},
+		{raw: "-1h", wantErr: true},
+		{raw: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, set, err := Config{ReleaseCacheTTL: tt.raw}.ReleaseCacheDuration()
+		if (err != nil) != tt.wantErr || got != tt.want || set != tt.wantSet {
+			t.Fatalf("ReleaseCacheDuration(%q) = %v, %v, %v; want %v, %v, error %v", tt.raw, got, set, err, tt.want, tt.wantSet, tt.wantErr)
+		}
+	}
+}
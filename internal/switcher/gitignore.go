@@ -0,0 +1,60 @@
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FindGitRoot returns the nearest ancestor of startDir (inclusive) that
+// contains a .git directory.
+func FindGitRoot(startDir string) (string, bool) {
+	current := filepath.Clean(startDir)
+	for {
+		info, err := os.Stat(filepath.Join(current, ".git"))
+		if err == nil && info.IsDir() {
+			return current, true
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", false
+		}
+		current = parent
+	}
+}
+
+// IgnoreLocalVersionFile adds LocalVersionFile to the .gitignore at the git
+// root above startDir, creating the file if needed. It returns the .gitignore
+// path and whether the file was changed.
+func IgnoreLocalVersionFile(startDir string) (string, bool, error) {
+	root, ok := FindGitRoot(startDir)
+	if !ok {
+		return "", false, fmt.Errorf("no git repository found above %s", startDir)
+	}
+
+	gitignorePath := filepath.Join(root, ".gitignore")
+	content, err := os.ReadFile(gitignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", false, fmt.Errorf("read %s: %w", gitignorePath, err)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == LocalVersionFile || trimmed == "/"+LocalVersionFile || trimmed == "**/"+LocalVersionFile {
+			return gitignorePath, false, nil
+		}
+	}
+
+	updated := string(content)
+	if updated != "" && !strings.HasSuffix(updated, "\n") {
+		updated += "\n"
+	}
+	updated += LocalVersionFile + "\n"
+
+	if err := writeFileAtomically(gitignorePath, []byte(updated), 0o644); err != nil {
+		return "", false, fmt.Errorf("write %s: %w", gitignorePath, err)
+	}
+	return gitignorePath, true, nil
+}
@@ -0,0 +1,56 @@
+package switcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreLocalVersionFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir .git: %v", err)
+	}
+	nested := filepath.Join(root, "cmd", "app")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("mkdir nested: %v", err)
+	}
+	gitignorePath := filepath.Join(root, ".gitignore")
+	if err := os.WriteFile(gitignorePath, []byte("bin/"), 0o644); err != nil {
+		t.Fatalf("write .gitignore: %v", err)
+	}
+
+	path, changed, err := IgnoreLocalVersionFile(nested)
+	if err != nil {
+		t.Fatalf("IgnoreLocalVersionFile: %v", err)
+	}
+	if path != gitignorePath || !changed {
+		t.Fatalf("expected %s to be changed, got %s (changed=%v)", gitignorePath, path, changed)
+	}
+
+	_, changed, err = IgnoreLocalVersionFile(nested)
+	if err != nil {
+		t.Fatalf("IgnoreLocalVersionFile second call: %v", err)
+	}
+	if changed {
+		t.Fatalf("expected second call to leave .gitignore untouched")
+	}
+
+	content, err := os.ReadFile(gitignorePath)
+	if err != nil {
+		t.Fatalf("read .gitignore: %v", err)
+	}
+	if string(content) != "bin/\n.switcher-version\n" {
+		t.Fatalf("unexpected .gitignore content %q", string(content))
+	}
+}
+
+func TestIgnoreLocalVersionFile_NoGitRoot(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := IgnoreLocalVersionFile(t.TempDir()); err == nil {
+		t.Fatalf("expected error outside a git repository")
+	}
+}
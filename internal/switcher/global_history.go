@@ -0,0 +1,79 @@
+package switcher
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mrtuuro/go-switcher/internal/versionutil"
+)
+
+// DefaultGlobalHistoryLimit is used when global_history_limit is unset.
+const DefaultGlobalHistoryLimit = 5
+
+// pushGlobalHistory records previous as the most recent entry when the global
+// version changes to next. next is dropped from the history so that `use -`
+// twice toggles between two versions.
+func pushGlobalHistory(cfg *Config, previous string, next string) {
+	previous = strings.TrimSpace(previous)
+	if previous == "" || previous == next {
+		return
+	}
+
+	history := []string{previous}
+	for _, version := range cfg.GlobalHistory {
+		if version != previous && version != next {
+			history = append(history, version)
+		}
+	}
+
+	limit := cfg.GlobalHistoryLimit
+	if limit <= 0 {
+		limit = DefaultGlobalHistoryLimit
+	}
+	if len(history) > limit {
+		history = history[:limit]
+	}
+	cfg.GlobalHistory = history
+}
+
+// PreviousGlobalVersion returns the global version active steps switches ago.
+func PreviousGlobalVersion(paths Paths, steps int) (string, error) {
+	cfg, err := ReadConfig(paths)
+	if err != nil {
+		return "", err
+	}
+	if steps < 1 {
+		return "", fmt.Errorf("invalid history step %d", steps)
+	}
+	if steps > len(cfg.GlobalHistory) {
+		return "", fmt.Errorf("global history holds %d previous version(s); cannot go back %d", len(cfg.GlobalHistory), steps)
+	}
+	return cfg.GlobalHistory[steps-1], nil
+}
+
+// RemoveFromGlobalHistory drops history entries that no longer resolve once
+// version is deleted: the version itself, and minor aliases without another
+// installed patch.
+func RemoveFromGlobalHistory(paths Paths, version string) error {
+	return UpdateConfig(paths, func(cfg *Config) bool {
+		history := slices.DeleteFunc(slices.Clone(cfg.GlobalHistory), func(entry string) bool {
+			if entry == version {
+				return true
+			}
+			if _, _, ok := versionutil.ParseMinorAlias(entry); !ok {
+				return false
+			}
+			_, err := ResolveVersionSpec(paths, entry)
+			return err != nil
+		})
+		if len(history) == len(cfg.GlobalHistory) {
+			return false
+		}
+		if len(history) == 0 {
+			history = nil
+		}
+		cfg.GlobalHistory = history
+		return true
+	})
+}
@@ -0,0 +1,57 @@
+package switcher
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSetGlobalVersion_TracksBoundedHistory(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	paths := Paths{
+		BaseDir:       filepath.Join(tmp, ".switcher"),
+		ToolchainsDir: filepath.Join(tmp, ".switcher", "toolchains"),
+		ToolsDir:      filepath.Join(tmp, ".switcher", "tools"),
+		BinDir:        filepath.Join(tmp, ".switcher", "bin"),
+		CacheDir:      filepath.Join(tmp, ".switcher", "cache"),
+		ConfigFile:    filepath.Join(tmp, ".switcher", "config.json"),
+	}
+	if err := WriteConfig(paths, Config{GlobalHistoryLimit: 3}); err != nil {
+		t.Fatalf("WriteConfig: %v", err)
+	}
+
+	for _, version := range []string{"go1.21.0", "go1.22.0", "go1.23.0", "go1.24.0", "go1.22.0", "go1.22.0"} {
+		if err := SetGlobalVersion(paths, version); err != nil {
+			t.Fatalf("SetGlobalVersion(%s): %v", version, err)
+		}
+	}
+
+	cfg, err := ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	if want := []string{"go1.24.0", "go1.23.0", "go1.21.0"}; !slices.Equal(cfg.GlobalHistory, want) {
+		t.Fatalf("expected history %v, got %v", want, cfg.GlobalHistory)
+	}
+
+	previous, err := PreviousGlobalVersion(paths, 2)
+	if err != nil || previous != "go1.23.0" {
+		t.Fatalf("expected go1.23.0 two steps back, got %q (%v)", previous, err)
+	}
+	if _, err := PreviousGlobalVersion(paths, 4); err == nil {
+		t.Fatalf("expected error beyond the recorded history")
+	}
+
+	if err := RemoveFromGlobalHistory(paths, "go1.23.0"); err != nil {
+		t.Fatalf("RemoveFromGlobalHistory: %v", err)
+	}
+	cfg, err = ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	if want := []string{"go1.24.0", "go1.21.0"}; !slices.Equal(cfg.GlobalHistory, want) {
+		t.Fatalf("expected history %v after delete, got %v", want, cfg.GlobalHistory)
+	}
+}
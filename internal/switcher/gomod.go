@@ -0,0 +1,104 @@
+package switcher
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mrtuuro/go-switcher/internal/versionutil"
+)
+
+// FindGoModVersion returns the go directive of the nearest go.mod at or
+// above startDir, normalized to a full Go version, and the go.mod path.
+// found is false when there is no go.mod or its directive cannot be parsed.
+func FindGoModVersion(startDir string) (version string, path string, found bool) {
+	candidate, ok := findUpward(startDir, "go.mod")
+	if !ok {
+		return "", "", false
+	}
+	normalized, ok := goDirectiveVersion(candidate)
+	return normalized, candidate, ok
+}
+
+// goDirectiveVersion returns the go directive of the go.mod at path,
+// normalized to a full Go version.
+func goDirectiveVersion(path string) (string, bool) {
+	goVersion, _ := readDirectives(path)
+	normalized, err := versionutil.NormalizeGoVersion(goVersion)
+	return normalized, err == nil
+}
+
+// FindProjectVersion returns the version the project around startDir asks
+// for: a .switcher-version pin first, then the nearest go.work and finally
+// the nearest go.mod. For go.work and go.mod a toolchain directive wins over
+// the go directive, matching what the go command itself would run.
+func FindProjectVersion(startDir string) (version string, path string, found bool, err error) {
+	version, path, found, err = FindLocalVersion(startDir)
+	if err != nil || found {
+		return version, path, found, err
+	}
+
+	for _, name := range []string{"go.work", "go.mod"} {
+		candidate, ok := findUpward(startDir, name)
+		if !ok {
+			continue
+		}
+		goVersion, toolchain := readDirectives(candidate)
+		if normalized, err := versionutil.NormalizeGoVersion(toolchain); err == nil {
+			return normalized, candidate, true, nil
+		}
+		if normalized, err := versionutil.NormalizeGoVersion(goVersion); err == nil {
+			return normalized, candidate, true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+func findUpward(startDir string, name string) (string, bool) {
+	current := filepath.Clean(startDir)
+	for {
+		candidate := filepath.Join(current, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", false
+		}
+		current = parent
+	}
+}
+
+// readDirectives returns the go and toolchain directives of a go.mod or
+// go.work file. Missing directives are returned empty.
+func readDirectives(path string) (goVersion string, toolchain string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "go":
+			if goVersion == "" {
+				goVersion = fields[1]
+			}
+		case "toolchain":
+			if toolchain == "" {
+				toolchain = fields[1]
+			}
+		}
+	}
+	return goVersion, toolchain
+}
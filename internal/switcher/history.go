@@ -0,0 +1,85 @@
+package switcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const historyFileName = "history.jsonl"
+
+const (
+	HistoryOutcomeOK     = "ok"
+	HistoryOutcomeFailed = "failed"
+)
+
+// HistoryEntry is one line of the append-only `use` history log.
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`
+	Version string    `json:"version"`
+	Scope   Scope     `json:"scope"`
+	Cwd     string    `json:"cwd"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func HistoryFile(paths Paths) string {
+	return filepath.Join(paths.BaseDir, historyFileName)
+}
+
+// AppendHistory adds entry as a single JSON line to the history log.
+func AppendHistory(paths Paths, entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode history entry: %w", err)
+	}
+
+	historyFile := HistoryFile(paths)
+	if err := os.MkdirAll(filepath.Dir(historyFile), 0o755); err != nil {
+		return fmt.Errorf("create history directory: %w", err)
+	}
+	file, err := os.OpenFile(historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open history log: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write history log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close history log: %w", err)
+	}
+	return nil
+}
+
+// ReadHistory returns all history entries, oldest first. Lines that cannot
+// be decoded, e.g. from an interrupted write, are skipped.
+func ReadHistory(paths Paths) ([]HistoryEntry, error) {
+	file, err := os.Open(HistoryFile(paths))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open history log: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history log: %w", err)
+	}
+	return entries, nil
+}
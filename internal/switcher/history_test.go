@@ -0,0 +1,40 @@
+package switcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadHistory_SkipsMalformedLines(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	paths := Paths{BaseDir: filepath.Join(tmp, ".switcher")}
+
+	first := HistoryEntry{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Version: "go1.24.2", Scope: ScopeGlobal, Cwd: tmp, Outcome: HistoryOutcomeOK}
+	if err := AppendHistory(paths, first); err != nil {
+		t.Fatalf("AppendHistory: %v", err)
+	}
+	file, err := os.OpenFile(HistoryFile(paths), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := file.WriteString("{\"time\":\n"); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+	_ = file.Close()
+	second := HistoryEntry{Time: first.Time.Add(time.Hour), Version: "go1.25.0", Scope: ScopeLocal, Cwd: tmp, Outcome: HistoryOutcomeFailed, Error: "boom"}
+	if err := AppendHistory(paths, second); err != nil {
+		t.Fatalf("AppendHistory: %v", err)
+	}
+
+	entries, err := ReadHistory(paths)
+	if err != nil {
+		t.Fatalf("ReadHistory: %v", err)
+	}
+	if len(entries) != 2 || entries[0] != first || entries[1] != second {
+		t.Fatalf("unexpected history %+v", entries)
+	}
+}
@@ -0,0 +1,108 @@
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mrtuuro/go-switcher/internal/versionutil"
+)
+
+// LinkedToolchain is an external GOROOT, such as a Go built from source,
+// registered under a name instead of a version.
+type LinkedToolchain struct {
+	Name string
+	Path string
+}
+
+// LinkToolchain registers goroot as the linked toolchain name. It is stored
+// as a symlink in the toolchains directory, so ToolchainDir and the shims
+// resolve name like an installed version. An existing link with the same
+// name is replaced; an installed toolchain is never touched.
+func LinkToolchain(paths Paths, name string, goroot string) (LinkedToolchain, error) {
+	validName, ok := versionutil.ParseLinkName(name)
+	if !ok {
+		return LinkedToolchain{}, fmt.Errorf("invalid link name %q (use letters, digits, '.', '-' or '_', starting with a letter and not a Go version)", name)
+	}
+	target, err := filepath.Abs(goroot)
+	if err != nil {
+		return LinkedToolchain{}, fmt.Errorf("resolve %s: %w", goroot, err)
+	}
+	info, err := os.Stat(GoBinaryPath(target))
+	if err != nil || info.IsDir() {
+		return LinkedToolchain{}, fmt.Errorf("%s is not a Go installation: bin/go not found", target)
+	}
+	if err := EnsureLayout(paths); err != nil {
+		return LinkedToolchain{}, err
+	}
+
+	linkPath := ToolchainDir(paths, validName)
+	if existing, err := os.Lstat(linkPath); err == nil {
+		if existing.Mode()&os.ModeSymlink == 0 {
+			return LinkedToolchain{}, fmt.Errorf("%s already exists and is not a linked toolchain", linkPath)
+		}
+		if err := os.Remove(linkPath); err != nil {
+			return LinkedToolchain{}, fmt.Errorf("replace link %s: %w", linkPath, err)
+		}
+	}
+	if err := os.Symlink(target, linkPath); err != nil {
+		return LinkedToolchain{}, fmt.Errorf("link %s: %w", validName, err)
+	}
+	return LinkedToolchain{Name: validName, Path: target}, nil
+}
+
+// UnlinkToolchain removes the link for name. The linked GOROOT itself is
+// never modified.
+func UnlinkToolchain(paths Paths, name string) error {
+	linkPath := ToolchainDir(paths, name)
+	info, err := os.Lstat(linkPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NotInstalled(name)
+		}
+		return fmt.Errorf("stat link %s: %w", linkPath, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s is not a linked toolchain", linkPath)
+	}
+	if err := os.Remove(linkPath); err != nil {
+		return fmt.Errorf("unlink %s: %w", name, err)
+	}
+	return nil
+}
+
+// LinkedToolchainPath returns the GOROOT name is linked to.
+func LinkedToolchainPath(paths Paths, name string) (string, bool) {
+	if _, ok := versionutil.ParseLinkName(name); !ok {
+		return "", false
+	}
+	target, err := os.Readlink(ToolchainDir(paths, name))
+	if err != nil {
+		return "", false
+	}
+	return target, true
+}
+
+// ListLinkedToolchains returns the registered links sorted by name.
+func ListLinkedToolchains(paths Paths) ([]LinkedToolchain, error) {
+	entries, err := os.ReadDir(paths.ToolchainsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read toolchains dir %s: %w", paths.ToolchainsDir, err)
+	}
+
+	var links []LinkedToolchain
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		if target, ok := LinkedToolchainPath(paths, entry.Name()); ok {
+			links = append(links, LinkedToolchain{Name: entry.Name(), Path: target})
+		}
+	}
+	sort.Slice(links, func(i int, j int) bool { return links[i].Name < links[j].Name })
+	return links, nil
+}
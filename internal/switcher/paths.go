@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 )
 
 type Paths struct {
@@ -15,13 +17,86 @@ type Paths struct {
 	ConfigFile    string
 }
 
-func DefaultPaths() (Paths, error) {
-	home, err := os.UserHomeDir()
+// HomeEnv replaces ~/.switcher as the base directory, e.g. to keep a
+// separate install per CI job on a shared machine or when the user home
+// directory cannot be resolved. HomeEnvAlias is honored when HomeEnv is
+// unset.
+const (
+	HomeEnv      = "SWITCHER_HOME"
+	HomeEnvAlias = "GOSWITCHER_HOME"
+)
+
+// DefaultPaths returns the layout under the base directory. note is set when
+// it had to fall back to the system temp dir, for the caller to print.
+func DefaultPaths() (paths Paths, note string, err error) {
+	base, note, err := resolveBaseDir(os.UserHomeDir, os.Getenv, os.TempDir)
 	if err != nil {
-		return Paths{}, fmt.Errorf("resolve user home: %w", err)
+		return Paths{}, "", err
+	}
+	return PathsForBase(base), note, nil
+}
+
+// resolveBaseDir picks HomeEnv, then HomeEnvAlias, then ~/.switcher, then a
+// per-user directory under the system temp dir. note explains the temp dir
+// fallback.
+func resolveBaseDir(userHomeDir func() (string, error), getenv func(string) string, tempDir func() string) (base string, note string, err error) {
+	for _, name := range []string{HomeEnv, HomeEnvAlias} {
+		override := strings.TrimSpace(getenv(name))
+		if override == "" {
+			continue
+		}
+		abs, err := filepath.Abs(override)
+		if err != nil {
+			return "", "", fmt.Errorf("resolve %s: %w", name, err)
+		}
+		if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+			return "", "", fmt.Errorf("%s=%s is not a directory", name, abs)
+		}
+		return abs, "", nil
+	}
+
+	home, homeErr := userHomeDir()
+	if homeErr == nil && home != "" {
+		return filepath.Join(home, ".switcher"), "", nil
+	}
+	if homeErr == nil {
+		homeErr = fmt.Errorf("home directory is empty")
 	}
 
-	base := filepath.Join(home, ".switcher")
+	base = filepath.Join(tempDir(), fmt.Sprintf("switcher-%d", os.Getuid()))
+	if err := ensurePrivateDir(base); err != nil {
+		return "", "", fmt.Errorf("cannot resolve user home (%v): %w", homeErr, err)
+	}
+	return base, fmt.Sprintf("cannot resolve user home (%v) and %s is not set; storing state in %s, which may not survive a reboot", homeErr, HomeEnv, base), nil
+}
+
+// ensurePrivateDir creates dir with mode 0700, or checks that an existing
+// dir is one only the current user can access. The temp dir fallback has a
+// predictable name in a shared directory, so another user could otherwise
+// create it first and plant shims or toolchains in it.
+func ensurePrivateDir(dir string) error {
+	if err := os.Mkdir(dir, 0o700); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("create %s: %w", dir, err)
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("refusing to use %s: it is not a directory; set %s instead", dir, HomeEnv)
+	}
+	// Windows keeps a temp dir per user and has no permission bits.
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	if info.Mode().Perm() != 0o700 || !ownedByCurrentUser(info) {
+		return fmt.Errorf("refusing to use %s: it must be owned by the current user with mode 0700; set %s instead", dir, HomeEnv)
+	}
+	return nil
+}
+
+// PathsForBase lays out the managed directories under base.
+func PathsForBase(base string) Paths {
 	return Paths{
 		BaseDir:       base,
 		ToolchainsDir: filepath.Join(base, "toolchains"),
@@ -29,10 +104,65 @@ func DefaultPaths() (Paths, error) {
 		BinDir:        filepath.Join(base, "bin"),
 		CacheDir:      filepath.Join(base, "cache"),
 		ConfigFile:    filepath.Join(base, "config.json"),
-	}, nil
+	}
+}
+
+// Validate checks that every path is set and absolute and that managed
+// directories live under BaseDir.
+func (p Paths) Validate() error {
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{name: "BaseDir", value: p.BaseDir},
+		{name: "ToolchainsDir", value: p.ToolchainsDir},
+		{name: "ToolsDir", value: p.ToolsDir},
+		{name: "BinDir", value: p.BinDir},
+		{name: "CacheDir", value: p.CacheDir},
+		{name: "ConfigFile", value: p.ConfigFile},
+	}
+
+	for _, field := range fields {
+		if strings.TrimSpace(field.value) == "" {
+			return fmt.Errorf("invalid paths: %s is empty", field.name)
+		}
+		if !filepath.IsAbs(field.value) {
+			return fmt.Errorf("invalid paths: %s %q is not absolute", field.name, field.value)
+		}
+	}
+
+	for _, field := range fields[1:] {
+		if !isWithinDir(p.BaseDir, field.value) {
+			return fmt.Errorf("invalid paths: %s %q is not under BaseDir %q", field.name, field.value, p.BaseDir)
+		}
+	}
+
+	return nil
+}
+
+func isWithinDir(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+type LayoutOptions struct {
+	// ProbeWritable creates and removes a probe file in every managed
+	// directory so read-only or exhausted filesystems fail early.
+	ProbeWritable bool
 }
 
 func EnsureLayout(paths Paths) error {
+	return EnsureLayoutWithOptions(paths, LayoutOptions{})
+}
+
+func EnsureLayoutWithOptions(paths Paths, opts LayoutOptions) error {
+	if err := paths.Validate(); err != nil {
+		return err
+	}
+
 	dirs := []string{
 		paths.BaseDir,
 		paths.ToolchainsDir,
@@ -45,6 +175,29 @@ func EnsureLayout(paths Paths) error {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			return fmt.Errorf("create %s: %w", dir, err)
 		}
+		if opts.ProbeWritable {
+			if err := probeWritable(dir); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func probeWritable(dir string) error {
+	probe, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %w", dir, err)
+	}
+
+	probePath := probe.Name()
+	closeErr := probe.Close()
+	if err := os.Remove(probePath); err != nil {
+		return fmt.Errorf("remove probe file in %s: %w", dir, err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("directory %s is not writable: %w", dir, closeErr)
 	}
 
 	return nil
@@ -54,17 +207,79 @@ func ToolchainDir(paths Paths, goVersion string) string {
 	return filepath.Join(paths.ToolchainsDir, goVersion)
 }
 
+// CrossToolchainDir is where toolchains for a non-host platform are kept.
+// They are never listed as installed or used by shims.
+func CrossToolchainDir(paths Paths, goVersion string, goos string, goarch string) string {
+	return filepath.Join(paths.ToolchainsDir, "cross", goos+"-"+goarch, goVersion)
+}
+
 func ToolchainExists(paths Paths, goVersion string) bool {
-	_, err := os.Stat(filepath.Join(ToolchainDir(paths, goVersion), "bin", "go"))
+	_, err := os.Stat(GoBinaryPath(ToolchainDir(paths, goVersion)))
 	return err == nil
 }
 
+// GoBinaryPath returns the go binary of the toolchain rooted at goroot.
+func GoBinaryPath(goroot string) string {
+	return filepath.Join(goroot, "bin", ExecutableName("go"))
+}
+
+// ExecutableName adds the .exe suffix executables need on Windows.
+func ExecutableName(name string) string {
+	return executableName(name, runtime.GOOS)
+}
+
+func executableName(name string, goos string) string {
+	if goos == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// EnsureExecutable restores the executable bit on a managed binary that lost
+// it, e.g. after being copied across filesystems. Binaries that are, or link
+// to, files outside paths.BaseDir are never modified.
+func EnsureExecutable(paths Paths, binary string) error {
+	info, err := os.Stat(binary)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", binary, err)
+	}
+	// Windows has no executable bit to restore.
+	if runtime.GOOS == "windows" || info.Mode()&0o111 != 0 {
+		return nil
+	}
+
+	// Chmod follows symlinks, so check where the binary really lives.
+	target, err := filepath.EvalSymlinks(binary)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", binary, err)
+	}
+	baseDir, err := filepath.EvalSymlinks(paths.BaseDir)
+	if err != nil {
+		baseDir = paths.BaseDir
+	}
+	if !isWithinDir(baseDir, target) {
+		return fmt.Errorf("%s is not executable and is outside %s", binary, paths.BaseDir)
+	}
+	if err := os.Chmod(target, 0o755); err != nil {
+		return fmt.Errorf("restore executable bit on %s: %w", binary, err)
+	}
+
+	info, err = os.Stat(binary)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", binary, err)
+	}
+	if info.Mode()&0o111 == 0 {
+		return fmt.Errorf("%s is still not executable; check the filesystem mount options", binary)
+	}
+	return nil
+}
+
 func GoToolBinary(paths Paths, goVersion string, tool string) (string, error) {
 	if tool != "go" && tool != "gofmt" {
 		return "", fmt.Errorf("unsupported go tool %q", tool)
 	}
 
-	binary := filepath.Join(ToolchainDir(paths, goVersion), "bin", tool)
+	binary := filepath.Join(ToolchainDir(paths, goVersion), "bin", ExecutableName(tool))
 	if _, err := os.Stat(binary); err != nil {
 		return "", fmt.Errorf("%s binary for %s not found at %s", tool, goVersion, binary)
 	}
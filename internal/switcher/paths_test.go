@@ -0,0 +1,339 @@
+package switcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEnsureLayoutWithOptions_ProbeLeavesNoFiles(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	paths := Paths{
+		BaseDir:       filepath.Join(tmp, ".switcher"),
+		ToolchainsDir: filepath.Join(tmp, ".switcher", "toolchains"),
+		ToolsDir:      filepath.Join(tmp, ".switcher", "tools"),
+		BinDir:        filepath.Join(tmp, ".switcher", "bin"),
+		CacheDir:      filepath.Join(tmp, ".switcher", "cache"),
+		ConfigFile:    filepath.Join(tmp, ".switcher", "config.json"),
+	}
+
+	if err := EnsureLayoutWithOptions(paths, LayoutOptions{ProbeWritable: true}); err != nil {
+		t.Fatalf("EnsureLayoutWithOptions: %v", err)
+	}
+
+	for _, dir := range []string{paths.ToolchainsDir, paths.ToolsDir, paths.BinDir, paths.CacheDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("ReadDir %s: %v", dir, err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("expected probe to clean up in %s, found %d entries", dir, len(entries))
+		}
+	}
+}
+
+func TestEnsureLayoutWithOptions_ProbeReportsReadOnlyDir(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("no permission bits on windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+
+	paths := PathsForBase(filepath.Join(t.TempDir(), ".switcher"))
+	if err := EnsureLayout(paths); err != nil {
+		t.Fatalf("EnsureLayout: %v", err)
+	}
+	if err := os.Chmod(paths.CacheDir, 0o555); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(paths.CacheDir, 0o755) })
+
+	tests := []struct {
+		name    string
+		probe   bool
+		wantErr bool
+	}{
+		{name: "probe", probe: true, wantErr: true},
+		{name: "no probe", probe: false, wantErr: false},
+	}
+	for _, tt := range tests {
+		err := EnsureLayoutWithOptions(paths, LayoutOptions{ProbeWritable: tt.probe})
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: expected error %v, got %v", tt.name, tt.wantErr, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), paths.CacheDir+" is not writable") {
+			t.Fatalf("%s: expected error naming %s, got %v", tt.name, paths.CacheDir, err)
+		}
+	}
+}
+
+func TestPathsValidate(t *testing.T) {
+	t.Parallel()
+
+	base := filepath.Join(t.TempDir(), ".switcher")
+	valid := Paths{
+		BaseDir:       base,
+		ToolchainsDir: filepath.Join(base, "toolchains"),
+		ToolsDir:      filepath.Join(base, "tools"),
+		BinDir:        filepath.Join(base, "bin"),
+		CacheDir:      filepath.Join(base, "cache"),
+		ConfigFile:    filepath.Join(base, "config.json"),
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(p *Paths)
+		wantErr string
+	}{
+		{name: "valid", mutate: func(p *Paths) {}},
+		{name: "empty base", mutate: func(p *Paths) { p.BaseDir = "" }, wantErr: "BaseDir is empty"},
+		{name: "relative cache", mutate: func(p *Paths) { p.CacheDir = "cache" }, wantErr: "CacheDir \"cache\" is not absolute"},
+		{name: "toolchains outside base", mutate: func(p *Paths) { p.ToolchainsDir = filepath.Join(filepath.Dir(base), "toolchains") }, wantErr: "ToolchainsDir"},
+		{name: "bin equals base", mutate: func(p *Paths) { p.BinDir = base }, wantErr: "BinDir"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			paths := valid
+			tc.mutate(&paths)
+			err := paths.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResolveBaseDir_Fallbacks(t *testing.T) {
+	t.Parallel()
+
+	noHome := func() (string, error) { return "", errors.New("$HOME is not defined") }
+	env := func(values map[string]string) func(string) string {
+		return func(key string) string { return values[key] }
+	}
+
+	tmp := t.TempDir()
+	tempDir := func() string { return tmp }
+
+	base, note, err := resolveBaseDir(func() (string, error) { return "/home/gopher", nil }, env(nil), tempDir)
+	if err != nil || base != filepath.Join("/home/gopher", ".switcher") || note != "" {
+		t.Fatalf("expected home base without note, got %q %q (%v)", base, note, err)
+	}
+
+	base, note, err = resolveBaseDir(noHome, env(map[string]string{HomeEnv: "/srv/switcher"}), tempDir)
+	if err != nil || base != "/srv/switcher" || note != "" {
+		t.Fatalf("expected %s without a note, got %q %q (%v)", HomeEnv, base, note, err)
+	}
+
+	base, _, err = resolveBaseDir(func() (string, error) { return "/home/gopher", nil }, env(map[string]string{HomeEnv: "/srv/switcher"}), tempDir)
+	if err != nil || base != "/srv/switcher" {
+		t.Fatalf("expected %s to override the home directory, got %q (%v)", HomeEnv, base, err)
+	}
+
+	base, _, err = resolveBaseDir(func() (string, error) { return "/home/gopher", nil }, env(map[string]string{HomeEnvAlias: "/srv/goswitcher"}), tempDir)
+	if err != nil || base != "/srv/goswitcher" {
+		t.Fatalf("expected %s to override the home directory, got %q (%v)", HomeEnvAlias, base, err)
+	}
+
+	base, _, err = resolveBaseDir(noHome, env(map[string]string{HomeEnv: "/srv/switcher", HomeEnvAlias: "/srv/goswitcher"}), tempDir)
+	if err != nil || base != "/srv/switcher" {
+		t.Fatalf("expected %s to win over %s, got %q (%v)", HomeEnv, HomeEnvAlias, base, err)
+	}
+
+	base, note, err = resolveBaseDir(noHome, env(nil), tempDir)
+	if err != nil || !strings.HasPrefix(base, tmp) || !strings.Contains(note, base) {
+		t.Fatalf("expected temp fallback, got %q %q (%v)", base, note, err)
+	}
+	if err := PathsForBase(base).Validate(); err != nil {
+		t.Fatalf("expected valid fallback paths: %v", err)
+	}
+	if info, err := os.Stat(base); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0o700) {
+		t.Fatalf("expected the fallback to be created private, got %v (%v)", info, err)
+	}
+}
+
+func TestEnsurePrivateDir(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("no permission bits on windows")
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, dir string)
+		wantErr bool
+	}{
+		{name: "created", prepare: func(*testing.T, string) {}},
+		{name: "existing private", prepare: func(t *testing.T, dir string) {
+			if err := os.Mkdir(dir, 0o700); err != nil {
+				t.Fatalf("mkdir: %v", err)
+			}
+		}},
+		{name: "existing shared", wantErr: true, prepare: func(t *testing.T, dir string) {
+			if err := os.Mkdir(dir, 0o700); err != nil {
+				t.Fatalf("mkdir: %v", err)
+			}
+			if err := os.Chmod(dir, 0o777); err != nil {
+				t.Fatalf("chmod: %v", err)
+			}
+		}},
+		{name: "symlink", wantErr: true, prepare: func(t *testing.T, dir string) {
+			target := t.TempDir()
+			if err := os.Chmod(target, 0o700); err != nil {
+				t.Fatalf("chmod: %v", err)
+			}
+			if err := os.Symlink(target, dir); err != nil {
+				t.Fatalf("symlink: %v", err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := filepath.Join(t.TempDir(), "switcher-1000")
+			tt.prepare(t, dir)
+			err := ensurePrivateDir(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEnsureExecutable(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("no executable bit on windows")
+	}
+
+	tests := []struct {
+		name string
+		// prepare creates the binary to check and returns it along with the
+		// file whose mode should be checked afterwards.
+		prepare  func(t *testing.T, base string, outside string) (binary string, target string)
+		wantErr  bool
+		wantMode os.FileMode
+	}{
+		{name: "restores managed binary", wantMode: 0o755, prepare: func(t *testing.T, base string, _ string) (string, string) {
+			binary := writeTestFile(t, filepath.Join(base, "toolchains", "go1.22.1", "bin", "go"), 0o644)
+			return binary, binary
+		}},
+		{name: "already executable", wantMode: 0o700, prepare: func(t *testing.T, base string, _ string) (string, string) {
+			binary := writeTestFile(t, filepath.Join(base, "bin", "go"), 0o700)
+			return binary, binary
+		}},
+		{name: "outside home", wantErr: true, wantMode: 0o644, prepare: func(t *testing.T, _ string, outside string) (string, string) {
+			binary := writeTestFile(t, filepath.Join(outside, "go"), 0o644)
+			return binary, binary
+		}},
+		{name: "symlink out of home", wantErr: true, wantMode: 0o644, prepare: func(t *testing.T, base string, outside string) (string, string) {
+			target := writeTestFile(t, filepath.Join(outside, "go"), 0o644)
+			link := filepath.Join(base, "toolchains", "go1.22.1", "bin", "go")
+			if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+				t.Fatalf("mkdir: %v", err)
+			}
+			if err := os.Symlink(target, link); err != nil {
+				t.Fatalf("symlink: %v", err)
+			}
+			return link, target
+		}},
+		{name: "symlink within home", wantMode: 0o755, prepare: func(t *testing.T, base string, _ string) (string, string) {
+			target := writeTestFile(t, filepath.Join(base, "toolchains", "go1.22.1", "bin", "go"), 0o644)
+			link := filepath.Join(base, "bin", "go")
+			if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+				t.Fatalf("mkdir: %v", err)
+			}
+			if err := os.Symlink(target, link); err != nil {
+				t.Fatalf("symlink: %v", err)
+			}
+			return link, target
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			base := t.TempDir()
+			binary, target := tt.prepare(t, base, t.TempDir())
+
+			err := EnsureExecutable(PathsForBase(base), binary)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			info, err := os.Stat(target)
+			if err != nil {
+				t.Fatalf("stat: %v", err)
+			}
+			if got := info.Mode().Perm(); got != tt.wantMode {
+				t.Fatalf("expected mode %o, got %o", tt.wantMode, got)
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path string, mode os.FileMode) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatalf("chmod %s: %v", path, err)
+	}
+	return path
+}
+
+func TestDefaultPaths_HomeOverride(t *testing.T) {
+	t.Setenv(HomeEnvAlias, "")
+	base := filepath.Join(t.TempDir(), "switcher-home")
+	t.Setenv("SWITCHER_HOME", base)
+
+	paths, note, err := DefaultPaths()
+	if err != nil || note != "" {
+		t.Fatalf("DefaultPaths: %q (%v)", note, err)
+	}
+	if paths != PathsForBase(base) {
+		t.Fatalf("expected layout under %s, got %+v", base, paths)
+	}
+	if paths.ToolchainsDir != filepath.Join(base, "toolchains") || paths.ConfigFile != filepath.Join(base, "config.json") {
+		t.Fatalf("unexpected derived paths %+v", paths)
+	}
+
+	t.Setenv("SWITCHER_HOME", "")
+	alias := filepath.Join(t.TempDir(), "goswitcher-home")
+	t.Setenv(HomeEnvAlias, alias)
+	if paths, _, err := DefaultPaths(); err != nil || paths != PathsForBase(alias) {
+		t.Fatalf("expected layout under %s, got %+v (%v)", alias, paths, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	t.Setenv("SWITCHER_HOME", file)
+	if _, _, err := DefaultPaths(); err == nil || !strings.Contains(err.Error(), "SWITCHER_HOME="+file+" is not a directory") {
+		t.Fatalf("expected an error for a file, got %v", err)
+	}
+}
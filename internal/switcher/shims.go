@@ -5,10 +5,27 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 )
 
 var shimTools = []string{"go", "gofmt", "golangci-lint"}
 
+// binaryMarkerName is written next to the copied switcher binary. Shims read
+// it to tell a bootstrapped install apart from shims copied on their own.
+const (
+	binaryMarkerName  = ".switcher-binary"
+	binaryMarkerValue = "switcher-shim-v1"
+)
+
+// shimTemplateVersion must be bumped whenever shimScript changes. It is
+// stamped into the shims and next to them so EnsureShims rewrites shims left
+// behind by an older switcher release.
+const (
+	shimStampName       = ".switcher-shim-template"
+	shimTemplateVersion = "2"
+)
+
 func EnsureShims(paths Paths) error {
 	if err := EnsureLayout(paths); err != nil {
 		return err
@@ -18,31 +35,161 @@ func EnsureShims(paths Paths) error {
 		return err
 	}
 
+	_, err := writeShims(paths)
+	return err
+}
+
+// writeShims rewrites the shim scripts unless the stamp in paths.BinDir
+// matches shimTemplateVersion and every shim is already up to date. It
+// reports whether anything was written.
+func writeShims(paths Paths) (bool, error) {
+	if shimsCurrent(paths) {
+		return false, nil
+	}
+
 	for _, tool := range shimTools {
-		shimPath := filepath.Join(paths.BinDir, tool)
+		shimPath := ShimPath(paths, tool)
 		script := shimScript(tool)
 		if err := writeFileAtomically(shimPath, []byte(script), 0o755); err != nil {
-			return fmt.Errorf("write shim %s: %w", shimPath, err)
+			return false, fmt.Errorf("write shim %s: %w", shimPath, err)
 		}
 	}
 
-	return nil
+	stampPath := filepath.Join(paths.BinDir, shimStampName)
+	if err := writeFileAtomically(stampPath, []byte(shimTemplateVersion+"\n"), 0o644); err != nil {
+		return false, fmt.Errorf("write shim stamp %s: %w", stampPath, err)
+	}
+	return true, nil
+}
+
+func shimsCurrent(paths Paths) bool {
+	stamp, err := os.ReadFile(filepath.Join(paths.BinDir, shimStampName))
+	if err != nil || strings.TrimSpace(string(stamp)) != shimTemplateVersion {
+		return false
+	}
+	for _, tool := range shimTools {
+		content, err := os.ReadFile(ShimPath(paths, tool))
+		if err != nil || string(content) != shimScript(tool) {
+			return false
+		}
+	}
+	return true
+}
+
+// ShimPath is where the shim for tool lives. Windows runs .cmd files by
+// name, so `go` finds go.cmd there.
+func ShimPath(paths Paths, tool string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(paths.BinDir, tool+".cmd")
+	}
+	return filepath.Join(paths.BinDir, tool)
+}
+
+// switcherBinaryPath is the copy of switcher the shims exec.
+func switcherBinaryPath(paths Paths) string {
+	return filepath.Join(paths.BinDir, ExecutableName("switcher"))
 }
 
 func shimScript(tool string) string {
+	return shimScriptFor(tool, runtime.GOOS)
+}
+
+func shimScriptFor(tool string, goos string) string {
+	if goos == "windows" {
+		return cmdShimScript(tool)
+	}
 	return fmt.Sprintf(`#!/usr/bin/env sh
+# switcher shim template v%[4]s
 set -eu
 
-switcher_bin="$(dirname "$0")/switcher"
+shim_dir="$(dirname "$0")"
+switcher_bin="$shim_dir/switcher"
+marker="$shim_dir/%[2]s"
 
 if [ ! -x "$switcher_bin" ]; then
-  echo "switcher binary not found at $switcher_bin" >&2
-  echo "Run 'switcher use <version>' once to bootstrap shims." >&2
+  echo "switcher: the %[1]s shim in $shim_dir has no switcher binary next to it" >&2
+  echo "Shims cannot be copied on their own. Run 'switcher use <version>' with a real switcher binary to bootstrap $shim_dir." >&2
   exit 1
 fi
 
-exec "$switcher_bin" exec %s "$@"
-`, tool)
+marker_value=""
+if [ -f "$marker" ]; then
+  read -r marker_value < "$marker" || true
+fi
+if [ "$marker_value" != "%[3]s" ]; then
+  echo "switcher: $switcher_bin was not installed by this version of switcher" >&2
+  echo "Run 'switcher use <version>' to re-bootstrap, or '$switcher_bin exec --self-check' to diagnose." >&2
+  exit 1
+fi
+
+exec "$switcher_bin" exec %[1]s "$@"
+`, tool, binaryMarkerName, binaryMarkerValue, shimTemplateVersion)
+}
+
+// cmdShimScript is the Windows counterpart of the sh shim, with the same
+// checks. cmd.exe expects CRLF line endings.
+func cmdShimScript(tool string) string {
+	script := fmt.Sprintf(`@echo off
+rem switcher shim template v%[4]s
+setlocal
+set "switcher_bin=%%~dp0switcher.exe"
+set "marker=%%~dp0%[2]s"
+
+if not exist "%%switcher_bin%%" (
+  echo switcher: the %[1]s shim in %%~dp0 has no switcher binary next to it 1>&2
+  echo Shims cannot be copied on their own. Run 'switcher use ^<version^>' with a real switcher binary to bootstrap %%~dp0. 1>&2
+  exit /b 1
+)
+
+set "marker_value="
+if exist "%%marker%%" set /p marker_value=<"%%marker%%"
+if not "%%marker_value%%"=="%[3]s" (
+  echo switcher: %%switcher_bin%% was not installed by this version of switcher 1>&2
+  echo Run 'switcher use ^<version^>' to re-bootstrap, or '%%switcher_bin%% exec --self-check' to diagnose. 1>&2
+  exit /b 1
+)
+
+"%%switcher_bin%%" exec %[1]s %%*
+exit /b %%ERRORLEVEL%%
+`, tool, binaryMarkerName, binaryMarkerValue, shimTemplateVersion)
+	return strings.ReplaceAll(script, "\n", "\r\n")
+}
+
+// CheckShims reports problems with the shim install in paths.BinDir. It
+// returns nil when the switcher binary, its marker and every shim are in place.
+func CheckShims(paths Paths) []string {
+	var problems []string
+
+	binaryPath := switcherBinaryPath(paths)
+	info, err := os.Stat(binaryPath)
+	switch {
+	case err != nil:
+		problems = append(problems, fmt.Sprintf("switcher binary missing at %s", binaryPath))
+	case runtime.GOOS != "windows" && info.Mode()&0o111 == 0:
+		problems = append(problems, fmt.Sprintf("switcher binary at %s is not executable", binaryPath))
+	}
+
+	markerPath := filepath.Join(paths.BinDir, binaryMarkerName)
+	marker, err := os.ReadFile(markerPath)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("marker %s missing", markerPath))
+	} else if strings.TrimSpace(string(marker)) != binaryMarkerValue {
+		problems = append(problems, fmt.Sprintf("marker %s does not match this switcher version", markerPath))
+	}
+
+	for _, tool := range shimTools {
+		shimPath := ShimPath(paths, tool)
+		content, err := os.ReadFile(shimPath)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("shim %s missing", shimPath))
+			continue
+		}
+		if string(content) != shimScript(tool) {
+			problems = append(problems, fmt.Sprintf("shim %s is out of date", shimPath))
+		}
+	}
+
+	return problems
 }
 
 func ensureSwitcherBinary(paths Paths) error {
@@ -56,12 +203,18 @@ func ensureSwitcherBinary(paths Paths) error {
 		resolvedPath = evaluatedPath
 	}
 
-	targetPath := filepath.Join(paths.BinDir, "switcher")
-	if sameFile(targetPath, resolvedPath) {
-		return nil
+	targetPath := switcherBinaryPath(paths)
+	if !sameFile(targetPath, resolvedPath) {
+		if err := copyExecutable(resolvedPath, targetPath); err != nil {
+			return err
+		}
 	}
 
-	return copyExecutable(resolvedPath, targetPath)
+	markerPath := filepath.Join(paths.BinDir, binaryMarkerName)
+	if err := writeFileAtomically(markerPath, []byte(binaryMarkerValue+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write switcher marker %s: %w", markerPath, err)
+	}
+	return nil
 }
 
 func sameFile(a string, b string) bool {
@@ -130,17 +283,60 @@ func ShimTools() []string {
 	return copySlice
 }
 
+// EnsurePathHint reports whether paths.BinDir is on PATH. When it is not,
+// the returned hint is the command that adds it for this platform and shell.
 func EnsurePathHint(paths Paths) (string, bool, error) {
-	currentPath := os.Getenv("PATH")
-	if currentPath == "" {
-		return paths.BinDir, false, nil
-	}
+	hint, inPath := pathHint(paths.BinDir, runtime.GOOS, os.Getenv)
+	return hint, inPath, nil
+}
 
-	for _, segment := range filepath.SplitList(currentPath) {
-		if segment == paths.BinDir {
-			return paths.BinDir, true, nil
+func pathHint(binDir string, goos string, getenv func(string) string) (string, bool) {
+	for _, segment := range strings.Split(getenv("PATH"), pathListSeparator(goos)) {
+		if samePathEntry(segment, binDir, goos) {
+			return binDir, true
 		}
 	}
 
-	return paths.BinDir, false, nil
+	if goos == "windows" {
+		// setx only affects new consoles and stores the user PATH, so the
+		// expanded %PATH% keeps the entries already visible here.
+		return fmt.Sprintf(`setx PATH "%s;%%PATH%%"`, binDir), false
+	}
+	switch filepath.Base(getenv("SHELL")) {
+	case "fish":
+		return fmt.Sprintf("fish_add_path %s", shellQuote(binDir)), false
+	case "csh", "tcsh":
+		return fmt.Sprintf("setenv PATH %s:$PATH", shellQuote(binDir)), false
+	default:
+		return fmt.Sprintf(`export PATH="%s:$PATH"`, binDir), false
+	}
+}
+
+func pathListSeparator(goos string) string {
+	if goos == "windows" {
+		return ";"
+	}
+	return ":"
+}
+
+// samePathEntry compares a PATH entry with dir, ignoring trailing separators
+// and, on Windows, case.
+func samePathEntry(entry string, dir string, goos string) bool {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return false
+	}
+	if goos == "windows" {
+		entry = strings.TrimRight(strings.Trim(entry, `"`), `\/`)
+		dir = strings.TrimRight(dir, `\/`)
+		return strings.EqualFold(entry, dir)
+	}
+	return filepath.Clean(entry) == filepath.Clean(dir)
+}
+
+func shellQuote(value string) string {
+	if !strings.ContainsAny(value, " \t'\"$`\\") {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
 }
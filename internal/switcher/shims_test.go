@@ -0,0 +1,133 @@
+package switcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckShims(t *testing.T) {
+	t.Parallel()
+
+	binDir := filepath.Join(t.TempDir(), "bin")
+	paths := Paths{BinDir: binDir}
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	for _, tool := range shimTools {
+		if err := os.WriteFile(filepath.Join(binDir, tool), []byte(shimScript(tool)), 0o755); err != nil {
+			t.Fatalf("write shim: %v", err)
+		}
+	}
+
+	problems := CheckShims(paths)
+	if len(problems) != 2 {
+		t.Fatalf("expected missing binary and marker, got %v", problems)
+	}
+	if !strings.Contains(problems[0], "switcher binary missing") || !strings.Contains(problems[1], "marker") {
+		t.Fatalf("unexpected problems %v", problems)
+	}
+
+	if err := os.WriteFile(filepath.Join(binDir, "switcher"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatalf("write binary: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, binaryMarkerName), []byte(binaryMarkerValue+"\n"), 0o644); err != nil {
+		t.Fatalf("write marker: %v", err)
+	}
+
+	if problems := CheckShims(paths); len(problems) != 0 {
+		t.Fatalf("expected healthy install, got %v", problems)
+	}
+}
+
+func TestWriteShims_RewritesOnTemplateChange(t *testing.T) {
+	t.Parallel()
+
+	binDir := filepath.Join(t.TempDir(), "bin")
+	paths := Paths{BinDir: binDir}
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	if wrote, err := writeShims(paths); err != nil || !wrote {
+		t.Fatalf("expected initial write, got wrote=%v err=%v", wrote, err)
+	}
+	if wrote, err := writeShims(paths); err != nil || wrote {
+		t.Fatalf("expected up-to-date shims to be left alone, got wrote=%v err=%v", wrote, err)
+	}
+
+	// Simulate shims written by an older release with a different template.
+	if err := os.WriteFile(filepath.Join(binDir, shimStampName), []byte("1\n"), 0o644); err != nil {
+		t.Fatalf("write stamp: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "go"), []byte("#!/bin/sh\nexec old\n"), 0o755); err != nil {
+		t.Fatalf("write shim: %v", err)
+	}
+	if wrote, err := writeShims(paths); err != nil || !wrote {
+		t.Fatalf("expected stale shims to be rewritten, got wrote=%v err=%v", wrote, err)
+	}
+	content, err := os.ReadFile(filepath.Join(binDir, "go"))
+	if err != nil {
+		t.Fatalf("read shim: %v", err)
+	}
+	if string(content) != shimScript("go") {
+		t.Fatalf("expected current template, got %q", content)
+	}
+	stamp, err := os.ReadFile(filepath.Join(binDir, shimStampName))
+	if err != nil || strings.TrimSpace(string(stamp)) != shimTemplateVersion {
+		t.Fatalf("expected stamp %s, got %q (%v)", shimTemplateVersion, stamp, err)
+	}
+}
+
+func TestShimScriptFor_Windows(t *testing.T) {
+	t.Parallel()
+
+	script := shimScriptFor("gofmt", "windows")
+	for _, want := range []string{"@echo off\r\n", `set "switcher_bin=%~dp0switcher.exe"`, `"%switcher_bin%" exec gofmt %*` + "\r\n", `=="` + binaryMarkerValue + `"`} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("expected %q in cmd shim:\n%s", want, script)
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(script, "\r\n", ""), "\n") {
+		t.Fatalf("expected CRLF line endings only")
+	}
+	if got := executableName("go", "windows"); got != "go.exe" {
+		t.Fatalf("expected go.exe, got %s", got)
+	}
+	if got := executableName("go", "linux"); got != "go" {
+		t.Fatalf("expected go, got %s", got)
+	}
+}
+
+func TestPathHint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		binDir     string
+		goos       string
+		env        map[string]string
+		wantInPath bool
+		wantHint   string
+	}{
+		{name: "posix on path", binDir: "/home/u/.switcher/bin", goos: "linux", env: map[string]string{"PATH": "/usr/bin:/home/u/.switcher/bin/"}, wantInPath: true, wantHint: "/home/u/.switcher/bin"},
+		{name: "bash", binDir: "/home/u/.switcher/bin", goos: "linux", env: map[string]string{"PATH": "/usr/bin", "SHELL": "/bin/bash"}, wantHint: `export PATH="/home/u/.switcher/bin:$PATH"`},
+		{name: "no shell", binDir: "/home/u/.switcher/bin", goos: "darwin", env: map[string]string{}, wantHint: `export PATH="/home/u/.switcher/bin:$PATH"`},
+		{name: "fish", binDir: "/home/u/my bin", goos: "linux", env: map[string]string{"SHELL": "/usr/bin/fish"}, wantHint: "fish_add_path '/home/u/my bin'"},
+		{name: "tcsh", binDir: "/home/u/.switcher/bin", goos: "freebsd", env: map[string]string{"SHELL": "/bin/tcsh"}, wantHint: "setenv PATH /home/u/.switcher/bin:$PATH"},
+		{name: "windows on path ignores case", binDir: `C:\Users\u\.switcher\bin`, goos: "windows", env: map[string]string{"PATH": `C:\Windows;c:\users\u\.switcher\bin\`}, wantInPath: true, wantHint: `C:\Users\u\.switcher\bin`},
+		{name: "windows setx", binDir: `C:\Users\u\.switcher\bin`, goos: "windows", env: map[string]string{"PATH": `C:\Windows`, "SHELL": "/bin/bash"}, wantHint: `setx PATH "C:\Users\u\.switcher\bin;%PATH%"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			hint, inPath := pathHint(tc.binDir, tc.goos, func(key string) string { return tc.env[key] })
+			if inPath != tc.wantInPath || hint != tc.wantHint {
+				t.Fatalf("expected (%q, %v), got (%q, %v)", tc.wantHint, tc.wantInPath, hint, inPath)
+			}
+		})
+	}
+}
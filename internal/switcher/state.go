@@ -1,11 +1,11 @@
 package switcher
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 
 	"github.com/mrtuuro/go-switcher/internal/versionutil"
@@ -15,6 +15,26 @@ const LocalVersionFile = ".switcher-version"
 
 var ErrNoActiveVersion = errors.New("no active go version configured")
 
+// ErrNotInstalled matches errors about a toolchain that is not installed.
+var ErrNotInstalled = errors.New("toolchain is not installed")
+
+type notInstalledError struct {
+	message string
+}
+
+func (e *notInstalledError) Error() string {
+	return e.message
+}
+
+func (e *notInstalledError) Is(target error) bool {
+	return target == ErrNotInstalled
+}
+
+// NotInstalled returns an error for version that matches ErrNotInstalled.
+func NotInstalled(version string) error {
+	return &notInstalledError{message: fmt.Sprintf("toolchain %s is not installed", version)}
+}
+
 type Scope string
 
 const (
@@ -34,16 +54,67 @@ func ParseScope(raw string) (Scope, error) {
 	}
 }
 
+// SourceKind identifies what Source in an ActiveVersion points at.
+type SourceKind string
+
+const (
+	SourceLocalFile    SourceKind = "local-file"
+	SourceGlobalConfig SourceKind = "global-config"
+	SourceGoMod        SourceKind = "go-mod"
+)
+
+// Describe renders the source for display, e.g. in `switcher current`.
+func (k SourceKind) Describe(path string) string {
+	switch k {
+	case SourceLocalFile:
+		return fmt.Sprintf("%s file at %s", LocalVersionFile, path)
+	case SourceGlobalConfig:
+		return fmt.Sprintf("global config at %s", path)
+	case SourceGoMod:
+		return fmt.Sprintf("go directive from go.mod at %s", path)
+	default:
+		return path
+	}
+}
+
 type ActiveVersion struct {
 	Version string
 	Scope   Scope
-	Source  string
+	// Source is the path the version was read from; SourceKind says what
+	// kind of file it is.
+	Source     string
+	SourceKind SourceKind
+	// Alias is the minor alias, such as go1.24.x, that Version was resolved
+	// from. It is empty for exact pins.
+	Alias string
+	// Linked is the external GOROOT when Version names a linked toolchain.
+	Linked string
 }
 
 func FindLocalVersion(start string) (version string, path string, found bool, err error) {
+	files, err := findProjectFiles(start, false)
+	if err != nil || files.pinPath == "" {
+		return "", "", false, err
+	}
+	return files.pin, files.pinPath, true, nil
+}
+
+// projectFiles is what findProjectFiles found above a directory.
+type projectFiles struct {
+	pin     string
+	pinPath string
+	// goModPath is the nearest go.mod, when it was looked for.
+	goModPath string
+}
+
+// findProjectFiles walks from start up to the root once, stopping at the
+// nearest .switcher-version. With goMod set it also notes the nearest go.mod
+// on the way, so the go.mod fallback needs no second walk. Every level costs
+// a single failed open in the common case of no pin.
+func findProjectFiles(start string, goMod bool) (projectFiles, error) {
 	abs, err := filepath.Abs(start)
 	if err != nil {
-		return "", "", false, fmt.Errorf("resolve absolute path from %s: %w", start, err)
+		return projectFiles{}, fmt.Errorf("resolve absolute path from %s: %w", start, err)
 	}
 
 	info, err := os.Stat(abs)
@@ -51,59 +122,149 @@ func FindLocalVersion(start string) (version string, path string, found bool, er
 		abs = filepath.Dir(abs)
 	}
 
+	var files projectFiles
 	current := abs
 	for {
 		candidate := filepath.Join(current, LocalVersionFile)
 		raw, err := os.ReadFile(candidate)
 		if err == nil {
-			normalized, normErr := versionutil.NormalizeGoVersion(strings.TrimSpace(string(raw)))
+			normalized, normErr := versionutil.NormalizeVersionSpec(strings.TrimSpace(string(raw)))
 			if normErr != nil {
-				return "", "", false, fmt.Errorf("invalid local version in %s: %w", candidate, normErr)
+				return projectFiles{}, fmt.Errorf("invalid local version in %s: %w", candidate, normErr)
 			}
-			return normalized, candidate, true, nil
+			files.pin, files.pinPath = normalized, candidate
+			return files, nil
 		}
-		if err != nil && !os.IsNotExist(err) {
-			return "", "", false, fmt.Errorf("read local version file %s: %w", candidate, err)
+		if !os.IsNotExist(err) {
+			// A directory stops the walk like any other unreadable file:
+			// falling back to an ancestor or the global version would hide
+			// the mistake.
+			if info, statErr := os.Stat(candidate); statErr == nil && info.IsDir() {
+				return projectFiles{}, fmt.Errorf("expected a file but found a directory at %s", candidate)
+			}
+			return projectFiles{}, fmt.Errorf("read local version file %s: %w", candidate, err)
+		}
+		if goMod && files.goModPath == "" {
+			modPath := filepath.Join(current, "go.mod")
+			if info, err := os.Stat(modPath); err == nil && !info.IsDir() {
+				files.goModPath = modPath
+			}
 		}
 
 		parent := filepath.Dir(current)
 		if parent == current {
-			break
+			return files, nil
 		}
 		current = parent
 	}
-
-	return "", "", false, nil
 }
 
 func ResolveActiveVersion(cwd string, paths Paths) (ActiveVersion, error) {
-	localVersion, localPath, found, err := FindLocalVersion(cwd)
+	// The config decides whether the walk also looks for go.mod, so it is
+	// read first and the walk happens once.
+	cfg, err := ReadConfig(paths)
 	if err != nil {
 		return ActiveVersion{}, err
 	}
-	if found {
-		return ActiveVersion{Version: localVersion, Scope: ScopeLocal, Source: localPath}, nil
-	}
+	return ResolveActiveVersionWithConfig(cwd, paths, cfg)
+}
 
-	cfg, err := ReadConfig(paths)
+// ResolveActiveVersionWithConfig is ResolveActiveVersion with a config the
+// caller already read, so a shim reads it only once.
+func ResolveActiveVersionWithConfig(cwd string, paths Paths, cfg Config) (ActiveVersion, error) {
+	files, err := findProjectFiles(cwd, cfg.GoModFallback)
 	if err != nil {
 		return ActiveVersion{}, err
 	}
+	if files.pinPath != "" {
+		return resolveActive(paths, files.pin, ScopeLocal, SourceLocalFile, files.pinPath)
+	}
+	if files.goModPath != "" {
+		if goModVersion, ok := goDirectiveVersion(files.goModPath); ok {
+			version, err := installedPatchFor(paths, goModVersion)
+			if err != nil {
+				return ActiveVersion{}, err
+			}
+			return resolveActive(paths, version, ScopeLocal, SourceGoMod, files.goModPath)
+		}
+	}
 
 	if cfg.GlobalVersion == "" {
 		return ActiveVersion{}, ErrNoActiveVersion
 	}
 
-	normalized, err := versionutil.NormalizeGoVersion(cfg.GlobalVersion)
+	normalized, err := versionutil.NormalizeVersionSpec(cfg.GlobalVersion)
 	if err != nil {
 		return ActiveVersion{}, fmt.Errorf("invalid global version in config: %w", err)
 	}
 
-	return ActiveVersion{Version: normalized, Scope: ScopeGlobal, Source: paths.ConfigFile}, nil
+	return resolveActive(paths, normalized, ScopeGlobal, SourceGlobalConfig, paths.ConfigFile)
+}
+
+// installedPatchFor returns the newest installed patch of directive's minor
+// line that satisfies it, since a go directive names a minimum: go 1.22 runs
+// an installed go1.22.5. directive is returned when no patch qualifies.
+func installedPatchFor(paths Paths, directive string) (string, error) {
+	line, err := versionutil.MinorLine(directive)
+	if err != nil {
+		return directive, nil
+	}
+	installed, err := ListInstalledVersions(paths)
+	if err != nil {
+		return "", err
+	}
+	latest, ok := versionutil.LatestPatch(installed, line)
+	if !ok {
+		return directive, nil
+	}
+	if cmp, err := versionutil.CompareGoVersions(latest, directive); err != nil || cmp < 0 {
+		return directive, nil
+	}
+	return latest, nil
+}
+
+func resolveActive(paths Paths, spec string, scope Scope, kind SourceKind, source string) (ActiveVersion, error) {
+	version, err := ResolveVersionSpec(paths, spec)
+	if err != nil {
+		return ActiveVersion{}, fmt.Errorf("%w (pinned in %s)", err, source)
+	}
+	active := ActiveVersion{Version: version, Scope: scope, Source: source, SourceKind: kind}
+	if version != spec {
+		active.Alias = spec
+	}
+	if target, ok := LinkedToolchainPath(paths, version); ok {
+		active.Linked = target
+	}
+	return active, nil
+}
+
+// ResolveVersionSpec expands a minor alias such as go1.24.x to the newest
+// installed patch of that line. Exact versions are returned unchanged.
+func ResolveVersionSpec(paths Paths, spec string) (string, error) {
+	if name, ok := versionutil.ParseLinkName(spec); ok {
+		if _, linked := LinkedToolchainPath(paths, name); !linked {
+			return "", &notInstalledError{message: fmt.Sprintf("no linked toolchain named %s; register one with 'switcher link %s <goroot>'", name, name)}
+		}
+		return name, nil
+	}
+	alias, majorMinor, ok := versionutil.ParseMinorAlias(spec)
+	if !ok {
+		return versionutil.NormalizeGoVersion(spec)
+	}
+
+	installed, err := ListInstalledVersions(paths)
+	if err != nil {
+		return "", err
+	}
+	latest, found := versionutil.LatestPatch(installed, majorMinor)
+	if !found {
+		return "", &notInstalledError{message: fmt.Sprintf("no installed toolchain matches %s", alias)}
+	}
+	return latest, nil
 }
 
 func SetActiveVersion(version string, scope Scope, cwd string, paths Paths) error {
-	normalized, err := versionutil.NormalizeGoVersion(version)
+	normalized, err := versionutil.NormalizeVersionSpec(version)
 	if err != nil {
 		return err
 	}
@@ -120,7 +281,7 @@ func SetActiveVersion(version string, scope Scope, cwd string, paths Paths) erro
 }
 
 func SetLocalVersionAtPath(filePath string, version string) error {
-	normalized, err := versionutil.NormalizeGoVersion(version)
+	normalized, err := versionutil.NormalizeVersionSpec(version)
 	if err != nil {
 		return err
 	}
@@ -140,42 +301,75 @@ func ClearLocalVersionAtPath(filePath string) error {
 }
 
 func SetGlobalVersion(paths Paths, version string) error {
-	normalized, err := versionutil.NormalizeGoVersion(version)
+	normalized, err := versionutil.NormalizeVersionSpec(version)
 	if err != nil {
 		return err
 	}
 
-	cfg, err := ReadConfig(paths)
+	return UpdateConfig(paths, func(cfg *Config) bool {
+		pushGlobalHistory(cfg, cfg.GlobalVersion, normalized)
+		cfg.GlobalVersion = normalized
+		return true
+	})
+}
+
+// SetGlobalVersionIfUnset sets the global version only when none is
+// configured and reports whether it wrote the config.
+func SetGlobalVersionIfUnset(paths Paths, version string) (bool, error) {
+	normalized, err := versionutil.NormalizeVersionSpec(version)
 	if err != nil {
-		return err
+		return false, err
 	}
-	cfg.GlobalVersion = normalized
-	return WriteConfig(paths, cfg)
+
+	written := false
+	err = UpdateConfig(paths, func(cfg *Config) bool {
+		if strings.TrimSpace(cfg.GlobalVersion) != "" {
+			return false
+		}
+		cfg.GlobalVersion = normalized
+		written = true
+		return true
+	})
+	return written && err == nil, err
 }
 
 func ClearGlobalVersion(paths Paths) error {
-	cfg, err := ReadConfig(paths)
-	if err != nil {
-		return err
-	}
-	cfg.GlobalVersion = ""
-	return WriteConfig(paths, cfg)
+	return UpdateConfig(paths, func(cfg *Config) bool {
+		if cfg.GlobalVersion == "" {
+			return false
+		}
+		cfg.GlobalVersion = ""
+		return true
+	})
 }
 
 func DeleteInstalledVersion(paths Paths, version string) error {
+	if name, ok := versionutil.ParseLinkName(version); ok {
+		return UnlinkToolchain(paths, name)
+	}
 	normalized, err := versionutil.NormalizeGoVersion(version)
 	if err != nil {
 		return err
 	}
 
 	targetDir := ToolchainDir(paths, normalized)
-	if _, err := os.Stat(targetDir); err != nil {
+	info, err := os.Lstat(targetDir)
+	if err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("toolchain %s is not installed", normalized)
+			return NotInstalled(normalized)
 		}
 		return fmt.Errorf("stat toolchain directory %s: %w", targetDir, err)
 	}
 
+	// Stray files and symlinks are removed themselves; a symlink's target is
+	// never followed.
+	if !info.IsDir() {
+		if err := os.Remove(targetDir); err != nil {
+			return fmt.Errorf("remove toolchain %s: %w", normalized, err)
+		}
+		return nil
+	}
+
 	if err := os.RemoveAll(targetDir); err != nil {
 		return fmt.Errorf("remove toolchain %s: %w", normalized, err)
 	}
@@ -191,7 +385,7 @@ func GlobalVersion(paths Paths) (string, bool, error) {
 	if strings.TrimSpace(cfg.GlobalVersion) == "" {
 		return "", false, nil
 	}
-	normalized, err := versionutil.NormalizeGoVersion(cfg.GlobalVersion)
+	normalized, err := versionutil.NormalizeVersionSpec(cfg.GlobalVersion)
 	if err != nil {
 		return "", false, fmt.Errorf("invalid global version %q: %w", cfg.GlobalVersion, err)
 	}
@@ -199,41 +393,76 @@ func GlobalVersion(paths Paths) (string, bool, error) {
 }
 
 func ListInstalledVersions(paths Paths) ([]string, error) {
+	return ListInstalledVersionsCtx(context.Background(), paths)
+}
+
+// ListInstalledVersionsCtx is ListInstalledVersions, but stops between
+// directory entries once ctx is done, so a slow filesystem cannot block the
+// caller indefinitely.
+func ListInstalledVersionsCtx(ctx context.Context, paths Paths) ([]string, error) {
+	versions, _, err := scanToolchains(ctx, paths)
+	return versions, err
+}
+
+// BrokenToolchain is an entry in the toolchains directory that is named like
+// a Go version but cannot be used, e.g. a leftover from a failed extraction.
+type BrokenToolchain struct {
+	Version string
+	Path    string
+	Reason  string
+}
+
+// ListBrokenToolchains reports version-named entries that ListInstalledVersions
+// skips: regular files, symlinks and directories without bin/go.
+func ListBrokenToolchains(paths Paths) ([]BrokenToolchain, error) {
+	_, broken, err := scanToolchains(context.Background(), paths)
+	return broken, err
+}
+
+func scanToolchains(ctx context.Context, paths Paths) ([]string, []BrokenToolchain, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	if err := EnsureLayout(paths); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	entries, err := os.ReadDir(paths.ToolchainsDir)
 	if err != nil {
-		return nil, fmt.Errorf("read toolchains dir %s: %w", paths.ToolchainsDir, err)
+		return nil, nil, fmt.Errorf("read toolchains dir %s: %w", paths.ToolchainsDir, err)
 	}
 
 	versions := make([]string, 0, len(entries))
+	var broken []BrokenToolchain
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
 		}
-
 		normalized, err := versionutil.NormalizeGoVersion(entry.Name())
 		if err != nil {
 			continue
 		}
 
-		goBinary := filepath.Join(paths.ToolchainsDir, entry.Name(), "bin", "go")
+		entryPath := filepath.Join(paths.ToolchainsDir, entry.Name())
+		switch {
+		case entry.Type()&os.ModeSymlink != 0:
+			broken = append(broken, BrokenToolchain{Version: normalized, Path: entryPath, Reason: "symbolic link instead of a toolchain directory"})
+			continue
+		case !entry.IsDir():
+			broken = append(broken, BrokenToolchain{Version: normalized, Path: entryPath, Reason: "file instead of a toolchain directory"})
+			continue
+		}
+
+		goBinary := GoBinaryPath(entryPath)
 		if _, err := os.Stat(goBinary); err != nil {
+			broken = append(broken, BrokenToolchain{Version: normalized, Path: entryPath, Reason: "missing bin/go"})
 			continue
 		}
 
 		versions = append(versions, normalized)
 	}
 
-	sort.Slice(versions, func(i int, j int) bool {
-		cmp, err := versionutil.CompareGoVersions(versions[i], versions[j])
-		if err != nil {
-			return versions[i] > versions[j]
-		}
-		return cmp > 0
-	})
+	versionutil.SortNewestFirst(versions)
 
-	return versions, nil
+	return versions, broken, nil
 }
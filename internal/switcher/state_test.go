@@ -1,8 +1,12 @@
 package switcher
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -48,8 +52,8 @@ func TestResolveActiveVersion_LocalTakesPrecedence(t *testing.T) {
 	if resolved.Scope != ScopeLocal {
 		t.Fatalf("expected scope local, got %s", resolved.Scope)
 	}
-	if resolved.Source != localPath {
-		t.Fatalf("expected source %s, got %s", localPath, resolved.Source)
+	if resolved.Source != localPath || resolved.SourceKind != SourceLocalFile {
+		t.Fatalf("expected local file source %s, got %s %s", localPath, resolved.SourceKind, resolved.Source)
 	}
 }
 
@@ -124,3 +128,354 @@ func TestListInstalledVersions_SortsDescending(t *testing.T) {
 		}
 	}
 }
+
+func TestListInstalledVersionsCtx_StopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	paths := Paths{
+		BaseDir:       filepath.Join(tmp, ".switcher"),
+		ToolchainsDir: filepath.Join(tmp, ".switcher", "toolchains"),
+		ToolsDir:      filepath.Join(tmp, ".switcher", "tools"),
+		BinDir:        filepath.Join(tmp, ".switcher", "bin"),
+		CacheDir:      filepath.Join(tmp, ".switcher", "cache"),
+		ConfigFile:    filepath.Join(tmp, ".switcher", "config.json"),
+	}
+	binDir := filepath.Join(paths.ToolchainsDir, "go1.25.0", "bin")
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "go"), []byte(""), 0o755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ListInstalledVersionsCtx(ctx, paths); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestListInstalledVersions_ReportsStrayFileAsBroken(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	paths := Paths{
+		BaseDir:       filepath.Join(tmp, ".switcher"),
+		ToolchainsDir: filepath.Join(tmp, ".switcher", "toolchains"),
+		ToolsDir:      filepath.Join(tmp, ".switcher", "tools"),
+		BinDir:        filepath.Join(tmp, ".switcher", "bin"),
+		CacheDir:      filepath.Join(tmp, ".switcher", "cache"),
+		ConfigFile:    filepath.Join(tmp, ".switcher", "config.json"),
+	}
+
+	if err := os.MkdirAll(paths.ToolchainsDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	strayFile := filepath.Join(paths.ToolchainsDir, "go1.24.0")
+	if err := os.WriteFile(strayFile, []byte("partial"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	installed, err := ListInstalledVersions(paths)
+	if err != nil {
+		t.Fatalf("ListInstalledVersions: %v", err)
+	}
+	if len(installed) != 0 {
+		t.Fatalf("expected stray file not to be listed, got %v", installed)
+	}
+
+	broken, err := ListBrokenToolchains(paths)
+	if err != nil {
+		t.Fatalf("ListBrokenToolchains: %v", err)
+	}
+	if len(broken) != 1 || broken[0].Version != "go1.24.0" || broken[0].Path != strayFile {
+		t.Fatalf("expected go1.24.0 to be reported as broken, got %+v", broken)
+	}
+
+	if err := DeleteInstalledVersion(paths, "go1.24.0"); err != nil {
+		t.Fatalf("DeleteInstalledVersion: %v", err)
+	}
+	if _, err := os.Lstat(strayFile); !os.IsNotExist(err) {
+		t.Fatalf("expected stray file to be removed, got %v", err)
+	}
+}
+
+func TestResolveActiveVersion_MinorAliasFollowsInstalledPatches(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	paths := Paths{
+		BaseDir:       filepath.Join(tmp, ".switcher"),
+		ToolchainsDir: filepath.Join(tmp, ".switcher", "toolchains"),
+		ToolsDir:      filepath.Join(tmp, ".switcher", "tools"),
+		BinDir:        filepath.Join(tmp, ".switcher", "bin"),
+		CacheDir:      filepath.Join(tmp, ".switcher", "cache"),
+		ConfigFile:    filepath.Join(tmp, ".switcher", "config.json"),
+	}
+	if err := EnsureLayout(paths); err != nil {
+		t.Fatalf("EnsureLayout: %v", err)
+	}
+
+	installToolchain := func(version string) {
+		binDir := filepath.Join(paths.ToolchainsDir, version, "bin")
+		if err := os.MkdirAll(binDir, 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(binDir, "go"), []byte(""), 0o755); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	expectVersion := func(want string) {
+		t.Helper()
+		resolved, err := ResolveActiveVersion(tmp, paths)
+		if err != nil {
+			t.Fatalf("ResolveActiveVersion: %v", err)
+		}
+		if resolved.Version != want || resolved.Alias != "go1.24.x" {
+			t.Fatalf("expected %s via go1.24.x, got %s via %q", want, resolved.Version, resolved.Alias)
+		}
+	}
+
+	if err := SetActiveVersion("1.24.x", ScopeGlobal, tmp, paths); err != nil {
+		t.Fatalf("SetActiveVersion: %v", err)
+	}
+	cfg, err := ReadConfig(paths)
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	if cfg.GlobalVersion != "go1.24.x" {
+		t.Fatalf("expected alias to be stored as go1.24.x, got %q", cfg.GlobalVersion)
+	}
+
+	if _, err := ResolveActiveVersion(tmp, paths); err == nil {
+		t.Fatalf("expected error when no go1.24 patch is installed")
+	}
+
+	installToolchain("go1.24.1")
+	installToolchain("go1.25.0")
+	expectVersion("go1.24.1")
+
+	installToolchain("go1.24.3")
+	expectVersion("go1.24.3")
+
+	if err := DeleteInstalledVersion(paths, "go1.24.3"); err != nil {
+		t.Fatalf("DeleteInstalledVersion: %v", err)
+	}
+	expectVersion("go1.24.1")
+}
+
+func TestResolveActiveVersion_GoModFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		fallback   bool
+		pin        string
+		directive  string
+		installed  []string
+		wantVer    string
+		wantScope  Scope
+		wantSource SourceKind
+	}{
+		{name: "disabled", wantVer: "go1.24.0", wantScope: ScopeGlobal, wantSource: SourceGlobalConfig},
+		{name: "enabled", fallback: true, wantVer: "go1.22.0", wantScope: ScopeLocal, wantSource: SourceGoMod},
+		{name: "pin wins", fallback: true, pin: "go1.23.1", wantVer: "go1.23.1", wantScope: ScopeLocal, wantSource: SourceLocalFile},
+		{name: "later patch installed", fallback: true, installed: []string{"go1.22.5", "go1.23.0"}, wantVer: "go1.22.5", wantScope: ScopeLocal, wantSource: SourceGoMod},
+		{name: "newest satisfying patch", fallback: true, directive: "1.22.3", installed: []string{"go1.22.1", "go1.22.4", "go1.22.6"}, wantVer: "go1.22.6", wantScope: ScopeLocal, wantSource: SourceGoMod},
+		{name: "only older patch installed", fallback: true, directive: "1.22.3", installed: []string{"go1.22.1"}, wantVer: "go1.22.3", wantScope: ScopeLocal, wantSource: SourceGoMod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			paths := testConfigPaths(t)
+			if err := WriteConfig(paths, Config{GlobalVersion: "go1.24.0", GoModFallback: tt.fallback}); err != nil {
+				t.Fatalf("WriteConfig: %v", err)
+			}
+
+			projectDir := filepath.Join(filepath.Dir(paths.BaseDir), "project")
+			nested := filepath.Join(projectDir, "internal", "pkg")
+			if err := os.MkdirAll(nested, 0o755); err != nil {
+				t.Fatalf("MkdirAll: %v", err)
+			}
+			for _, version := range tt.installed {
+				binDir := filepath.Join(paths.ToolchainsDir, version, "bin")
+				if err := os.MkdirAll(binDir, 0o755); err != nil {
+					t.Fatalf("MkdirAll: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(binDir, "go"), []byte(""), 0o755); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+			}
+			directive := tt.directive
+			if directive == "" {
+				directive = "1.22"
+			}
+			goMod := filepath.Join(projectDir, "go.mod")
+			if err := os.WriteFile(goMod, []byte("module example.com/project\n\ngo "+directive+"\n"), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			if tt.pin != "" {
+				if err := os.WriteFile(filepath.Join(projectDir, LocalVersionFile), []byte(tt.pin+"\n"), 0o644); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+			}
+
+			active, err := ResolveActiveVersion(nested, paths)
+			if err != nil {
+				t.Fatalf("ResolveActiveVersion: %v", err)
+			}
+			if active.Version != tt.wantVer || active.Scope != tt.wantScope || active.SourceKind != tt.wantSource {
+				t.Fatalf("expected %s (%s, %s), got %s (%s, %s)", tt.wantVer, tt.wantScope, tt.wantSource, active.Version, active.Scope, active.SourceKind)
+			}
+			if tt.wantSource == SourceGoMod && active.Source != goMod {
+				t.Fatalf("expected source %s, got %s", goMod, active.Source)
+			}
+		})
+	}
+}
+
+func TestFindLocalVersion_DirectoryStopsWalk(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, LocalVersionFile), []byte("go1.24.2\n"), 0o644); err != nil {
+		t.Fatalf("write ancestor version file: %v", err)
+	}
+	projectDir := filepath.Join(tmp, "project")
+	versionDir := filepath.Join(projectDir, LocalVersionFile)
+	if err := os.MkdirAll(versionDir, 0o755); err != nil {
+		t.Fatalf("create version dir: %v", err)
+	}
+
+	_, _, found, err := FindLocalVersion(projectDir)
+	if err == nil || found {
+		t.Fatalf("expected error instead of falling back to the ancestor, got found=%v err=%v", found, err)
+	}
+	if want := "expected a file but found a directory at " + versionDir; err.Error() != want {
+		t.Fatalf("expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestFindProjectVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    string
+		wantIn  string
+		notFind bool
+	}{
+		{name: "nothing", notFind: true},
+		{name: "go directive", files: map[string]string{"go.mod": "module m\n\ngo 1.24\n"}, want: "go1.24.0", wantIn: "go.mod"},
+		{name: "toolchain wins", files: map[string]string{"go.mod": "module m\n\ngo 1.24\ntoolchain go1.24.3\n"}, want: "go1.24.3", wantIn: "go.mod"},
+		{name: "toolchain default", files: map[string]string{"go.mod": "module m\n\ngo 1.24.1\ntoolchain default\n"}, want: "go1.24.1", wantIn: "go.mod"},
+		{name: "go.work over go.mod", files: map[string]string{"go.mod": "go 1.23\n", "../go.work": "go 1.24.2\n"}, want: "go1.24.2", wantIn: "go.work"},
+		{name: "pin over go.mod", files: map[string]string{"go.mod": "go 1.23\n", LocalVersionFile: "go1.25.0\n"}, want: "go1.25.0", wantIn: LocalVersionFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			projectDir := filepath.Join(t.TempDir(), "project")
+			if err := os.MkdirAll(projectDir, 0o755); err != nil {
+				t.Fatalf("create project dir: %v", err)
+			}
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("write %s: %v", name, err)
+				}
+			}
+
+			version, path, found, err := FindProjectVersion(projectDir)
+			if err != nil {
+				t.Fatalf("FindProjectVersion: %v", err)
+			}
+			if tt.notFind {
+				if found {
+					t.Fatalf("expected no version, got %s from %s", version, path)
+				}
+				return
+			}
+			if !found || version != tt.want || filepath.Base(path) != tt.wantIn {
+				t.Fatalf("expected %s from %s, got %s from %s (found=%v)", tt.want, tt.wantIn, version, path, found)
+			}
+		})
+	}
+}
+
+// BenchmarkResolveActiveVersion mirrors a shim run from a nested package
+// without a pin, which walks to the root and falls back to the global
+// version, opening each ancestor directory's pin file once.
+func BenchmarkResolveActiveVersion(b *testing.B) {
+	tmp := b.TempDir()
+	paths := Paths{
+		BaseDir:       filepath.Join(tmp, ".switcher"),
+		ToolchainsDir: filepath.Join(tmp, ".switcher", "toolchains"),
+		ToolsDir:      filepath.Join(tmp, ".switcher", "tools"),
+		BinDir:        filepath.Join(tmp, ".switcher", "bin"),
+		CacheDir:      filepath.Join(tmp, ".switcher", "cache"),
+		ConfigFile:    filepath.Join(tmp, ".switcher", "config.json"),
+	}
+	if err := WriteConfig(paths, Config{GlobalVersion: "go1.25.0", GolangCILintByGo: map[string]string{"go1.25.0": "v2.9.0"}}); err != nil {
+		b.Fatalf("WriteConfig: %v", err)
+	}
+	cwd := filepath.Join(tmp, "src", "github.com", "example", "project", "internal", "pkg")
+	if err := os.MkdirAll(cwd, 0o755); err != nil {
+		b.Fatalf("MkdirAll: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ResolveActiveVersion(cwd, paths); err != nil {
+			b.Fatalf("ResolveActiveVersion: %v", err)
+		}
+	}
+}
+
+func TestSetGlobalVersionIfUnset_OneConcurrentWriterWins(t *testing.T) {
+	t.Parallel()
+
+	paths := testConfigPaths(t)
+	var (
+		start   = make(chan struct{})
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		winners []string
+		errs    []error
+	)
+	for patch := range 16 {
+		version := fmt.Sprintf("go1.25.%d", patch)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			written, err := SetGlobalVersionIfUnset(paths, version)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if written {
+				winners = append(winners, version)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	if len(winners) != 1 {
+		t.Fatalf("expected exactly one writer to set the global version, got %v", winners)
+	}
+	global, ok, err := GlobalVersion(paths)
+	if err != nil || !ok || global != winners[0] {
+		t.Fatalf("expected global version %s, got %q (%v, %v)", winners[0], global, ok, err)
+	}
+}
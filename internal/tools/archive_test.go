@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name    string
+	mode    int64
+	content string
+}
+
+func writeTarGz(t *testing.T, entries []tarEntry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: entry.mode, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("write content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "golangci-lint.tar.gz")
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	return archivePath
+}
+
+func TestExtractBinaryFromArchive_Layouts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		want    string
+	}{
+		{
+			name: "v1 layout",
+			entries: []tarEntry{
+				{name: "golangci-lint-1.64.8-linux-amd64/README.md", mode: 0o644, content: "readme"},
+				{name: "golangci-lint-1.64.8-linux-amd64/golangci-lint", mode: 0o755, content: "v1"},
+			},
+			want: "v1",
+		},
+		{
+			name: "v2 nested layout",
+			entries: []tarEntry{
+				{name: "golangci-lint-2.9.0-linux-amd64/LICENSE", mode: 0o644, content: "license"},
+				{name: "golangci-lint-2.9.0-linux-amd64/bin/golangci-lint", mode: 0o755, content: "v2"},
+			},
+			want: "v2",
+		},
+		{
+			name: "prefers top-level binary",
+			entries: []tarEntry{
+				{name: "golangci-lint-2.9.0-linux-amd64/extras/bin/golangci-lint", mode: 0o755, content: "nested"},
+				{name: "golangci-lint-2.9.0-linux-amd64/golangci-lint", mode: 0o755, content: "top"},
+			},
+			want: "top",
+		},
+		{
+			name: "prefers executable over docs with same name",
+			entries: []tarEntry{
+				{name: "golangci-lint", mode: 0o644, content: "doc"},
+				{name: "golangci-lint-2.9.0-linux-amd64/golangci-lint", mode: 0o755, content: "exe"},
+			},
+			want: "exe",
+		},
+	}
+
+	for _, tc := range tests {
+		archivePath := writeTarGz(t, tc.entries)
+		destination := filepath.Join(t.TempDir(), "bin", "golangci-lint")
+
+		if err := extractBinaryFromArchive(archivePath, destination, "golangci-lint"); err != nil {
+			t.Fatalf("%s: extract: %v", tc.name, err)
+		}
+		got, err := os.ReadFile(destination)
+		if err != nil {
+			t.Fatalf("%s: read extracted binary: %v", tc.name, err)
+		}
+		if string(got) != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.name, tc.want, string(got))
+		}
+	}
+}
+
+func TestExtractBinaryFromArchive_Missing(t *testing.T) {
+	t.Parallel()
+
+	archivePath := writeTarGz(t, []tarEntry{{name: "golangci-lint-2.9.0/README.md", mode: 0o644, content: "readme"}})
+	if err := extractBinaryFromArchive(archivePath, filepath.Join(t.TempDir(), "golangci-lint"), "golangci-lint"); err == nil {
+		t.Fatalf("expected missing binary error")
+	}
+}
+
+func TestGolangCILintArchiveNameFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		goos   string
+		goarch string
+		want   string
+	}{
+		{goos: "linux", goarch: "amd64", want: "golangci-lint-1.64.8-linux-amd64.tar.gz"},
+		{goos: "darwin", goarch: "arm64", want: "golangci-lint-1.64.8-darwin-arm64.tar.gz"},
+		{goos: "windows", goarch: "amd64", want: "golangci-lint-1.64.8-windows-amd64.zip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			t.Parallel()
+			if got := golangCILintArchiveNameFor("v1.64.8", tt.goos, tt.goarch); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExtractBinaryFromArchive_Zip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range []tarEntry{
+		{name: "golangci-lint-1.64.8-windows-amd64/README.md", content: "readme"},
+		{name: "golangci-lint-1.64.8-windows-amd64/golangci-lint.exe", content: "windows"},
+	} {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			t.Fatalf("create zip entry: %v", err)
+		}
+		if _, err := w.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("write zip entry: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "golangci-lint.zip")
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+
+	destination := filepath.Join(t.TempDir(), "golangci-lint.exe")
+	if err := extractBinaryFromArchive(archivePath, destination, "golangci-lint.exe"); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	got, err := os.ReadFile(destination)
+	if err != nil || string(got) != "windows" {
+		t.Fatalf("expected extracted windows binary, got %q (%v)", got, err)
+	}
+}
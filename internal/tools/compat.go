@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/mrtuuro/go-switcher/internal/versionutil"
@@ -52,3 +53,29 @@ func isWithinRange(value string, min string, max string) bool {
 
 	return true
 }
+
+// LintRecommended passed to `use --lint` drops a pin and returns to the
+// recommended golangci-lint version.
+const LintRecommended = "recommended"
+
+// NormalizeLintVersion validates a golangci-lint version such as v1.64.8 and
+// adds the v prefix when missing.
+func NormalizeLintVersion(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if strings.Count(trimmed, ".") != 2 || versionutil.ValidateDottedVersion(trimmed) != nil {
+		return "", fmt.Errorf("invalid golangci-lint version %q (expected vX.Y.Z)", raw)
+	}
+	return "v" + strings.TrimPrefix(trimmed, "v"), nil
+}
+
+// CompatibilityWarning returns a warning when lintVersion is older than the
+// first golangci-lint release compatibilityRules lists for goVersion, and ""
+// otherwise.
+func CompatibilityWarning(goVersion string, lintVersion string) string {
+	recommended := RecommendedGolangCILint(goVersion)
+	cmp, err := versionutil.CompareDottedVersions(lintVersion, recommended)
+	if err != nil || cmp >= 0 {
+		return ""
+	}
+	return fmt.Sprintf("golangci-lint %s is older than %s, the version recommended for %s; it may fail to analyze code for this toolchain", lintVersion, recommended, goVersion)
+}
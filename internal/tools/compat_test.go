@@ -1,6 +1,10 @@
 package tools
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
 
 func TestRecommendedGolangCILint(t *testing.T) {
 	t.Parallel()
@@ -23,3 +27,76 @@ func TestRecommendedGolangCILint(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalizeLintVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "v1.64.8", want: "v1.64.8"},
+		{raw: "2.9.0", want: "v2.9.0"},
+		{raw: "v1.64", wantErr: true},
+		{raw: "latest", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := NormalizeLintVersion(tc.raw)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("expected error for %q, got %s", tc.raw, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Fatalf("for %q expected %s, got %s (err %v)", tc.raw, tc.want, got, err)
+		}
+	}
+}
+
+func TestCompatibilityWarning(t *testing.T) {
+	t.Parallel()
+
+	if warning := CompatibilityWarning("go1.25.0", "v1.64.8"); warning == "" {
+		t.Fatalf("expected warning for golangci-lint older than the recommendation")
+	}
+	if warning := CompatibilityWarning("go1.24.1", "v1.64.8"); warning != "" {
+		t.Fatalf("expected no warning for the recommended version, got %q", warning)
+	}
+	if warning := CompatibilityWarning("go1.24.1", "v2.9.0"); warning != "" {
+		t.Fatalf("expected no warning for a newer version, got %q", warning)
+	}
+}
+
+func TestResolveLintVersion_PinAndDefault(t *testing.T) {
+	t.Parallel()
+
+	cfg := switcher.Config{GolangCILintByGo: map[string]string{"go1.25.0": "v1.61.0"}}
+	if got := ResolveLintVersion(cfg, "go1.25.0"); got != "v2.9.0" {
+		t.Fatalf("expected unpinned stale mapping to resolve to v2.9.0, got %s", got)
+	}
+
+	PinLintVersion(&cfg, "go1.25.0", "v1.61.0")
+	if got := ResolveLintVersion(cfg, "go1.25.0"); got != "v1.61.0" {
+		t.Fatalf("expected pinned v1.61.0, got %s", got)
+	}
+
+	cfg.GolangCILintDefault = "v2.1.0"
+	if got := ResolveLintVersion(cfg, "go1.24.1"); got != "v2.1.0" {
+		t.Fatalf("expected default v2.1.0, got %s", got)
+	}
+	if got := ResolveLintVersion(cfg, "go1.25.0"); got != "v1.61.0" {
+		t.Fatalf("expected pin to win over default, got %s", got)
+	}
+
+	UnpinLintVersion(&cfg, "go1.25.0")
+	if len(cfg.GolangCILintPinned) != 0 || cfg.GolangCILintByGo["go1.25.0"] != "" {
+		t.Fatalf("expected pin removed, got %v %v", cfg.GolangCILintPinned, cfg.GolangCILintByGo)
+	}
+	if got := ResolveLintVersion(cfg, "go1.25.0"); got != "v2.1.0" {
+		t.Fatalf("expected default after unpin, got %s", got)
+	}
+}
@@ -2,29 +2,68 @@ package tools
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/mrtuuro/go-switcher/internal/filelock"
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
 	"github.com/mrtuuro/go-switcher/internal/progress"
 	"github.com/mrtuuro/go-switcher/internal/switcher"
 	"github.com/mrtuuro/go-switcher/internal/versionutil"
 )
 
+// ErrUnavailable reports that golangci-lint publishes no archive for the
+// requested version on the current platform.
+var ErrUnavailable = errors.New("golangci-lint is unavailable for this platform")
+
+// notInstalledError reports a missing golangci-lint binary. It matches
+// os.ErrNotExist so callers can tell it apart from other stat failures.
+type notInstalledError struct {
+	message string
+}
+
+func (e *notInstalledError) Error() string {
+	return e.message
+}
+
+func (e *notInstalledError) Is(target error) bool {
+	return target == os.ErrNotExist
+}
+
+// errNotFound marks a download that failed with HTTP 404.
+var errNotFound = errors.New("not found")
+
 type EnsureOptions struct {
 	Reporter progress.Reporter
+	// Reinstall forces a fresh download and extraction even when the binary
+	// or its cached archive is already present.
+	Reinstall bool
+	// LintVersion pins golangci-lint for the Go version, overriding the
+	// recommendation. LintRecommended removes an existing pin.
+	LintVersion string
+}
+
+// GolangCILintRoot is the directory holding one subdirectory per installed
+// golangci-lint version.
+func GolangCILintRoot(paths switcher.Paths) string {
+	return filepath.Join(paths.ToolsDir, "golangci-lint")
 }
 
 func GolangCILintBinaryPath(paths switcher.Paths, lintVersion string) string {
 	platformDir := runtime.GOOS + "-" + runtime.GOARCH
-	return filepath.Join(paths.ToolsDir, "golangci-lint", lintVersion, platformDir, "golangci-lint")
+	return filepath.Join(GolangCILintRoot(paths), lintVersion, platformDir, switcher.ExecutableName("golangci-lint"))
 }
 
 func EnsureForGoVersion(ctx context.Context, paths switcher.Paths, cfg *switcher.Config, goVersion string) (string, error) {
@@ -32,32 +71,23 @@ func EnsureForGoVersion(ctx context.Context, paths switcher.Paths, cfg *switcher
 }
 
 func EnsureForGoVersionWithOptions(ctx context.Context, paths switcher.Paths, cfg *switcher.Config, goVersion string, opts EnsureOptions) (string, error) {
-	if cfg.GolangCILintByGo == nil {
-		cfg.GolangCILintByGo = map[string]string{}
-	}
-
-	recommended := RecommendedGolangCILint(goVersion)
-	lintVersion := strings.TrimSpace(cfg.GolangCILintByGo[goVersion])
-	if lintVersion == "" {
-		lintVersion = recommended
-		cfg.GolangCILintByGo[goVersion] = lintVersion
-	} else {
-		cmp, err := versionutil.CompareDottedVersions(lintVersion, recommended)
-		if err != nil || cmp < 0 {
-			progress.Emit(opts.Reporter, "lint-install", fmt.Sprintf("Upgrading golangci-lint mapping from %s to %s for %s", lintVersion, recommended, goVersion), 0, 0)
-			lintVersion = recommended
-			cfg.GolangCILintByGo[goVersion] = lintVersion
-		}
+	lintVersion, err := MapLintVersion(cfg, goVersion, opts)
+	if err != nil {
+		return "", err
 	}
 
 	binaryPath := GolangCILintBinaryPath(paths, lintVersion)
-	if _, err := os.Stat(binaryPath); err == nil {
+	if _, err := os.Stat(binaryPath); err == nil && !opts.Reinstall {
 		progress.Emit(opts.Reporter, "lint-install", fmt.Sprintf("Using cached golangci-lint %s", lintVersion), 0, 0)
 		return lintVersion, nil
 	}
 
-	progress.Emit(opts.Reporter, "lint-install", fmt.Sprintf("Installing golangci-lint %s", lintVersion), 0, 0)
-	if err := installGolangCILint(ctx, paths, lintVersion, opts.Reporter); err != nil {
+	if opts.Reinstall {
+		progress.Emit(opts.Reporter, "lint-install", fmt.Sprintf("Reinstalling golangci-lint %s", lintVersion), 0, 0)
+	} else {
+		progress.Emit(opts.Reporter, "lint-install", fmt.Sprintf("Installing golangci-lint %s", lintVersion), 0, 0)
+	}
+	if err := installGolangCILint(ctx, paths, lintVersion, opts.Reinstall, opts.Reporter); err != nil {
 		return "", err
 	}
 
@@ -65,6 +95,140 @@ func EnsureForGoVersionWithOptions(ctx context.Context, paths switcher.Paths, cf
 	return lintVersion, nil
 }
 
+// MapLintVersion makes the config changes of EnsureForGoVersionWithOptions
+// without installing anything: it applies opts.LintVersion and maps
+// goVersion to the golangci-lint version that resolves for it.
+func MapLintVersion(cfg *switcher.Config, goVersion string, opts EnsureOptions) (string, error) {
+	if cfg.GolangCILintByGo == nil {
+		cfg.GolangCILintByGo = map[string]string{}
+	}
+
+	switch opts.LintVersion {
+	case "":
+	case LintRecommended:
+		UnpinLintVersion(cfg, goVersion)
+	default:
+		pinned, err := NormalizeLintVersion(opts.LintVersion)
+		if err != nil {
+			return "", err
+		}
+		PinLintVersion(cfg, goVersion, pinned)
+	}
+
+	mapped := strings.TrimSpace(cfg.GolangCILintByGo[goVersion])
+	lintVersion := ResolveLintVersion(*cfg, goVersion)
+	if mapped != "" && mapped != lintVersion {
+		progress.Emit(opts.Reporter, "lint-install", fmt.Sprintf("Upgrading golangci-lint mapping from %s to %s for %s", mapped, lintVersion, goVersion), 0, 0)
+	}
+	cfg.GolangCILintByGo[goVersion] = lintVersion
+	return lintVersion, nil
+}
+
+// ResolveLintVersion returns the golangci-lint version EnsureForGoVersion
+// would select for goVersion, without modifying cfg.
+func ResolveLintVersion(cfg switcher.Config, goVersion string) string {
+	lintVersion := strings.TrimSpace(cfg.GolangCILintByGo[goVersion])
+	if lintVersion != "" && slices.Contains(cfg.GolangCILintPinned, goVersion) {
+		return lintVersion
+	}
+	if fallback, err := NormalizeLintVersion(cfg.GolangCILintDefault); err == nil {
+		return fallback
+	}
+
+	recommended := RecommendedGolangCILint(goVersion)
+	if lintVersion == "" {
+		return recommended
+	}
+
+	cmp, err := versionutil.CompareDottedVersions(lintVersion, recommended)
+	if err != nil || cmp < 0 {
+		return recommended
+	}
+	return lintVersion
+}
+
+// PinLintVersion maps goVersion to lintVersion and keeps that mapping even
+// when it is older than the recommendation.
+func PinLintVersion(cfg *switcher.Config, goVersion string, lintVersion string) {
+	if cfg.GolangCILintByGo == nil {
+		cfg.GolangCILintByGo = map[string]string{}
+	}
+	cfg.GolangCILintByGo[goVersion] = lintVersion
+	if !slices.Contains(cfg.GolangCILintPinned, goVersion) {
+		cfg.GolangCILintPinned = append(cfg.GolangCILintPinned, goVersion)
+		slices.Sort(cfg.GolangCILintPinned)
+	}
+}
+
+// UnpinLintVersion drops the pin for goVersion so ResolveLintVersion picks
+// the default or recommended version again.
+func UnpinLintVersion(cfg *switcher.Config, goVersion string) {
+	if !slices.Contains(cfg.GolangCILintPinned, goVersion) {
+		return
+	}
+	cfg.GolangCILintPinned = slices.DeleteFunc(cfg.GolangCILintPinned, func(v string) bool { return v == goVersion })
+	if len(cfg.GolangCILintPinned) == 0 {
+		cfg.GolangCILintPinned = nil
+	}
+	delete(cfg.GolangCILintByGo, goVersion)
+}
+
+// CheckAvailable reports whether golangci-lint lintVersion can be installed
+// for the current platform. Installed binaries and cached archives count as
+// available; otherwise the release asset is probed with a HEAD request.
+func CheckAvailable(ctx context.Context, paths switcher.Paths, lintVersion string) (bool, error) {
+	if _, err := os.Stat(GolangCILintBinaryPath(paths, lintVersion)); err == nil {
+		return true, nil
+	}
+	if _, err := os.Stat(filepath.Join(paths.CacheDir, golangCILintArchiveName(lintVersion))); err == nil {
+		return true, nil
+	}
+
+	return remoteAssetExists(ctx, golangCILintArchiveURL(lintVersion))
+}
+
+func remoteAssetExists(ctx context.Context, url string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+
+	client := httpclient.New(15 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("check %s: %w", url, err)
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("check %s: %w", url, &httpclient.StatusError{StatusCode: resp.StatusCode})
+	}
+}
+
+func golangCILintArchiveName(lintVersion string) string {
+	return golangCILintArchiveNameFor(lintVersion, runtime.GOOS, runtime.GOARCH)
+}
+
+// golangCILintArchiveNameFor names the release archive for goos/goarch.
+// golangci-lint ships .zip archives for Windows and .tar.gz everywhere else.
+func golangCILintArchiveNameFor(lintVersion string, goos string, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	versionNoPrefix := strings.TrimPrefix(lintVersion, "v")
+	return fmt.Sprintf("golangci-lint-%s-%s-%s%s", versionNoPrefix, goos, goarch, ext)
+}
+
+func golangCILintArchiveURL(lintVersion string) string {
+	return fmt.Sprintf("https://github.com/golangci/golangci-lint/releases/download/%s/%s", lintVersion, golangCILintArchiveName(lintVersion))
+}
+
 func ResolveBinary(paths switcher.Paths, cfg switcher.Config, goVersion string) (binaryPath string, lintVersion string, err error) {
 	lintVersion = cfg.GolangCILintByGo[goVersion]
 	if strings.TrimSpace(lintVersion) == "" {
@@ -73,26 +237,44 @@ func ResolveBinary(paths switcher.Paths, cfg switcher.Config, goVersion string)
 
 	binaryPath = GolangCILintBinaryPath(paths, lintVersion)
 	if _, statErr := os.Stat(binaryPath); statErr != nil {
-		return "", lintVersion, fmt.Errorf("golangci-lint %s is not installed for %s (expected %s)", lintVersion, goVersion, binaryPath)
+		if !os.IsNotExist(statErr) {
+			return "", lintVersion, fmt.Errorf("stat golangci-lint binary: %w", statErr)
+		}
+		return "", lintVersion, &notInstalledError{message: fmt.Sprintf("golangci-lint %s is not installed for %s (expected %s)", lintVersion, goVersion, binaryPath)}
 	}
 
 	return binaryPath, lintVersion, nil
 }
 
-func installGolangCILint(ctx context.Context, paths switcher.Paths, lintVersion string, reporter progress.Reporter) error {
+func installGolangCILint(ctx context.Context, paths switcher.Paths, lintVersion string, reinstall bool, reporter progress.Reporter) error {
 	if err := switcher.EnsureLayout(paths); err != nil {
 		return err
 	}
 
-	versionNoPrefix := strings.TrimPrefix(lintVersion, "v")
-	archiveName := fmt.Sprintf("golangci-lint-%s-%s-%s.tar.gz", versionNoPrefix, runtime.GOOS, runtime.GOARCH)
-	archiveURL := fmt.Sprintf("https://github.com/golangci/golangci-lint/releases/download/%s/%s", lintVersion, archiveName)
+	archiveName := golangCILintArchiveName(lintVersion)
+	archiveURL := golangCILintArchiveURL(lintVersion)
 	cachePath := filepath.Join(paths.CacheDir, archiveName)
+	unlock, err := filelock.Lock(ctx, cachePath, func() {
+		progress.Emit(reporter, "lint-download", fmt.Sprintf("Waiting for another download of %s to finish", archiveName), 0, 0)
+	})
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if reinstall {
+		if err := os.Remove(cachePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove cached archive %s: %w", cachePath, err)
+		}
+	}
 	if _, err := os.Stat(cachePath); err != nil {
 		if !os.IsNotExist(err) {
 			return fmt.Errorf("stat cache file %s: %w", cachePath, err)
 		}
-		if err := downloadToFile(ctx, archiveURL, cachePath, reporter, "lint-download", archiveName); err != nil {
+		if err := downloadArchive(ctx, archiveURL, cachePath, reporter, archiveName); err != nil {
+			if errors.Is(err, errNotFound) {
+				return fmt.Errorf("%w: no %s archive for %s/%s", ErrUnavailable, lintVersion, runtime.GOOS, runtime.GOARCH)
+			}
 			return fmt.Errorf("download golangci-lint archive: %w", err)
 		}
 	} else {
@@ -101,112 +283,174 @@ func installGolangCILint(ctx context.Context, paths switcher.Paths, lintVersion
 
 	binaryPath := GolangCILintBinaryPath(paths, lintVersion)
 	progress.Emit(reporter, "lint-extract", fmt.Sprintf("Extracting %s", archiveName), 0, 0)
-	if err := extractBinaryFromArchive(cachePath, binaryPath, "golangci-lint"); err != nil {
+	if err := extractBinaryFromArchive(cachePath, binaryPath, switcher.ExecutableName("golangci-lint")); err != nil {
+		return fmt.Errorf("install golangci-lint %s: %w", lintVersion, err)
+	}
+
+	if err := verifyExecutable(binaryPath); err != nil {
 		return fmt.Errorf("install golangci-lint %s: %w", lintVersion, err)
 	}
 
 	return nil
 }
 
-func downloadToFile(ctx context.Context, url string, destination string, reporter progress.Reporter, stage string, label string) error {
-	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
-		return fmt.Errorf("create destination directory: %w", err)
-	}
-
-	tmp, err := os.CreateTemp(filepath.Dir(destination), ".download-*")
+func verifyExecutable(path string) error {
+	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("create temporary file: %w", err)
+		return fmt.Errorf("stat installed binary: %w", err)
+	}
+	if !info.Mode().IsRegular() || info.Size() == 0 {
+		return fmt.Errorf("installed binary %s is empty or not a regular file", path)
 	}
-	tmpPath := tmp.Name()
+	// Windows has no executable bit; the .exe suffix is what counts there.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("installed binary %s is not executable", path)
+	}
+	return nil
+}
 
-	cleanup := func() {
-		_ = tmp.Close()
-		_ = os.Remove(tmpPath)
+// downloadArchive downloads url to the cache file destination, reporting a
+// missing asset as errNotFound.
+func downloadArchive(ctx context.Context, url string, destination string, reporter progress.Reporter, label string) error {
+	client := httpclient.New(120 * time.Second)
+	if _, err := httpclient.Download(ctx, client, url, destination, reporter, "lint-download", label); err != nil {
+		var statusErr *httpclient.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%s: %w", url, errNotFound)
+		}
+		return err
 	}
+	return nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// extractBinaryFromArchive copies binaryName out of a .tar.gz archive.
+// golangci-lint v1 and v2 archives nest the binary differently, so every
+// regular file with that base name is considered; executables win over
+// non-executables, and shallower paths win over deeper ones.
+func extractBinaryFromArchive(archivePath string, destination string, binaryName string) error {
+	entryName, err := findBinaryInArchive(archivePath, binaryName)
 	if err != nil {
-		cleanup()
-		return fmt.Errorf("create request: %w", err)
+		return err
 	}
 
-	client := &http.Client{Timeout: 120 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		cleanup()
-		return fmt.Errorf("execute request: %w", err)
+	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+		return fmt.Errorf("create binary destination directory: %w", err)
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
 
-	if resp.StatusCode != http.StatusOK {
-		cleanup()
-		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	found := false
+	err = walkArchive(archivePath, func(entry archiveEntry, content io.Reader) (bool, error) {
+		if !entry.regular || entry.name != entryName {
+			return false, nil
+		}
+		found = true
+		return true, writeExtractedBinary(content, destination)
+	})
+	if err != nil {
+		return err
 	}
+	if !found {
+		return fmt.Errorf("binary %s not found in archive", binaryName)
+	}
+	return nil
+}
 
-	total := resp.ContentLength
-	progress.Emit(reporter, stage, fmt.Sprintf("Downloading %s", label), 0, total)
+func findBinaryInArchive(archivePath string, binaryName string) (string, error) {
+	best := ""
+	bestExecutable := false
+	bestDepth := 0
 
-	progressWriter := &downloadProgressWriter{
-		reporter: reporter,
-		stage:    stage,
-		label:    label,
-		total:    total,
-	}
+	err := walkArchive(archivePath, func(entry archiveEntry, _ io.Reader) (bool, error) {
+		if !entry.regular {
+			return false, nil
+		}
+		name := strings.TrimPrefix(path.Clean(entry.name), "./")
+		if path.Base(name) != binaryName {
+			return false, nil
+		}
 
-	if _, err := io.Copy(tmp, io.TeeReader(resp.Body, progressWriter)); err != nil {
-		cleanup()
-		return fmt.Errorf("write response: %w", err)
-	}
-	progressWriter.emit(true)
+		executable := entry.executable
+		depth := strings.Count(name, "/")
+		switch {
+		case best == "":
+		case executable != bestExecutable:
+			if !executable {
+				return false, nil
+			}
+		case depth >= bestDepth:
+			return false, nil
+		}
 
-	if err := tmp.Close(); err != nil {
-		cleanup()
-		return fmt.Errorf("close temporary file: %w", err)
+		best = entry.name
+		bestExecutable = executable
+		bestDepth = depth
+		return false, nil
+	})
+	if err != nil {
+		return "", err
 	}
-
-	if err := os.Rename(tmpPath, destination); err != nil {
-		cleanup()
-		return fmt.Errorf("finalize download: %w", err)
+	if best == "" {
+		return "", fmt.Errorf("binary %s not found in archive", binaryName)
 	}
-
-	return nil
+	return best, nil
 }
 
-type downloadProgressWriter struct {
-	reporter progress.Reporter
-	stage    string
-	label    string
-	total    int64
-	current  int64
-	lastEmit time.Time
+// archiveEntry is the part of a tar or zip entry header the extraction
+// needs.
+type archiveEntry struct {
+	name       string
+	regular    bool
+	executable bool
 }
 
-func (w *downloadProgressWriter) Write(p []byte) (int, error) {
-	w.current += int64(len(p))
-	w.emit(false)
-	return len(p), nil
+// walkArchive calls fn for each entry of a .zip or .tar.gz archive until fn
+// returns stop or an error.
+func walkArchive(archivePath string, fn func(entry archiveEntry, content io.Reader) (bool, error)) error {
+	if strings.HasSuffix(archivePath, ".zip") {
+		return walkZip(archivePath, fn)
+	}
+	return walkTarGz(archivePath, func(header *tar.Header, content io.Reader) (bool, error) {
+		entry := archiveEntry{
+			name:       header.Name,
+			regular:    header.Typeflag == tar.TypeReg,
+			executable: header.FileInfo().Mode()&0o111 != 0,
+		}
+		return fn(entry, content)
+	})
 }
 
-func (w *downloadProgressWriter) emit(force bool) {
-	if w.reporter == nil {
-		return
-	}
-	if !force && time.Since(w.lastEmit) < 250*time.Millisecond {
-		return
+func walkZip(archivePath string, fn func(entry archiveEntry, content io.Reader) (bool, error)) error {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("open zip archive: %w", err)
 	}
+	defer func() {
+		_ = zipReader.Close()
+	}()
 
-	transfer := progress.FormatTransfer(w.current, w.total)
-	progress.Emit(w.reporter, w.stage, fmt.Sprintf("Downloading %s %s", w.label, transfer), w.current, w.total)
-	w.lastEmit = time.Now()
-}
-
-func extractBinaryFromArchive(archivePath string, destination string, binaryName string) error {
-	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
-		return fmt.Errorf("create binary destination directory: %w", err)
+	for _, file := range zipReader.File {
+		mode := file.Mode()
+		entry := archiveEntry{name: file.Name, regular: mode.IsRegular(), executable: mode&0o111 != 0}
+		if !entry.regular {
+			if stop, err := fn(entry, nil); err != nil || stop {
+				return err
+			}
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("read zip entry %s: %w", file.Name, err)
+		}
+		stop, err := fn(entry, content)
+		_ = content.Close()
+		if err != nil || stop {
+			return err
+		}
 	}
+	return nil
+}
 
+// walkTarGz calls fn for each entry until fn returns stop or an error.
+func walkTarGz(archivePath string, fn func(header *tar.Header, content io.Reader) (bool, error)) error {
 	archiveFile, err := os.Open(archivePath)
 	if err != nil {
 		return fmt.Errorf("open archive: %w", err)
@@ -227,51 +471,48 @@ func extractBinaryFromArchive(archivePath string, destination string, binaryName
 	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
 			return fmt.Errorf("read tar entry: %w", err)
 		}
 
-		if header.Typeflag != tar.TypeReg {
-			continue
+		stop, err := fn(header, tarReader)
+		if err != nil || stop {
+			return err
 		}
+	}
+}
 
-		if !strings.HasSuffix(header.Name, "/"+binaryName) && filepath.Base(header.Name) != binaryName {
-			continue
-		}
-
-		tmpFile, err := os.CreateTemp(filepath.Dir(destination), ".tmp-golangci-*")
-		if err != nil {
-			return fmt.Errorf("create temp binary file: %w", err)
-		}
-		tmpPath := tmpFile.Name()
-
-		cleanup := func() {
-			_ = tmpFile.Close()
-			_ = os.Remove(tmpPath)
-		}
+func writeExtractedBinary(content io.Reader, destination string) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(destination), ".tmp-golangci-*")
+	if err != nil {
+		return fmt.Errorf("create temp binary file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
 
-		if _, err := io.Copy(tmpFile, tarReader); err != nil {
-			cleanup()
-			return fmt.Errorf("write temporary binary: %w", err)
-		}
-		if err := tmpFile.Chmod(0o755); err != nil {
-			cleanup()
-			return fmt.Errorf("set executable bit: %w", err)
-		}
-		if err := tmpFile.Close(); err != nil {
-			cleanup()
-			return fmt.Errorf("close temporary binary: %w", err)
-		}
+	cleanup := func() {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+	}
 
-		if err := os.Rename(tmpPath, destination); err != nil {
-			cleanup()
-			return fmt.Errorf("finalize binary install: %w", err)
-		}
+	if _, err := io.Copy(tmpFile, content); err != nil {
+		cleanup()
+		return fmt.Errorf("write temporary binary: %w", err)
+	}
+	if err := tmpFile.Chmod(0o755); err != nil {
+		cleanup()
+		return fmt.Errorf("set executable bit: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		cleanup()
+		return fmt.Errorf("close temporary binary: %w", err)
+	}
 
-		return nil
+	if err := os.Rename(tmpPath, destination); err != nil {
+		cleanup()
+		return fmt.Errorf("finalize binary install: %w", err)
 	}
 
-	return fmt.Errorf("binary %s not found in archive", binaryName)
+	return nil
 }
@@ -2,10 +2,16 @@ package tools
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
 	"github.com/mrtuuro/go-switcher/internal/switcher"
 )
 
@@ -113,3 +119,132 @@ func mustWriteLintBinary(t *testing.T, paths switcher.Paths, version string) {
 		t.Fatalf("WriteFile: %v", err)
 	}
 }
+
+func TestVerifyExecutable(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+		wantErr bool
+	}{
+		{name: "executable", content: "#!/bin/sh\n", mode: 0o755},
+		{name: "not executable", content: "#!/bin/sh\n", mode: 0o644, wantErr: true},
+		{name: "empty", content: "", mode: 0o755, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		path := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(path, []byte(tc.content), tc.mode); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if err := os.Chmod(path, tc.mode); err != nil {
+			t.Fatalf("Chmod: %v", err)
+		}
+
+		err := verifyExecutable(path)
+		if tc.wantErr && err == nil {
+			t.Fatalf("%s: expected error", tc.name)
+		}
+		if !tc.wantErr && err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+func TestRemoteAssetExists(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected HEAD request, got %s", r.Method)
+		}
+		if strings.HasSuffix(r.URL.Path, "/missing.tar.gz") {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ok, err := remoteAssetExists(context.Background(), server.URL+"/present.tar.gz")
+	if err != nil || !ok {
+		t.Fatalf("expected present asset, got ok=%v err=%v", ok, err)
+	}
+
+	ok, err = remoteAssetExists(context.Background(), server.URL+"/missing.tar.gz")
+	if err != nil || ok {
+		t.Fatalf("expected missing asset, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestDownloadArchive_NotFound(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	destination := filepath.Join(t.TempDir(), "golangci-lint.tar.gz")
+	err := downloadArchive(context.Background(), server.URL+"/golangci-lint.tar.gz", destination, nil, "golangci-lint")
+	if !errors.Is(err, errNotFound) {
+		t.Fatalf("expected errNotFound, got %v", err)
+	}
+	if _, err := os.Stat(destination); !os.IsNotExist(err) {
+		t.Fatalf("expected no file at %s", destination)
+	}
+}
+
+func TestDownloadArchive_ResumesPartialDownload(t *testing.T) {
+	t.Parallel()
+
+	const content = "golangci-lint archive bytes"
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "golangci-lint.tar.gz", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	destination := filepath.Join(cacheDir, "golangci-lint.tar.gz")
+	partPath := filepath.Join(cacheDir, httpclient.PartialDir, "golangci-lint.tar.gz.part")
+	if err := os.MkdirAll(filepath.Dir(partPath), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(partPath, []byte(content[:10]), 0o644); err != nil {
+		t.Fatalf("write partial: %v", err)
+	}
+	if err := os.WriteFile(partPath+".validator", []byte(`"v1"`+"\n"), 0o644); err != nil {
+		t.Fatalf("write validator: %v", err)
+	}
+
+	if err := downloadArchive(context.Background(), server.URL+"/golangci-lint.tar.gz", destination, nil, "golangci-lint"); err != nil {
+		t.Fatalf("downloadArchive: %v", err)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=10-" {
+		t.Fatalf("expected one request resuming at byte 10, got %q", ranges)
+	}
+	if data, err := os.ReadFile(destination); err != nil || string(data) != content {
+		t.Fatalf("expected whole file, got %q (%v)", data, err)
+	}
+	for _, leftover := range []string{partPath, partPath + ".validator"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed", leftover)
+		}
+	}
+}
+
+func TestCheckAvailable_InstalledBinarySkipsNetwork(t *testing.T) {
+	t.Parallel()
+
+	paths := testPaths(t)
+	mustWriteLintBinary(t, paths, "v1.64.8")
+
+	ok, err := CheckAvailable(context.Background(), paths, "v1.64.8")
+	if err != nil || !ok {
+		t.Fatalf("expected installed binary to be available, got ok=%v err=%v", ok, err)
+	}
+}
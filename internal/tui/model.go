@@ -3,23 +3,50 @@ package tui
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mrtuuro/go-switcher/internal/httpclient"
 	"github.com/mrtuuro/go-switcher/internal/progress"
+	"github.com/mrtuuro/go-switcher/internal/releases"
 	"github.com/mrtuuro/go-switcher/internal/switcher"
 )
 
+// ReadOnlyEnv starts the TUI in read-only mode when set to a true value.
+const ReadOnlyEnv = "GOSWITCHER_TUI_READONLY"
+
 type Service interface {
-	ListLocal() ([]string, error)
-	ListRemote(context.Context) ([]string, error)
+	ListLocalCtx(context.Context) ([]string, error)
+	ListRemoteListingWithOptions(context.Context, releases.FetchOptions) (releases.Listing, error)
 	Current(cwd string) (switcher.ActiveVersion, error)
 	InstallWithProgress(context.Context, string, progress.Reporter) (string, error)
 	UseWithProgress(context.Context, string, switcher.Scope, string, progress.Reporter) (string, string, error)
 	DeleteInstalledWithProgress(context.Context, string, string, progress.Reporter) (switcher.DeleteResult, error)
+	PreferredScope() (switcher.Scope, bool, error)
+	RememberScope(switcher.Scope) error
+	PruneStorage(ctx context.Context, cwd string, dryRun bool) (PruneSummary, error)
+}
+
+// PruneSummary counts what a storage prune removed, or would remove for a
+// dry run.
+type PruneSummary struct {
+	BrokenToolchains int
+	CacheEntries     int
+	BytesReclaimed   int64
+}
+
+func (s PruneSummary) empty() bool {
+	return s.BrokenToolchains == 0 && s.CacheEntries == 0
+}
+
+func (s PruneSummary) String() string {
+	return fmt.Sprintf("%d broken toolchains, %d cache entries (%s)", s.BrokenToolchains, s.CacheEntries, progress.FormatBytes(s.BytesReclaimed))
 }
 
 type listMode int
@@ -46,30 +73,84 @@ type model struct {
 
 	localVersions  []string
 	remoteVersions []string
-	activeVersion  string
-	activeScope    switcher.Scope
+	// remoteFetchedAt is when the release data behind remoteVersions was
+	// fetched, shown as the cache age in remote mode.
+	remoteFetchedAt time.Time
+	activeVersion   string
+	activeScope     switcher.Scope
+	activeSource    switcher.SourceKind
 
 	busy         bool
 	status       string
 	lastError    string
+	warning      string // non-fatal problem of the last operation
 	spinner      spinner.Model
 	hasRemoteHit bool
 	progressCh   <-chan progress.Event
 	doneCh       <-chan tea.Msg
 
+	// transfer tracks the byte-level download in progress, rendered on its
+	// own line below the status. It is nil when no transfer is active.
+	transfer *transferState
+
+	// fetchCancel is set while a remote fetch is in flight; fetchID lets
+	// results from cancelled fetches be discarded.
+	fetchCancel context.CancelFunc
+	fetchID     int
+
 	scopeInitialized bool
+
+	// readOnly disables install, delete, use and scope changes.
+	readOnly bool
+
+	// selectMode turns Enter into picking the version under the cursor,
+	// which ends the TUI with picked set; mutating actions are disabled.
+	selectMode bool
+	picked     string
+
+	// selected holds versions marked with space for a batch delete (local
+	// mode) or install (remote mode). It is cleared when the mode changes.
+	selected map[string]bool
+
+	// pruneConfirm is set after a prune dry run found something to remove;
+	// the next key either confirms the prune with y or cancels it.
+	pruneConfirm bool
+
+	// startCmd is returned from Init, e.g. to fetch remote versions when the
+	// TUI is launched in remote mode.
+	startCmd tea.Cmd
+}
+
+// Options seeds the initial TUI state. Zero values keep the defaults.
+type Options struct {
+	Remote   bool
+	Scope    switcher.Scope
+	Search   string
+	ReadOnly bool
 }
 
 type versionsMsg struct {
 	mode     listMode
 	versions []string
 	err      error
+	fetchID  int
+	// fetchedAt is when remote release data was fetched; it can be in the
+	// past when served from a cache.
+	fetchedAt time.Time
 }
 
 type currentMsg struct {
 	version string
 	scope   switcher.Scope
+	source  switcher.SourceKind
 	err     error
+
+	preferredScope switcher.Scope
+	hasPreferred   bool
+}
+
+type scopeSavedMsg struct {
+	err error
 }
 
 type installDoneMsg struct {
@@ -88,6 +169,13 @@ type progressMsg struct {
 	event progress.Event
 }
 
+type transferState struct {
+	stage   string
+	current int64
+	total   int64
+	meter   progress.RateMeter
+}
+
 type asyncClosedMsg struct{}
 
 type deleteDoneMsg struct {
@@ -95,13 +183,78 @@ type deleteDoneMsg struct {
 	err    error
 }
 
+type pruneDoneMsg struct {
+	summary PruneSummary
+	dryRun  bool
+	err     error
+}
+
+type batchFailure struct {
+	version string
+	err     error
+}
+
+// batchOutcome is what one successful batch step reports besides success:
+// a note for the status line and a non-fatal warning.
+type batchOutcome struct {
+	note    string
+	warning string
+}
+
+type batchDoneMsg struct {
+	action    string
+	succeeded []string
+	failed    []batchFailure
+	notes     []string
+	warnings  []string
+}
+
 func Run(ctx context.Context, svc Service, cwd string) error {
+	return RunWithOptions(ctx, svc, cwd, Options{})
+}
+
+func RunWithOptions(ctx context.Context, svc Service, cwd string, opts Options) error {
 	m := newModel(ctx, svc, cwd)
+	m = m.applyOptions(opts)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	_, err := p.Run()
 	return err
 }
 
+// Select runs the TUI as a picker: Enter returns the version under the
+// cursor instead of switching to it. The TUI is drawn on stderr so stdout can
+// be captured. An empty version means the user quit without picking.
+func Select(ctx context.Context, svc Service, cwd string, opts Options) (string, error) {
+	m := newModel(ctx, svc, cwd)
+	m = m.applyOptions(opts)
+	m.selectMode = true
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(os.Stderr))
+	final, err := p.Run()
+	if err != nil {
+		return "", err
+	}
+	return final.(model).picked, nil
+}
+
+// ReadOnlyRequested reports whether ReadOnlyEnv is set to a true value.
+func ReadOnlyRequested() bool {
+	return httpclient.EnvEnabled(ReadOnlyEnv)
+}
+
+func (m model) applyOptions(opts Options) model {
+	if opts.Scope != "" {
+		m.scope = opts.Scope
+		m.scopeInitialized = true
+	}
+	m.searchQuery = strings.TrimSpace(opts.Search)
+	m.readOnly = opts.ReadOnly
+	if opts.Remote {
+		m.mode = modeRemote
+		m, m.startCmd = m.startRemoteFetch("Loading remote versions... (Esc to cancel)", releases.FetchOptions{})
+	}
+	return m
+}
+
 func newModel(ctx context.Context, svc Service, cwd string) model {
 	spin := spinner.New()
 	spin.Spinner = spinner.MiniDot
@@ -126,6 +279,7 @@ func (m model) Init() tea.Cmd {
 		m.spinner.Tick,
 		m.loadLocalCmd(),
 		m.loadCurrentCmd(),
+		m.startCmd,
 	)
 }
 
@@ -146,7 +300,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 		}
 	case progressMsg:
-		if typed.event.Message != "" {
+		m.trackTransfer(typed.event)
+		switch {
+		case typed.event.Level == progress.LevelWarning:
+			m.warning = typed.event.Message
+		case typed.event.Message != "" && (typed.event.Current <= 0 || typed.event.Unit != progress.UnitBytes):
 			m.status = typed.event.Message
 		}
 		m.lastError = ""
@@ -157,7 +315,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.busy = false
 		m.progressCh = nil
 		m.doneCh = nil
+		m.transfer = nil
 	case versionsMsg:
+		if typed.mode == modeRemote {
+			if typed.fetchID != m.fetchID {
+				return m, tea.Batch(cmds...)
+			}
+			if m.fetchCancel != nil {
+				m.fetchCancel()
+				m.fetchCancel = nil
+			}
+		}
+		if typed.mode == modeLocal && m.fetchCancel != nil {
+			// A remote fetch is still running; keep its busy state and status.
+			if typed.err == nil {
+				m.localVersions = typed.versions
+			}
+			return m, tea.Batch(cmds...)
+		}
 		m.busy = false
 		if typed.err != nil {
 			m.lastError = typed.err.Error()
@@ -177,6 +352,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.status = fmt.Sprintf("Loaded %d local versions", len(m.localVersions))
 		} else {
 			m.remoteVersions = typed.versions
+			m.remoteFetchedAt = typed.fetchedAt
 			m.hasRemoteHit = true
 			if m.mode == modeRemote {
 				if len(m.remoteVersions) > 0 && m.cursor >= len(m.remoteVersions) {
@@ -192,10 +368,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ensureCursorVisible()
 		m.lastError = ""
 	case currentMsg:
+		if !m.scopeInitialized && typed.hasPreferred {
+			m.scope = typed.preferredScope
+			m.scopeInitialized = true
+		}
 		if typed.err != nil {
 			if typed.err == switcher.ErrNoActiveVersion {
 				m.activeVersion = ""
 				m.activeScope = switcher.ScopeGlobal
+				m.activeSource = ""
 				return m, tea.Batch(cmds...)
 			}
 			m.lastError = typed.err.Error()
@@ -207,6 +388,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.activeVersion = typed.version
 		m.activeScope = typed.scope
+		m.activeSource = typed.source
+	case scopeSavedMsg:
+		if typed.err != nil {
+			m.lastError = "Remember scope: " + typed.err.Error()
+		}
 	case installDoneMsg:
 		m.busy = false
 		m.progressCh = nil
@@ -233,12 +419,40 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.activeVersion = typed.active.Version
 		m.activeScope = typed.active.Scope
+		m.activeSource = typed.active.SourceKind
 		m.lastError = ""
-		if typed.active.Version == typed.version && typed.active.Scope == m.scope {
+		switch {
+		case typed.active.Version == typed.version && typed.active.Scope == m.scope && typed.lintVersion == "":
+			m.status = fmt.Sprintf("Using %s (%s), golangci-lint skipped", typed.active.Version, typed.active.Scope)
+		case typed.active.Version == typed.version && typed.active.Scope == m.scope:
 			m.status = fmt.Sprintf("Using %s (%s), golangci-lint %s", typed.active.Version, typed.active.Scope, typed.lintVersion)
-		} else {
+		default:
 			m.status = fmt.Sprintf("Set %s scope to %s; effective active is %s (%s)", m.scope, typed.version, typed.active.Version, typed.active.Scope)
 		}
+	case batchDoneMsg:
+		m.busy = false
+		m.progressCh = nil
+		m.doneCh = nil
+		m.selected = nil
+		m.status = fmt.Sprintf("%s %d of %d versions", typed.action, len(typed.succeeded), len(typed.succeeded)+len(typed.failed))
+		if len(typed.notes) > 0 {
+			m.status += "; " + strings.Join(typed.notes, "; ")
+		}
+		if len(typed.warnings) > 0 {
+			m.warning = strings.Join(typed.warnings, "; ")
+		}
+		m.lastError = ""
+		if len(typed.failed) > 0 {
+			failures := make([]string, 0, len(typed.failed))
+			for _, failure := range typed.failed {
+				failures = append(failures, fmt.Sprintf("%s: %v", failure.version, failure.err))
+			}
+			m.lastError = fmt.Sprintf("%d failed: %s", len(typed.failed), strings.Join(failures, "; "))
+		}
+		cmds = append(cmds, m.loadLocalCmd(), m.loadCurrentCmd())
+		if m.mode == modeRemote {
+			m.cursor = 0
+		}
 	case deleteDoneMsg:
 		m.busy = false
 		m.progressCh = nil
@@ -251,20 +465,34 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		m.lastError = ""
 		result := typed.result
-		switch {
-		case result.WasActive && result.SwitchedToNewest && result.ActiveAfter.Version != "":
-			m.status = fmt.Sprintf("Deleted %s; switched to %s (%s)", result.DeletedVersion, result.ActiveAfter.Version, result.ActiveAfter.Scope)
-		case result.WasActive && result.ActiveAfter.Version == "":
-			m.status = fmt.Sprintf("Deleted %s; no installed versions remain", result.DeletedVersion)
-		default:
-			m.status = fmt.Sprintf("Deleted %s", result.DeletedVersion)
+		m.status = fmt.Sprintf("Deleted %s", result.DeletedVersion)
+		if change := activeChange(result); change != "" {
+			m.status += "; " + change
 		}
 
 		if result.ToolSyncWarning != "" {
-			m.lastError = "Tool sync warning: " + result.ToolSyncWarning
+			m.warning = "Tool sync warning: " + result.ToolSyncWarning
 		}
 
 		cmds = append(cmds, m.loadLocalCmd(), m.loadCurrentCmd())
+	case pruneDoneMsg:
+		m.busy = false
+		if typed.err != nil {
+			m.lastError = typed.err.Error()
+			m.status = "Prune failed"
+			return m, tea.Batch(cmds...)
+		}
+		m.lastError = ""
+		switch {
+		case typed.summary.empty():
+			m.status = "Nothing to prune"
+		case typed.dryRun:
+			m.pruneConfirm = true
+			m.status = fmt.Sprintf("Prune %s? y to confirm, any other key to cancel", typed.summary)
+		default:
+			m.status = fmt.Sprintf("Pruned %s", typed.summary)
+			cmds = append(cmds, m.loadLocalCmd())
+		}
 	}
 
 	return m, tea.Batch(cmds...)
@@ -272,7 +500,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	key := msg.String()
+	if m.fetchCancel != nil && (key == "esc" || key == "q") {
+		return m.cancelRemoteFetch(), nil
+	}
 	if key == "ctrl+c" || key == "q" {
+		if m.fetchCancel != nil {
+			m.fetchCancel()
+		}
 		return m, tea.Quit
 	}
 
@@ -280,10 +514,28 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.pruneConfirm {
+		m.pruneConfirm = false
+		if key != "y" {
+			m.status = "Prune cancelled"
+			return m, nil
+		}
+		return m.startPrune(false)
+	}
+
 	if updated, handled := m.handleSearchKey(msg); handled {
 		return updated, nil
 	}
 
+	if action, disabled := m.selectModeAction(key); disabled {
+		m.status = fmt.Sprintf("Select mode: %s is disabled", action)
+		return m, nil
+	}
+	if action, disabled := m.readOnlyAction(key); disabled {
+		m.status = fmt.Sprintf("Read-only mode: %s is disabled", action)
+		return m, nil
+	}
+
 	current := m.currentList()
 
 	switch key {
@@ -317,16 +569,47 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.cursor = len(current) - 1
 			m.ensureCursorVisible()
 		}
+	case "a":
+		if m.activeVersion == "" {
+			m.status = "No active version"
+			return m, nil
+		}
+		index := slices.Index(current, m.activeVersion)
+		if index < 0 {
+			m.status = fmt.Sprintf("Active version %s is not in this list", m.activeVersion)
+			return m, nil
+		}
+		m.cursor = index
+		m.ensureCursorVisible()
+	case " ":
+		if m.searchActive {
+			m.status = "Press Enter to apply the search before selecting"
+			return m, nil
+		}
+		if len(current) == 0 {
+			return m, nil
+		}
+		version := current[m.cursor]
+		if m.selected[version] {
+			delete(m.selected, version)
+		} else {
+			if m.selected == nil {
+				m.selected = map[string]bool{}
+			}
+			m.selected[version] = true
+		}
+		m.status = fmt.Sprintf("%d selected", len(m.selected))
 	case "tab":
+		m.selected = nil
 		if m.mode == modeLocal {
 			m.mode = modeRemote
 			m.cursor = 0
 			m.listOffset = 0
 			m.status = "Remote versions"
 			if !m.hasRemoteHit {
-				m.busy = true
-				m.status = "Loading remote versions..."
-				return m, tea.Batch(m.spinner.Tick, m.loadRemoteCmd())
+				var cmd tea.Cmd
+				m, cmd = m.startRemoteFetch("Loading remote versions... (Esc to cancel)", releases.FetchOptions{})
+				return m, tea.Batch(m.spinner.Tick, cmd)
 			}
 		} else {
 			m.mode = modeLocal
@@ -346,29 +629,56 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		m.scopeInitialized = true
 		m.status = fmt.Sprintf("Scope set to %s", m.scope)
+		return m, m.rememberScopeCmd(m.scope)
 	case "r":
 		m.busy = true
 		m.status = "Refreshing information..."
 		if m.mode == modeLocal {
 			return m, tea.Batch(m.spinner.Tick, m.loadLocalCmd(), m.loadCurrentCmd())
 		}
-		return m, tea.Batch(m.spinner.Tick, m.loadRemoteCmd())
+		var cmd tea.Cmd
+		m, cmd = m.startRemoteFetch("Refreshing remote versions... (Esc to cancel)", releases.FetchOptions{Refresh: true})
+		return m, tea.Batch(m.spinner.Tick, cmd)
 	case "x", "X":
 		if m.mode != modeLocal {
 			m.status = "Delete works in local mode only"
 			return m, nil
 		}
+		if len(m.selected) > 0 {
+			return m.startBatch("Deleted", m.selectedVersions(), func(version string, reporter progress.Reporter) (batchOutcome, error) {
+				result, err := m.svc.DeleteInstalledWithProgress(m.ctx, m.cwd, version, reporter)
+				if err != nil {
+					return batchOutcome{}, err
+				}
+				outcome := batchOutcome{}
+				if change := activeChange(result); change != "" {
+					outcome.note = fmt.Sprintf("%s was active, %s", version, change)
+				}
+				if result.ToolSyncWarning != "" {
+					outcome.warning = "Tool sync warning: " + result.ToolSyncWarning
+				}
+				return outcome, nil
+			})
+		}
 		if len(current) == 0 {
 			m.status = "No installed version selected"
 			return m, nil
 		}
 		version := current[m.cursor]
 		return m.startDelete(version)
+	case "P":
+		return m.startPrune(true)
 	case "i":
 		if m.mode != modeRemote {
 			m.status = "Switch to remote mode (Tab) to install"
 			return m, nil
 		}
+		if len(m.selected) > 0 {
+			return m.startBatch("Installed", m.selectedVersions(), func(version string, reporter progress.Reporter) (batchOutcome, error) {
+				_, err := m.svc.InstallWithProgress(m.ctx, version, reporter)
+				return batchOutcome{}, err
+			})
+		}
 		if len(current) == 0 {
 			m.status = "No remote version selected"
 			return m, nil
@@ -381,12 +691,56 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		version := current[m.cursor]
+		if m.selectMode {
+			m.picked = version
+			return m, tea.Quit
+		}
 		return m.startUse(version)
 	}
 
 	return m, nil
 }
 
+// readOnlyAction names the action behind key when read-only mode disables
+// it. Enter picks rather than uses in select mode, so it stays available.
+func (m model) readOnlyAction(key string) (string, bool) {
+	if !m.readOnly {
+		return "", false
+	}
+	if key == "enter" {
+		return "use", !m.selectMode
+	}
+	return mutatingAction(key)
+}
+
+// selectModeAction names the action behind key when select mode disables it.
+// Enter stays available for picking.
+func (m model) selectModeAction(key string) (string, bool) {
+	if !m.selectMode {
+		return "", false
+	}
+	return mutatingAction(key)
+}
+
+// mutatingAction names the action behind key if it changes installed
+// versions, the scope or the selection marks that feed batch actions.
+func mutatingAction(key string) (string, bool) {
+	switch key {
+	case "i":
+		return "install", true
+	case "x", "X":
+		return "delete", true
+	case "P":
+		return "prune", true
+	case " ":
+		return "selection", true
+	case "s":
+		return "scope change", true
+	default:
+		return "", false
+	}
+}
+
 func (m model) handleSearchKey(msg tea.KeyMsg) (model, bool) {
 	key := msg.String()
 
@@ -481,25 +835,73 @@ func (m model) searchStatusText() string {
 
 func (m model) loadLocalCmd() tea.Cmd {
 	return func() tea.Msg {
-		versions, err := m.svc.ListLocal()
+		versions, err := m.svc.ListLocalCtx(m.ctx)
 		return versionsMsg{mode: modeLocal, versions: versions, err: err}
 	}
 }
 
-func (m model) loadRemoteCmd() tea.Cmd {
+func (m model) startRemoteFetch(status string, opts releases.FetchOptions) (model, tea.Cmd) {
+	if m.fetchCancel != nil {
+		m.fetchCancel()
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.fetchID++
+	m.fetchCancel = cancel
+	m.busy = true
+	m.status = status
+	return m, m.loadRemoteCmd(ctx, m.fetchID, opts)
+}
+
+func (m model) cancelRemoteFetch() model {
+	m.fetchCancel()
+	m.fetchCancel = nil
+	m.fetchID++
+	m.busy = false
+	if !m.hasRemoteHit {
+		m.mode = modeLocal
+		m.cursor = 0
+		m.listOffset = 0
+		m.ensureCursorVisible()
+	}
+	m.status = "Remote fetch cancelled"
+	return m
+}
+
+func (m model) loadRemoteCmd(ctx context.Context, fetchID int, opts releases.FetchOptions) tea.Cmd {
 	return func() tea.Msg {
-		versions, err := m.svc.ListRemote(m.ctx)
-		return versionsMsg{mode: modeRemote, versions: versions, err: err}
+		listing, err := m.svc.ListRemoteListingWithOptions(ctx, opts)
+		return versionsMsg{mode: modeRemote, versions: listing.Versions, err: err, fetchID: fetchID, fetchedAt: listing.FetchedAt}
 	}
 }
 
 func (m model) loadCurrentCmd() tea.Cmd {
+	initialized := m.scopeInitialized
 	return func() tea.Msg {
+		msg := currentMsg{}
+		if !initialized {
+			preferred, ok, err := m.svc.PreferredScope()
+			if err == nil && ok {
+				msg.preferredScope = preferred
+				msg.hasPreferred = true
+			}
+		}
+
 		active, err := m.svc.Current(m.cwd)
 		if err != nil {
-			return currentMsg{err: err}
+			msg.err = err
+			return msg
 		}
-		return currentMsg{version: active.Version, scope: active.Scope}
+		msg.version = active.Version
+		msg.scope = active.Scope
+		msg.source = active.SourceKind
+		return msg
+	}
+}
+
+func (m model) rememberScopeCmd(scope switcher.Scope) tea.Cmd {
+	return func() tea.Msg {
+		return scopeSavedMsg{err: m.svc.RememberScope(scope)}
 	}
 }
 
@@ -523,6 +925,7 @@ func (m model) startInstall(version string) (tea.Model, tea.Cmd) {
 
 	m.busy = true
 	m.lastError = ""
+	m.warning = ""
 	m.status = fmt.Sprintf("Starting installation for %s...", version)
 	m.progressCh = progressCh
 	m.doneCh = doneCh
@@ -564,6 +967,7 @@ func (m model) startUse(version string) (tea.Model, tea.Cmd) {
 
 	m.busy = true
 	m.lastError = ""
+	m.warning = ""
 	m.status = fmt.Sprintf("Switching to %s (%s)...", version, m.scope)
 	m.progressCh = progressCh
 	m.doneCh = doneCh
@@ -591,6 +995,7 @@ func (m model) startDelete(version string) (tea.Model, tea.Cmd) {
 
 	m.busy = true
 	m.lastError = ""
+	m.warning = ""
 	m.status = fmt.Sprintf("Deleting %s...", version)
 	m.progressCh = progressCh
 	m.doneCh = doneCh
@@ -598,6 +1003,97 @@ func (m model) startDelete(version string) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(m.spinner.Tick, m.waitAsyncCmd())
 }
 
+// startPrune removes cached archives no installed toolchain needs and broken
+// toolchain directories. A dry run only reports them, so the user can confirm.
+func (m model) startPrune(dryRun bool) (tea.Model, tea.Cmd) {
+	m.busy = true
+	m.lastError = ""
+	m.warning = ""
+	m.status = "Looking for unused storage..."
+	if !dryRun {
+		m.status = "Pruning unused storage..."
+	}
+	prune := func() tea.Msg {
+		summary, err := m.svc.PruneStorage(m.ctx, m.cwd, dryRun)
+		return pruneDoneMsg{summary: summary, dryRun: dryRun, err: err}
+	}
+	return m, tea.Batch(m.spinner.Tick, prune)
+}
+
+// activeChange describes how deleting a version changed the active version,
+// or returns "" when the deleted version was not active.
+func activeChange(result switcher.DeleteResult) string {
+	switch {
+	case result.WasActive && result.SwitchedToNewest && result.ActiveAfter.Version != "":
+		return fmt.Sprintf("switched to %s (%s)", result.ActiveAfter.Version, result.ActiveAfter.Scope)
+	case result.WasActive && result.ActiveAfter.Version == "":
+		return "no installed versions remain"
+	default:
+		return ""
+	}
+}
+
+// selectedVersions returns the selection in list order.
+func (m model) selectedVersions() []string {
+	versions := make([]string, 0, len(m.selected))
+	for _, version := range m.unfilteredList() {
+		if m.selected[version] {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// startBatch runs action for each version in turn on the async progress
+// channels, prefixing progress with the batch position. Failures do not stop
+// the batch; they are aggregated into the batchDoneMsg along with the notes
+// and warnings of the successful steps.
+func (m model) startBatch(verb string, versions []string, action func(string, progress.Reporter) (batchOutcome, error)) (tea.Model, tea.Cmd) {
+	progressCh := make(chan progress.Event, 128)
+	doneCh := make(chan tea.Msg, 1)
+
+	go func() {
+		done := batchDoneMsg{action: verb}
+		for i, version := range versions {
+			prefix := fmt.Sprintf("[%d/%d] ", i+1, len(versions))
+			reporter := func(event progress.Event) {
+				if event.Message != "" {
+					event.Message = prefix + event.Message
+				}
+				select {
+				case progressCh <- event:
+				default:
+				}
+			}
+
+			outcome, err := action(version, reporter)
+			if err != nil {
+				done.failed = append(done.failed, batchFailure{version: version, err: err})
+				continue
+			}
+			done.succeeded = append(done.succeeded, version)
+			if outcome.note != "" {
+				done.notes = append(done.notes, outcome.note)
+			}
+			if outcome.warning != "" {
+				done.warnings = append(done.warnings, outcome.warning)
+			}
+		}
+		close(progressCh)
+		doneCh <- done
+		close(doneCh)
+	}()
+
+	m.busy = true
+	m.lastError = ""
+	m.warning = ""
+	m.status = fmt.Sprintf("Processing %d selected versions...", len(versions))
+	m.progressCh = progressCh
+	m.doneCh = doneCh
+
+	return m, tea.Batch(m.spinner.Tick, m.waitAsyncCmd())
+}
+
 func (m model) waitAsyncCmd() tea.Cmd {
 	progressCh := m.progressCh
 	doneCh := m.doneCh
@@ -673,21 +1169,43 @@ func (m model) View() string {
 	cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("69")).Bold(true)
 	activeCursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Bold(true).Underline(true)
 	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 
 	currentMode := "Local"
 	if m.mode == modeRemote {
 		currentMode = "Remote"
+		if age := cacheAge(m.remoteFetchedAt, time.Now()); age != "" {
+			currentMode += fmt.Sprintf(" (cached %s ago; r to refresh)", age)
+		}
 	}
 
 	header := titleStyle.Render("Go Switcher")
+	switch {
+	case m.selectMode:
+		header += " " + subtleStyle.Render("[select mode]")
+	case m.readOnly:
+		header += " " + errorStyle.Render("[read-only mode]")
+	}
 	header += "\n"
-	header += subtleStyle.Render("Tab: local/remote  /:search  Enter: use  i:install(remote)  X:delete(local)  s:scope  r:refresh  Esc:clear search  q:quit")
+	if m.selectMode {
+		header += subtleStyle.Render("Tab: local/remote  /:search  a:active  Enter: select  r:refresh  Esc:clear search  q:quit")
+	} else if m.readOnly {
+		header += subtleStyle.Render("Tab: local/remote  /:search  a:active  r:refresh  Esc:clear search  q:quit")
+	} else {
+		header += subtleStyle.Render("Tab: local/remote  /:search  a:active  Enter: use  Space:select  i:install(remote)  X:delete(local)  P:prune  s:scope  r:refresh  Esc:clear search  q:quit")
+	}
 
 	active := "none"
 	if m.activeVersion != "" {
 		active = fmt.Sprintf("%s (%s)", m.activeVersion, m.activeScope)
+		if m.activeSource == switcher.SourceGoMod {
+			active = fmt.Sprintf("%s (%s, from go.mod)", m.activeVersion, m.activeScope)
+		}
 	}
 	meta := fmt.Sprintf("Mode: %s  Scope: %s  Active: %s", currentMode, m.scope, active)
+	if len(m.selected) > 0 {
+		meta += fmt.Sprintf("  Selected: %d (i/X to act on all)", len(m.selected))
+	}
 	if m.activeScope == switcher.ScopeLocal && m.scope == switcher.ScopeGlobal {
 		meta += "\n" + subtleStyle.Render("Local override is active; switching global will not change effective active version here")
 	}
@@ -730,6 +1248,13 @@ func (m model) View() string {
 		if isCursor {
 			prefix = "> "
 		}
+		if len(m.selected) > 0 {
+			if m.selected[version] {
+				prefix += "[x] "
+			} else {
+				prefix += "[ ] "
+			}
+		}
 		line := prefix + version
 		if isActive {
 			line += "  [active]"
@@ -764,6 +1289,12 @@ func (m model) View() string {
 	}
 
 	footer := status
+	if m.transfer != nil {
+		footer += "\n" + subtleStyle.Render(m.transferLine())
+	}
+	if m.warning != "" {
+		footer += "\n" + warningStyle.Render(m.warning)
+	}
 	if m.lastError != "" {
 		footer += "\n" + errorStyle.Render(m.lastError)
 	}
@@ -771,6 +1302,55 @@ func (m model) View() string {
 	return fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s", header, meta, body, footer)
 }
 
+// trackTransfer updates the transfer line from byte-level events. Any other
+// event for a new stage, or a completed transfer, clears it. File counts,
+// such as extraction progress, are shown in the status line instead.
+func (m *model) trackTransfer(event progress.Event) {
+	if event.Current <= 0 || event.Unit != progress.UnitBytes {
+		if m.transfer != nil && event.Stage != m.transfer.stage {
+			m.transfer = nil
+		}
+		return
+	}
+	if m.transfer == nil || m.transfer.stage != event.Stage || event.Current < m.transfer.current {
+		m.transfer = &transferState{stage: event.Stage}
+	}
+	m.transfer.current = event.Current
+	m.transfer.total = event.Total
+	m.transfer.meter.Observe(event.Current, time.Now())
+	if event.Total > 0 && event.Current >= event.Total {
+		m.transfer = nil
+	}
+}
+
+// transferLine renders a progress bar sized to the terminal width followed
+// by the transfer size, rate and ETA. It is redrawn on every spinner tick,
+// so the rate of a stalled download keeps falling and its ETA growing.
+func (m model) transferLine() string {
+	rate := m.transfer.meter.Rate(time.Now())
+	text := progress.FormatTransferRate(m.transfer.current, m.transfer.total, rate)
+	if m.transfer.total <= 0 {
+		return text
+	}
+
+	barWidth := 30
+	if m.width > 0 {
+		barWidth = m.width - utf8.RuneCountInString(text) - 3
+	}
+	if barWidth > 40 {
+		barWidth = 40
+	}
+	if barWidth < 10 {
+		return text
+	}
+
+	filled := int(float64(barWidth) * float64(m.transfer.current) / float64(m.transfer.total))
+	if filled > barWidth {
+		filled = barWidth
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", barWidth-filled) + "] " + text
+}
+
 func (m *model) pageSize() int {
 	if m.height <= 0 {
 		return 15
@@ -780,6 +1360,9 @@ func (m *model) pageSize() int {
 	if m.lastError != "" {
 		reserved++
 	}
+	if m.transfer != nil {
+		reserved++
+	}
 
 	size := m.height - reserved
 	if size < 5 {
@@ -861,3 +1444,22 @@ func (m model) visibleRange(pageSize int, total int) (int, int) {
 
 	return start, end
 }
+
+// cacheAge formats how long ago fetchedAt was, or returns "" when the data is
+// less than a minute old and worth no mention.
+func cacheAge(fetchedAt time.Time, now time.Time) string {
+	if fetchedAt.IsZero() {
+		return ""
+	}
+	age := now.Sub(fetchedAt)
+	switch {
+	case age < time.Minute:
+		return ""
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age/time.Minute))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	}
+}
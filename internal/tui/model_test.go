@@ -0,0 +1,706 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mrtuuro/go-switcher/internal/progress"
+	"github.com/mrtuuro/go-switcher/internal/releases"
+	"github.com/mrtuuro/go-switcher/internal/switcher"
+)
+
+type fakeService struct {
+	local      []string
+	remote     []string
+	active     switcher.ActiveVersion
+	prune      PruneSummary
+	pruneErr   error
+	pruneCalls []bool
+
+	deleteResults map[string]switcher.DeleteResult
+	deleteErrs    map[string]error
+	deleted       []string
+
+	preferred   switcher.Scope
+	rememberErr error
+	remembered  []switcher.Scope
+}
+
+func (f *fakeService) ListLocalCtx(context.Context) ([]string, error) {
+	return f.local, nil
+}
+
+func (f *fakeService) ListRemoteListingWithOptions(ctx context.Context, _ releases.FetchOptions) (releases.Listing, error) {
+	if err := ctx.Err(); err != nil {
+		return releases.Listing{}, err
+	}
+	return releases.Listing{Versions: f.remote}, nil
+}
+
+func (f *fakeService) Current(string) (switcher.ActiveVersion, error) {
+	if f.active.Version == "" {
+		return switcher.ActiveVersion{}, switcher.ErrNoActiveVersion
+	}
+	return f.active, nil
+}
+
+func (f *fakeService) InstallWithProgress(_ context.Context, version string, _ progress.Reporter) (string, error) {
+	return version, nil
+}
+
+func (f *fakeService) UseWithProgress(_ context.Context, version string, _ switcher.Scope, _ string, _ progress.Reporter) (string, string, error) {
+	return version, "", nil
+}
+
+func (f *fakeService) DeleteInstalledWithProgress(_ context.Context, _ string, version string, _ progress.Reporter) (switcher.DeleteResult, error) {
+	if err := f.deleteErrs[version]; err != nil {
+		return switcher.DeleteResult{}, err
+	}
+	f.deleted = append(f.deleted, version)
+	if result, ok := f.deleteResults[version]; ok {
+		return result, nil
+	}
+	return switcher.DeleteResult{DeletedVersion: version}, nil
+}
+
+func (f *fakeService) PreferredScope() (switcher.Scope, bool, error) {
+	return f.preferred, f.preferred != "", nil
+}
+
+func (f *fakeService) RememberScope(scope switcher.Scope) error {
+	f.remembered = append(f.remembered, scope)
+	return f.rememberErr
+}
+
+func (f *fakeService) PruneStorage(_ context.Context, _ string, dryRun bool) (PruneSummary, error) {
+	f.pruneCalls = append(f.pruneCalls, dryRun)
+	return f.prune, f.pruneErr
+}
+
+// testModel returns a local-mode model that has finished loading versions.
+func testModel(svc *fakeService) model {
+	m := newModel(context.Background(), svc, "/work")
+	m.busy = false
+	m.localVersions = svc.local
+	m.activeVersion = svc.active.Version
+	return m
+}
+
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	default:
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+}
+
+func pressKey(t *testing.T, m model, key string) (model, tea.Cmd) {
+	t.Helper()
+	updated, cmd := m.Update(keyMsg(key))
+	return updated.(model), cmd
+}
+
+// runCmd runs cmd, including batched commands, and feeds the first message
+// of type T back into the model.
+func runCmd[T tea.Msg](t *testing.T, m model, cmd tea.Cmd) model {
+	t.Helper()
+	updated, _ := m.Update(cmdMsg[T](t, cmd))
+	return updated.(model)
+}
+
+// cmdMsg runs cmd, including batched commands, and returns the first message
+// of type T.
+func cmdMsg[T tea.Msg](t *testing.T, cmd tea.Cmd) T {
+	t.Helper()
+	if cmd == nil {
+		t.Fatalf("expected a command")
+	}
+	msgs := []tea.Msg{cmd()}
+	for len(msgs) > 0 {
+		msg := msgs[0]
+		msgs = msgs[1:]
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for _, sub := range batch {
+				if sub != nil {
+					msgs = append(msgs, sub())
+				}
+			}
+			continue
+		}
+		if typed, ok := msg.(T); ok {
+			return typed
+		}
+	}
+	var zero T
+	t.Fatalf("command did not produce %T", zero)
+	return zero
+}
+
+// finishAsync waits for the async operation started by the last key and feeds
+// its result back into the model.
+func finishAsync(t *testing.T, m model) model {
+	t.Helper()
+	if m.doneCh == nil {
+		t.Fatalf("expected an async operation, status %q", m.status)
+	}
+	msg, ok := <-m.doneCh
+	if !ok {
+		t.Fatalf("async operation closed without a result")
+	}
+	updated, _ := m.Update(msg)
+	return updated.(model)
+}
+
+func TestSelection_SpaceTogglesMarks(t *testing.T) {
+	t.Parallel()
+
+	m := testModel(&fakeService{local: []string{"go1.21.0", "go1.22.0", "go1.23.0"}})
+
+	m, _ = pressKey(t, m, " ")
+	m, _ = pressKey(t, m, "j")
+	m, _ = pressKey(t, m, " ")
+	if !m.selected["go1.21.0"] || !m.selected["go1.22.0"] || m.status != "2 selected" {
+		t.Fatalf("expected two marked versions, got %v (status %q)", m.selected, m.status)
+	}
+
+	m, _ = pressKey(t, m, " ")
+	if m.selected["go1.22.0"] || len(m.selected) != 1 || m.status != "1 selected" {
+		t.Fatalf("expected space to unmark go1.22.0, got %v (status %q)", m.selected, m.status)
+	}
+	if !strings.Contains(m.View(), "[x] go1.21.0") || !strings.Contains(m.View(), "[ ] go1.22.0") {
+		t.Fatalf("expected checkmarks in view:\n%s", m.View())
+	}
+
+	m, _ = pressKey(t, m, "tab")
+	if m.selected != nil {
+		t.Fatalf("expected marks to clear on mode change, got %v", m.selected)
+	}
+}
+
+func TestSelection_DisabledWhileTypingSearch(t *testing.T) {
+	t.Parallel()
+
+	m := testModel(&fakeService{local: []string{"go1.21.0"}})
+	m.searchActive = true
+
+	m, _ = pressKey(t, m, " ")
+	if len(m.selected) != 0 {
+		t.Fatalf("expected no selection while typing a search, got %v", m.selected)
+	}
+}
+
+func TestBatchDelete_ReportsResults(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		results     map[string]switcher.DeleteResult
+		errs        map[string]error
+		wantStatus  string
+		wantWarning string
+		wantError   string
+	}{
+		{
+			name:       "plain deletes",
+			wantStatus: "Deleted 2 of 2 versions",
+		},
+		{
+			name: "active version switched",
+			results: map[string]switcher.DeleteResult{
+				"go1.22.0": {DeletedVersion: "go1.22.0", WasActive: true, SwitchedToNewest: true, ActiveAfter: switcher.ActiveVersion{Version: "go1.23.0", Scope: switcher.ScopeGlobal}, ToolSyncWarning: "lint missing"},
+			},
+			wantStatus:  "Deleted 2 of 2 versions; go1.22.0 was active, switched to go1.23.0 (global)",
+			wantWarning: "Tool sync warning: lint missing",
+		},
+		{
+			name: "last active version removed",
+			results: map[string]switcher.DeleteResult{
+				"go1.21.0": {DeletedVersion: "go1.21.0", WasActive: true},
+			},
+			wantStatus: "Deleted 2 of 2 versions; go1.21.0 was active, no installed versions remain",
+		},
+		{
+			name:       "failure is aggregated",
+			errs:       map[string]error{"go1.21.0": errors.New("permission denied")},
+			wantStatus: "Deleted 1 of 2 versions",
+			wantError:  "1 failed: go1.21.0: permission denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeService{local: []string{"go1.21.0", "go1.22.0", "go1.23.0"}, deleteResults: tt.results, deleteErrs: tt.errs}
+			m := testModel(svc)
+			m, _ = pressKey(t, m, " ")
+			m, _ = pressKey(t, m, "j")
+			m, _ = pressKey(t, m, " ")
+
+			m, _ = pressKey(t, m, "X")
+			m = finishAsync(t, m)
+
+			if m.status != tt.wantStatus {
+				t.Fatalf("expected status %q, got %q", tt.wantStatus, m.status)
+			}
+			if m.warning != tt.wantWarning {
+				t.Fatalf("expected warning %q, got %q", tt.wantWarning, m.warning)
+			}
+			if m.lastError != tt.wantError {
+				t.Fatalf("expected error %q, got %q", tt.wantError, m.lastError)
+			}
+			if m.selected != nil {
+				t.Fatalf("expected marks to clear after the batch, got %v", m.selected)
+			}
+		})
+	}
+}
+
+func TestPrune_DryRunThenConfirm(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeService{local: []string{"go1.22.1"}, prune: PruneSummary{BrokenToolchains: 1, CacheEntries: 2, BytesReclaimed: 2048}}
+	m := testModel(svc)
+
+	m, cmd := pressKey(t, m, "P")
+	m = runCmd[pruneDoneMsg](t, m, cmd)
+	if !m.pruneConfirm {
+		t.Fatalf("expected prune confirmation prompt, status %q", m.status)
+	}
+	if !strings.Contains(m.status, "1 broken toolchains, 2 cache entries") || !strings.Contains(m.status, "y to confirm") {
+		t.Fatalf("unexpected status %q", m.status)
+	}
+
+	m, cmd = pressKey(t, m, "y")
+	m = runCmd[pruneDoneMsg](t, m, cmd)
+	if m.pruneConfirm || !strings.HasPrefix(m.status, "Pruned ") {
+		t.Fatalf("expected prune to finish, status %q", m.status)
+	}
+	if len(svc.pruneCalls) != 2 || !svc.pruneCalls[0] || svc.pruneCalls[1] {
+		t.Fatalf("expected a dry run then a real prune, got %v", svc.pruneCalls)
+	}
+}
+
+func TestPrune_Outcomes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		summary    PruneSummary
+		err        error
+		confirmKey string
+		wantStatus string
+		wantCalls  int
+	}{
+		{name: "nothing to prune", wantStatus: "Nothing to prune", wantCalls: 1},
+		{name: "dry run fails", err: errors.New("boom"), wantStatus: "Prune failed", wantCalls: 1},
+		{name: "other key cancels", summary: PruneSummary{CacheEntries: 1}, confirmKey: "n", wantStatus: "Prune cancelled", wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeService{prune: tt.summary, pruneErr: tt.err}
+			m, cmd := pressKey(t, testModel(svc), "P")
+			m = runCmd[pruneDoneMsg](t, m, cmd)
+			if tt.confirmKey != "" {
+				m, cmd = pressKey(t, m, tt.confirmKey)
+				if cmd != nil {
+					t.Fatalf("expected no command after cancelling")
+				}
+			}
+			if m.status != tt.wantStatus {
+				t.Fatalf("expected status %q, got %q", tt.wantStatus, m.status)
+			}
+			if m.pruneConfirm {
+				t.Fatalf("expected no pending confirmation")
+			}
+			if len(svc.pruneCalls) != tt.wantCalls {
+				t.Fatalf("expected %d prune calls, got %d", tt.wantCalls, len(svc.pruneCalls))
+			}
+		})
+	}
+}
+
+func TestPrune_DisabledInReadOnlyMode(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeService{prune: PruneSummary{CacheEntries: 1}}
+	m := testModel(svc)
+	m.readOnly = true
+
+	m, cmd := pressKey(t, m, "P")
+	if cmd != nil || len(svc.pruneCalls) != 0 {
+		t.Fatalf("expected prune to be disabled, got %d calls", len(svc.pruneCalls))
+	}
+	if m.status != "Read-only mode: prune is disabled" {
+		t.Fatalf("unexpected status %q", m.status)
+	}
+}
+
+func TestReadOnly_GatesMutatingKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key        string
+		wantStatus string
+	}{
+		{key: "i", wantStatus: "Read-only mode: install is disabled"},
+		{key: "x", wantStatus: "Read-only mode: delete is disabled"},
+		{key: "X", wantStatus: "Read-only mode: delete is disabled"},
+		{key: "P", wantStatus: "Read-only mode: prune is disabled"},
+		{key: "enter", wantStatus: "Read-only mode: use is disabled"},
+		{key: " ", wantStatus: "Read-only mode: selection is disabled"},
+		{key: "s", wantStatus: "Read-only mode: scope change is disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeService{local: []string{"go1.21.0", "go1.22.0"}}
+			m := testModel(svc)
+			m.readOnly = true
+
+			m, cmd := pressKey(t, m, tt.key)
+			if cmd != nil {
+				t.Fatalf("expected no command for %q", tt.key)
+			}
+			if m.status != tt.wantStatus {
+				t.Fatalf("expected status %q, got %q", tt.wantStatus, m.status)
+			}
+			if m.busy || len(m.selected) != 0 || m.scope != switcher.ScopeGlobal || len(svc.deleted) != 0 {
+				t.Fatalf("expected %q to leave the model unchanged", tt.key)
+			}
+		})
+	}
+}
+
+func TestReadOnly_KeepsNavigationAndSearch(t *testing.T) {
+	t.Parallel()
+
+	m := testModel(&fakeService{local: []string{"go1.21.0", "go1.22.0"}})
+	m.readOnly = true
+
+	m, _ = pressKey(t, m, "j")
+	if m.cursor != 1 {
+		t.Fatalf("expected cursor to move, got %d", m.cursor)
+	}
+	m, _ = pressKey(t, m, "/")
+	m, _ = pressKey(t, m, "22")
+	if !m.searchActive || m.searchQuery != "22" {
+		t.Fatalf("expected search to work, got active=%v query=%q", m.searchActive, m.searchQuery)
+	}
+	if got := m.currentList(); len(got) != 1 || got[0] != "go1.22.0" {
+		t.Fatalf("expected filtered list [go1.22.0], got %v", got)
+	}
+	if !strings.Contains(m.View(), "[read-only mode]") {
+		t.Fatalf("expected read-only note in header:\n%s", m.View())
+	}
+}
+
+func TestReadOnlyRequested(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "", want: false},
+		{value: "1", want: true},
+		{value: " TRUE ", want: true},
+		{value: "on", want: true},
+		{value: "0", want: false},
+		{value: "no", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(ReadOnlyEnv, tt.value)
+			if got := ReadOnlyRequested(); got != tt.want {
+				t.Fatalf("ReadOnlyRequested() with %q = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectMode_EnterPicksVersion(t *testing.T) {
+	t.Parallel()
+
+	m := testModel(&fakeService{local: []string{"go1.21.0", "go1.22.0"}})
+	m.selectMode = true
+	m.readOnly = true
+
+	m, _ = pressKey(t, m, "j")
+	m, cmd := pressKey(t, m, "enter")
+	if m.picked != "go1.22.0" {
+		t.Fatalf("expected go1.22.0 to be picked, got %q", m.picked)
+	}
+	if cmd == nil {
+		t.Fatalf("expected a quit command")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatalf("expected Enter to quit the TUI")
+	}
+	if m.busy {
+		t.Fatalf("expected Enter not to start a switch")
+	}
+}
+
+func TestSelectMode_BlocksMutatingKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key        string
+		wantStatus string
+	}{
+		{key: "i", wantStatus: "Select mode: install is disabled"},
+		{key: "X", wantStatus: "Select mode: delete is disabled"},
+		{key: "P", wantStatus: "Select mode: prune is disabled"},
+		{key: " ", wantStatus: "Select mode: selection is disabled"},
+		{key: "s", wantStatus: "Select mode: scope change is disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeService{local: []string{"go1.21.0"}}
+			m := testModel(svc)
+			m.selectMode = true
+			// Select mode wins over read-only mode when both are set.
+			m.readOnly = true
+
+			m, cmd := pressKey(t, m, tt.key)
+			if cmd != nil {
+				t.Fatalf("expected no command for %q", tt.key)
+			}
+			if m.status != tt.wantStatus {
+				t.Fatalf("expected status %q, got %q", tt.wantStatus, m.status)
+			}
+			if m.picked != "" || len(m.selected) != 0 || len(svc.deleted) != 0 {
+				t.Fatalf("expected %q to leave the model unchanged", tt.key)
+			}
+		})
+	}
+}
+
+func TestSelectMode_EnterOnEmptyListPicksNothing(t *testing.T) {
+	t.Parallel()
+
+	m := testModel(&fakeService{})
+	m.selectMode = true
+
+	m, cmd := pressKey(t, m, "enter")
+	if cmd != nil || m.picked != "" {
+		t.Fatalf("expected nothing to be picked, got %q", m.picked)
+	}
+	if m.status != "No version selected" {
+		t.Fatalf("unexpected status %q", m.status)
+	}
+}
+
+func TestJumpToActive(t *testing.T) {
+	t.Parallel()
+
+	versions := make([]string, 0, 40)
+	for minor := 0; minor < 40; minor++ {
+		versions = append(versions, fmt.Sprintf("go1.%d.0", minor))
+	}
+
+	tests := []struct {
+		name       string
+		active     string
+		search     string
+		wantCursor int
+		wantStatus string
+	}{
+		{name: "moves to active row", active: "go1.30.0", wantCursor: 30, wantStatus: "Loaded"},
+		{name: "no active version", wantCursor: 2, wantStatus: "No active version"},
+		{name: "active filtered out", active: "go1.30.0", search: "go1.1", wantCursor: 2, wantStatus: "Active version go1.30.0 is not in this list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := testModel(&fakeService{local: versions, active: switcher.ActiveVersion{Version: tt.active}})
+			m.height = 20
+			m.searchQuery = tt.search
+			m.cursor = 2
+			m.status = "Loaded"
+
+			m, cmd := pressKey(t, m, "a")
+			if cmd != nil {
+				t.Fatalf("expected no command")
+			}
+			if m.cursor != tt.wantCursor {
+				t.Fatalf("expected cursor %d, got %d", tt.wantCursor, m.cursor)
+			}
+			if m.status != tt.wantStatus {
+				t.Fatalf("expected status %q, got %q", tt.wantStatus, m.status)
+			}
+			if start, end := m.visibleRange(m.pageSize(), len(m.currentList())); m.cursor < start || m.cursor >= end {
+				t.Fatalf("expected cursor %d to be visible in [%d, %d)", m.cursor, start, end)
+			}
+		})
+	}
+}
+
+func TestStartupScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		active    switcher.ActiveVersion
+		preferred switcher.Scope
+		option    switcher.Scope
+		wantScope switcher.Scope
+	}{
+		{name: "active scope", active: switcher.ActiveVersion{Version: "go1.22.0", Scope: switcher.ScopeLocal}, wantScope: switcher.ScopeLocal},
+		{name: "no active version", wantScope: switcher.ScopeGlobal},
+		{name: "remembered scope wins over active", active: switcher.ActiveVersion{Version: "go1.22.0", Scope: switcher.ScopeLocal}, preferred: switcher.ScopeGlobal, wantScope: switcher.ScopeGlobal},
+		{name: "remembered scope without active version", preferred: switcher.ScopeLocal, wantScope: switcher.ScopeLocal},
+		{name: "option wins over remembered", active: switcher.ActiveVersion{Version: "go1.22.0", Scope: switcher.ScopeGlobal}, preferred: switcher.ScopeGlobal, option: switcher.ScopeLocal, wantScope: switcher.ScopeLocal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeService{active: tt.active, preferred: tt.preferred}
+			m := newModel(context.Background(), svc, "/work").applyOptions(Options{Scope: tt.option})
+
+			updated, _ := m.Update(m.loadCurrentCmd()())
+			m = updated.(model)
+			if m.scope != tt.wantScope {
+				t.Fatalf("expected scope %q, got %q", tt.wantScope, m.scope)
+			}
+		})
+	}
+}
+
+func TestScopeToggle_RemembersChoice(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		err       error
+		wantError string
+	}{
+		{name: "saved"},
+		{name: "save fails", err: errors.New("read-only config"), wantError: "Remember scope: read-only config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeService{rememberErr: tt.err}
+			m := testModel(svc)
+
+			m, cmd := pressKey(t, m, "s")
+			if m.scope != switcher.ScopeLocal || m.status != "Scope set to local" {
+				t.Fatalf("expected local scope, got %q (status %q)", m.scope, m.status)
+			}
+			m = runCmd[scopeSavedMsg](t, m, cmd)
+			if len(svc.remembered) != 1 || svc.remembered[0] != switcher.ScopeLocal {
+				t.Fatalf("expected local scope to be remembered, got %v", svc.remembered)
+			}
+			if m.lastError != tt.wantError {
+				t.Fatalf("expected error %q, got %q", tt.wantError, m.lastError)
+			}
+		})
+	}
+}
+
+func TestRemoteFetch_Cancel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		key          string
+		previousList []string
+		wantMode     listMode
+	}{
+		{name: "esc returns to local", key: "esc", wantMode: modeLocal},
+		{name: "q returns to local without quitting", key: "q", wantMode: modeLocal},
+		{name: "esc keeps earlier remote list", key: "esc", previousList: []string{"go1.22.0"}, wantMode: modeRemote},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeService{local: []string{"go1.21.0"}, remote: []string{"go1.23.0", "go1.22.0"}}
+			m := testModel(svc)
+			var fetch tea.Cmd
+			if tt.previousList != nil {
+				m.mode = modeRemote
+				m.remoteVersions = tt.previousList
+				m.hasRemoteHit = true
+				m, fetch = pressKey(t, m, "r")
+			} else {
+				m, fetch = pressKey(t, m, "tab")
+			}
+			if !m.busy || m.fetchCancel == nil {
+				t.Fatalf("expected a remote fetch in flight")
+			}
+
+			m, cmd := pressKey(t, m, tt.key)
+			if cmd != nil {
+				t.Fatalf("expected %q to cancel the fetch, not quit", tt.key)
+			}
+			if m.busy || m.fetchCancel != nil || m.status != "Remote fetch cancelled" {
+				t.Fatalf("expected an idle model after cancel, got busy=%v status %q", m.busy, m.status)
+			}
+			if m.mode != tt.wantMode {
+				t.Fatalf("expected mode %v, got %v", tt.wantMode, m.mode)
+			}
+
+			// The cancelled fetch finishes later; its result must be dropped.
+			msg := cmdMsg[versionsMsg](t, fetch)
+			if !errors.Is(msg.err, context.Canceled) {
+				t.Fatalf("expected the fetch context to be cancelled, got %v", msg.err)
+			}
+			updated, _ := m.Update(msg)
+			m = updated.(model)
+			if m.status != "Remote fetch cancelled" || m.lastError != "" {
+				t.Fatalf("expected stale fetch result to be ignored, got status %q error %q", m.status, m.lastError)
+			}
+			if tt.previousList != nil && len(m.remoteVersions) != len(tt.previousList) {
+				t.Fatalf("expected earlier remote list to be kept, got %v", m.remoteVersions)
+			}
+		})
+	}
+}
+
+func TestRemoteFetch_LocalResultKeepsFetchBusy(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeService{local: []string{"go1.21.0"}, remote: []string{"go1.23.0"}}
+	m := testModel(svc)
+	m, fetch := pressKey(t, m, "tab")
+
+	updated, _ := m.Update(versionsMsg{mode: modeLocal, versions: []string{"go1.21.0", "go1.22.0"}})
+	m = updated.(model)
+	if !m.busy || m.fetchCancel == nil {
+		t.Fatalf("expected the remote fetch to stay in flight")
+	}
+	if len(m.localVersions) != 2 {
+		t.Fatalf("expected local versions to update, got %v", m.localVersions)
+	}
+
+	m = runCmd[versionsMsg](t, m, fetch)
+	if m.busy || m.fetchCancel != nil || len(m.remoteVersions) != 1 {
+		t.Fatalf("expected fetch to complete, got busy=%v remote %v", m.busy, m.remoteVersions)
+	}
+}
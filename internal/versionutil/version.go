@@ -2,125 +2,336 @@ package versionutil
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 // NormalizeGoVersion normalizes versions like 1.24.2 or go1.24 to go1.24.2.
+// Prereleases such as 1.25rc1 or go1.24beta1 keep their suffix: go1.25rc1.
 func NormalizeGoVersion(input string) (string, error) {
+	key, err := parseGoVersion(input)
+	if err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
+
+// parseGoVersion parses a release or prerelease version; minor aliases are
+// not versions and are rejected.
+func parseGoVersion(input string) (VersionKey, error) {
 	trimmed := strings.TrimSpace(input)
 	if trimmed == "" {
-		return "", fmt.Errorf("version cannot be empty")
+		return VersionKey{}, fmt.Errorf("version cannot be empty")
 	}
 
 	trimmed = strings.TrimPrefix(trimmed, "go")
+	for stage, marker := range prereleaseStages {
+		selector, rest, found := strings.Cut(trimmed, marker)
+		if !found {
+			continue
+		}
+		major, minor, err := ParseMinorSelector(selector)
+		number, numberErr := strconv.Atoi(rest)
+		if err != nil || numberErr != nil || number < 1 {
+			return VersionKey{}, fmt.Errorf("invalid go version %q", input)
+		}
+		return VersionKey{major: major, minor: minor, stage: stage, number: number}, nil
+	}
+
 	parts := strings.Split(trimmed, ".")
 	if len(parts) < 2 || len(parts) > 3 {
-		return "", fmt.Errorf("invalid go version %q", input)
+		return VersionKey{}, fmt.Errorf("invalid go version %q", input)
 	}
 
 	numbers := make([]int, 3)
 	for i := 0; i < len(parts); i++ {
 		n, err := strconv.Atoi(parts[i])
 		if err != nil || n < 0 {
-			return "", fmt.Errorf("invalid go version %q", input)
+			return VersionKey{}, fmt.Errorf("invalid go version %q", input)
 		}
 		numbers[i] = n
 	}
+	return VersionKey{major: numbers[0], minor: numbers[1], stage: stageRelease, patch: numbers[2]}, nil
+}
+
+// NormalizeVersionSpec normalizes a pinned version or a minor alias such as
+// go1.24.x, which is returned in its canonical form. Linked toolchain names
+// are returned unchanged.
+func NormalizeVersionSpec(input string) (string, error) {
+	if alias, _, ok := ParseMinorAlias(input); ok {
+		return alias, nil
+	}
+	if name, ok := ParseLinkName(input); ok {
+		return name, nil
+	}
+	return NormalizeGoVersion(input)
+}
+
+// ParseLinkName recognizes the name of a linked toolchain such as devel or
+// go-src. Names start with a letter, contain only letters, digits, '.', '-'
+// and '_', and can never be read as a Go version or minor alias; the "x"
+// wildcard of aliases is reserved.
+func ParseLinkName(input string) (string, bool) {
+	name := strings.TrimSpace(input)
+	if name == "" || !isASCIILetter(name[0]) || name == "x" || strings.HasSuffix(name, ".x") {
+		return "", false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isASCIILetter(c) && (c < '0' || c > '9') && c != '.' && c != '-' && c != '_' {
+			return "", false
+		}
+	}
+	if rest, ok := strings.CutPrefix(name, "go"); ok && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+		return "", false
+	}
+	return name, true
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
 
-	if len(parts) == 2 {
-		numbers[2] = 0
+// ParseMinorAlias recognizes aliases like go1.24.x or 1.24.x that follow the
+// newest installed patch of a minor line. majorMinor is the line, e.g. 1.24,
+// as LatestPatch takes it.
+func ParseMinorAlias(input string) (alias string, majorMinor string, ok bool) {
+	major, minor, ok := parseMinorAlias(input)
+	if !ok {
+		return "", "", false
 	}
+	majorMinor = fmt.Sprintf("%d.%d", major, minor)
+	return "go" + majorMinor + ".x", majorMinor, true
+}
 
-	return fmt.Sprintf("go%d.%d.%d", numbers[0], numbers[1], numbers[2]), nil
+func parseMinorAlias(input string) (major int, minor int, ok bool) {
+	selector, found := strings.CutSuffix(strings.TrimSpace(input), ".x")
+	if !found {
+		return 0, 0, false
+	}
+	major, minor, err := ParseMinorSelector(selector)
+	return major, minor, err == nil
 }
 
-// ParseGoVersion parses a normalized or raw go version.
+// ParseGoVersion parses a normalized or raw go version. Prereleases report
+// patch 0, the release they lead up to.
 func ParseGoVersion(version string) (major int, minor int, patch int, err error) {
-	normalized, err := NormalizeGoVersion(version)
+	key, err := parseGoVersion(version)
 	if err != nil {
 		return 0, 0, 0, err
 	}
+	return key.major, key.minor, key.patch, nil
+}
 
-	trimmed := strings.TrimPrefix(normalized, "go")
+// ParseMinorSelector parses a major.minor selector such as 1.24 or go1.24.
+func ParseMinorSelector(input string) (major int, minor int, err error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(input), "go")
 	parts := strings.Split(trimmed, ".")
-	major, err = strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, 0, 0, fmt.Errorf("parse major from %q: %w", version, err)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid minor selector %q (expected major.minor)", input)
+	}
+
+	major, majorErr := strconv.Atoi(parts[0])
+	minor, minorErr := strconv.Atoi(parts[1])
+	if majorErr != nil || minorErr != nil || major < 0 || minor < 0 {
+		return 0, 0, fmt.Errorf("invalid minor selector %q (expected major.minor)", input)
 	}
-	minor, err = strconv.Atoi(parts[1])
+	return major, minor, nil
+}
+
+// FilterMinor returns the versions in the given major.minor line, keeping
+// their order. Unparseable versions are dropped.
+func FilterMinor(versions []string, major int, minor int) []string {
+	filtered := make([]string, 0, len(versions))
+	for _, v := range versions {
+		vMajor, vMinor, _, err := ParseGoVersion(v)
+		if err != nil || vMajor != major || vMinor != minor {
+			continue
+		}
+		filtered = append(filtered, v)
+	}
+	return filtered
+}
+
+// MinorLine returns the major.minor line of a go version, e.g. 1.24 for
+// go1.24.2 or go1.24rc1.
+func MinorLine(version string) (string, error) {
+	major, minor, _, err := ParseGoVersion(version)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("parse minor from %q: %w", version, err)
+		return "", err
 	}
-	patch, err = strconv.Atoi(parts[2])
+	return fmt.Sprintf("%d.%d", major, minor), nil
+}
+
+// LatestPatch returns the newest version in the major.minor line selected by
+// majorMinor, e.g. "1.24" or "go1.24".
+func LatestPatch(versions []string, majorMinor string) (string, bool) {
+	major, minor, err := ParseMinorSelector(majorMinor)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("parse patch from %q: %w", version, err)
+		return "", false
 	}
 
-	return major, minor, patch, nil
+	latest := ""
+	for _, v := range FilterMinor(versions, major, minor) {
+		if latest == "" {
+			latest = v
+			continue
+		}
+		if cmp, err := CompareGoVersions(v, latest); err == nil && cmp > 0 {
+			latest = v
+		}
+	}
+	return latest, latest != ""
 }
 
-// CompareGoVersions compares go versions and returns -1/0/1.
+// GroupByMinor groups versions by their major.minor line as MinorLine names
+// it, newest patch first within each group. Unparseable versions are
+// dropped.
+func GroupByMinor(versions []string) map[string][]string {
+	groups := map[string][]string{}
+	for _, v := range versions {
+		line, err := MinorLine(v)
+		if err != nil {
+			continue
+		}
+		groups[line] = append(groups[line], v)
+	}
+	for _, group := range groups {
+		SortNewestFirst(group)
+	}
+	return groups
+}
+
+// CompareGoVersions compares go versions and returns -1/0/1. Prereleases
+// order before the release they lead up to: go1.25rc1 < go1.25.0.
 func CompareGoVersions(a string, b string) (int, error) {
-	aMajor, aMinor, aPatch, err := ParseGoVersion(a)
+	aKey, err := parseGoVersion(a)
 	if err != nil {
 		return 0, err
 	}
-	bMajor, bMinor, bPatch, err := ParseGoVersion(b)
+	bKey, err := parseGoVersion(b)
 	if err != nil {
 		return 0, err
 	}
+	return aKey.Compare(bKey), nil
+}
 
-	if aMajor != bMajor {
-		if aMajor < bMajor {
-			return -1, nil
-		}
-		return 1, nil
+// ParsePrerelease recognizes alpha, beta and release candidate versions such
+// as go1.25rc1 or 1.21beta2 and returns them in canonical form.
+func ParsePrerelease(input string) (version string, major int, minor int, ok bool) {
+	key, err := parseGoVersion(input)
+	if err != nil || key.stage >= stageRelease {
+		return "", 0, 0, false
 	}
+	return key.String(), key.major, key.minor, true
+}
 
-	if aMinor != bMinor {
-		if aMinor < bMinor {
-			return -1, nil
-		}
-		return 1, nil
-	}
+const (
+	stageAlpha = iota
+	stageBeta
+	stageRC
+	stageRelease
+	stageAlias
+)
 
-	if aPatch != bPatch {
-		if aPatch < bPatch {
-			return -1, nil
-		}
-		return 1, nil
+var prereleaseStages = [...]string{stageAlpha: "alpha", stageBeta: "beta", stageRC: "rc"}
+
+// VersionKey orders concrete versions, prereleases and minor aliases on one
+// scale: within a minor line, alphas come before betas, betas before release
+// candidates, which come before the .0 release, and the line's alias comes
+// after every patch.
+type VersionKey struct {
+	major, minor, stage, patch, number int
+}
+
+// String returns the canonical form: go1.24.2, go1.25rc1 or go1.24.x.
+func (k VersionKey) String() string {
+	switch {
+	case k.stage == stageAlias:
+		return fmt.Sprintf("go%d.%d.x", k.major, k.minor)
+	case k.stage < stageRelease:
+		return fmt.Sprintf("go%d.%d%s", k.major, k.minor, k.Prerelease())
+	default:
+		return fmt.Sprintf("go%d.%d.%d", k.major, k.minor, k.patch)
 	}
+}
 
-	return 0, nil
+// Prerelease returns the prerelease suffix such as rc1, or "" for releases
+// and aliases.
+func (k VersionKey) Prerelease() string {
+	if k.stage >= stageRelease {
+		return ""
+	}
+	return fmt.Sprintf("%s%d", prereleaseStages[k.stage], k.number)
 }
 
-// CompareDottedVersions compares dotted versions like 1.59.1 and v1.60.0.
-func CompareDottedVersions(a string, b string) (int, error) {
-	parse := func(v string) ([]int, error) {
-		trimmed := strings.TrimPrefix(strings.TrimSpace(v), "v")
-		parts := strings.Split(trimmed, ".")
-		if len(parts) == 0 {
-			return nil, fmt.Errorf("empty dotted version")
-		}
+// ParseVersionKey parses go1.24.2, go1.25rc1, go1.21beta2 or go1.24.x, with
+// or without the go prefix.
+func ParseVersionKey(input string) (VersionKey, bool) {
+	if major, minor, ok := parseMinorAlias(input); ok {
+		return VersionKey{major: major, minor: minor, stage: stageAlias}, true
+	}
+	key, err := parseGoVersion(input)
+	return key, err == nil
+}
 
-		numbers := make([]int, len(parts))
-		for i, p := range parts {
-			n, err := strconv.Atoi(p)
-			if err != nil {
-				return nil, fmt.Errorf("invalid dotted version %q", v)
+// Compare returns -1/0/1.
+func (k VersionKey) Compare(other VersionKey) int {
+	a := [...]int{k.major, k.minor, k.stage, k.patch, k.number}
+	b := [...]int{other.major, other.minor, other.stage, other.patch, other.number}
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
 			}
-			numbers[i] = n
+			return 1
 		}
+	}
+	return 0
+}
 
-		return numbers, nil
+// CompareVersions is CompareGoVersions extended to minor aliases; ok is
+// false when either side is neither a version nor an alias.
+func CompareVersions(a string, b string) (cmp int, ok bool) {
+	aKey, aOK := ParseVersionKey(a)
+	bKey, bOK := ParseVersionKey(b)
+	if !aOK || !bOK {
+		return 0, false
 	}
+	return aKey.Compare(bKey), true
+}
+
+// SortNewestFirst sorts versions newest first using CompareVersions. Other
+// strings, such as linked toolchain names, follow in lexical order.
+func SortNewestFirst(versions []string) {
+	sort.SliceStable(versions, func(i int, j int) bool {
+		aKey, aOK := ParseVersionKey(versions[i])
+		bKey, bOK := ParseVersionKey(versions[j])
+		switch {
+		case aOK && bOK:
+			return aKey.Compare(bKey) > 0
+		case aOK != bOK:
+			return aOK
+		default:
+			return versions[i] < versions[j]
+		}
+	})
+}
 
-	aParts, err := parse(a)
+// ValidateDottedVersion reports whether v is a dotted version like v1.60.3.
+func ValidateDottedVersion(v string) error {
+	_, err := parseDottedVersion(v)
+	return err
+}
+
+// CompareDottedVersions compares dotted versions like 1.59.1 and v1.60.0.
+func CompareDottedVersions(a string, b string) (int, error) {
+	aParts, err := parseDottedVersion(a)
 	if err != nil {
 		return 0, err
 	}
-	bParts, err := parse(b)
+	bParts, err := parseDottedVersion(b)
 	if err != nil {
 		return 0, err
 	}
@@ -150,3 +361,22 @@ func CompareDottedVersions(a string, b string) (int, error) {
 
 	return 0, nil
 }
+
+func parseDottedVersion(v string) ([]int, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	parts := strings.Split(trimmed, ".")
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("empty dotted version")
+	}
+
+	numbers := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dotted version %q", v)
+		}
+		numbers[i] = n
+	}
+
+	return numbers, nil
+}
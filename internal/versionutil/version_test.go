@@ -1,6 +1,9 @@
 package versionutil
 
-import "testing"
+import (
+	"slices"
+	"testing"
+)
 
 func TestNormalizeGoVersion(t *testing.T) {
 	t.Parallel()
@@ -14,7 +17,11 @@ func TestNormalizeGoVersion(t *testing.T) {
 		{name: "already normalized", input: "go1.24.2", want: "go1.24.2"},
 		{name: "missing go prefix", input: "1.24.2", want: "go1.24.2"},
 		{name: "missing patch", input: "1.25", want: "go1.25.0"},
-		{name: "invalid prerelease", input: "go1.25rc1", wantErr: true},
+		{name: "release candidate", input: "go1.25rc1", want: "go1.25rc1"},
+		{name: "beta without prefix", input: "1.24beta2", want: "go1.24beta2"},
+		{name: "alpha", input: "go1.26alpha1", want: "go1.26alpha1"},
+		{name: "prerelease without number", input: "go1.25rc", wantErr: true},
+		{name: "prerelease of a patch", input: "go1.25.1rc1", wantErr: true},
 		{name: "invalid text", input: "latest", wantErr: true},
 	}
 
@@ -52,6 +59,13 @@ func TestCompareGoVersions(t *testing.T) {
 	if cmp <= 0 {
 		t.Fatalf("expected go1.24.0 > go1.23.9")
 	}
+
+	for _, pair := range [][2]string{{"go1.25rc1", "go1.25.0"}, {"go1.25beta1", "go1.25rc1"}, {"go1.25alpha1", "go1.25beta1"}, {"go1.24.9", "go1.25rc1"}} {
+		cmp, err := CompareGoVersions(pair[0], pair[1])
+		if err != nil || cmp >= 0 {
+			t.Fatalf("expected %s < %s, got %d (%v)", pair[0], pair[1], cmp, err)
+		}
+	}
 }
 
 func TestCompareDottedVersions(t *testing.T) {
@@ -65,3 +79,204 @@ func TestCompareDottedVersions(t *testing.T) {
 		t.Fatalf("expected v1.60.3 > 1.57.2")
 	}
 }
+
+func TestGroupByMinor(t *testing.T) {
+	t.Parallel()
+
+	groups := GroupByMinor([]string{"go1.24.2", "1.25.0", "go1.24.10", "bogus", "go1.24.0", "go1.25.1", "go1.26rc1", "go1.25rc2"})
+	want := map[string][]string{
+		"1.24": {"go1.24.10", "go1.24.2", "go1.24.0"},
+		"1.25": {"go1.25.1", "1.25.0", "go1.25rc2"},
+		"1.26": {"go1.26rc1"},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("expected %d groups, got %v", len(want), groups)
+	}
+	for key, wantGroup := range want {
+		if !slices.Equal(groups[key], wantGroup) {
+			t.Fatalf("group %s: expected %v, got %v", key, wantGroup, groups[key])
+		}
+	}
+
+	if groups := GroupByMinor(nil); len(groups) != 0 {
+		t.Fatalf("expected no groups, got %v", groups)
+	}
+}
+
+func TestFilterMinor(t *testing.T) {
+	t.Parallel()
+
+	major, minor, err := ParseMinorSelector("1.24")
+	if err != nil {
+		t.Fatalf("ParseMinorSelector: %v", err)
+	}
+	got := FilterMinor([]string{"go1.25.0", "go1.24.2", "go1.24.1", "go1.2.4"}, major, minor)
+	if len(got) != 2 || got[0] != "go1.24.2" || got[1] != "go1.24.1" {
+		t.Fatalf("unexpected filtered versions %v", got)
+	}
+
+	for _, input := range []string{"1", "1.24.2", "go1.x", ""} {
+		if _, _, err := ParseMinorSelector(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestNormalizeVersionSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"go1.24.x": "go1.24.x",
+		"1.24.x":   "go1.24.x",
+		"go1.24.2": "go1.24.2",
+		"1.24":     "go1.24.0",
+	}
+	for input, want := range tests {
+		got, err := NormalizeVersionSpec(input)
+		if err != nil {
+			t.Fatalf("NormalizeVersionSpec(%q): %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("NormalizeVersionSpec(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	for _, input := range []string{"go1.x", "go1.24.2.x", "x"} {
+		if _, err := NormalizeVersionSpec(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestLatestPatch(t *testing.T) {
+	t.Parallel()
+
+	versions := []string{"go1.24.2", "go1.25.0", "go1.24.10", "go1.24.9", "bogus", "go1.2.40", "go1.25rc1", "go1.26rc2"}
+	tests := []struct {
+		majorMinor string
+		want       string
+		found      bool
+	}{
+		{majorMinor: "1.24", want: "go1.24.10", found: true},
+		{majorMinor: "go1.25", want: "go1.25.0", found: true},
+		{majorMinor: " 1.2 ", want: "go1.2.40", found: true},
+		{majorMinor: "1.26", want: "go1.26rc2", found: true},
+		{majorMinor: "1.23", found: false},
+		{majorMinor: "1.24.2", found: false},
+		{majorMinor: "1.24.x", found: false},
+		{majorMinor: "", found: false},
+	}
+	for _, tc := range tests {
+		got, found := LatestPatch(versions, tc.majorMinor)
+		if got != tc.want || found != tc.found {
+			t.Fatalf("LatestPatch(%q) = %q, %v; want %q, %v", tc.majorMinor, got, found, tc.want, tc.found)
+		}
+	}
+
+	if _, found := LatestPatch(nil, "1.24"); found {
+		t.Fatalf("expected no match for empty input")
+	}
+}
+
+func TestParseMinorAlias(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input      string
+		alias      string
+		majorMinor string
+		ok         bool
+	}{
+		{input: "go1.24.x", alias: "go1.24.x", majorMinor: "1.24", ok: true},
+		{input: " 1.25.x ", alias: "go1.25.x", majorMinor: "1.25", ok: true},
+		{input: "go1.24.2"},
+		{input: "1.x"},
+	}
+	for _, tc := range tests {
+		alias, majorMinor, ok := ParseMinorAlias(tc.input)
+		if alias != tc.alias || majorMinor != tc.majorMinor || ok != tc.ok {
+			t.Fatalf("ParseMinorAlias(%q) = %q, %q, %v; want %q, %q, %v", tc.input, alias, majorMinor, ok, tc.alias, tc.majorMinor, tc.ok)
+		}
+	}
+}
+
+func TestParseLinkName(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"devel":     true,
+		"go-src":    true,
+		"gotip":     true,
+		"my_go.1":   true,
+		"go1.24.2":  false,
+		"go1":       false,
+		"1.24":      false,
+		"x":         false,
+		"devel.x":   false,
+		"-devel":    false,
+		"dev/local": false,
+		"":          false,
+	}
+	for input, want := range tests {
+		if _, got := ParseLinkName(input); got != want {
+			t.Fatalf("ParseLinkName(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestSortNewestFirst(t *testing.T) {
+	t.Parallel()
+
+	versions := []string{"go1.24.x", "devel", "go1.25.0", "go1.25rc1", "go1.24.2", "go1.25beta1", "go1.25rc2", "go1.9.7", "go1.25.1", "go1.24.10", "go-src"}
+	SortNewestFirst(versions)
+
+	want := []string{"go1.25.1", "go1.25.0", "go1.25rc2", "go1.25rc1", "go1.25beta1", "go1.24.x", "go1.24.10", "go1.24.2", "go1.9.7", "devel", "go-src"}
+	if len(versions) != len(want) {
+		t.Fatalf("expected %v, got %v", want, versions)
+	}
+	for i := range want {
+		if versions[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, versions)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b   string
+		want   int
+		wantOK bool
+	}{
+		{a: "go1.25rc1", b: "go1.25.0", want: -1, wantOK: true},
+		{a: "1.25rc2", b: "go1.25rc1", want: 1, wantOK: true},
+		{a: "go1.21beta2", b: "go1.21rc1", want: -1, wantOK: true},
+		{a: "go1.25rc1", b: "go1.24.9", want: 1, wantOK: true},
+		{a: "go1.24.x", b: "go1.24.99", want: 1, wantOK: true},
+		{a: "go1.24.x", b: "go1.25rc1", want: -1, wantOK: true},
+		{a: "1.24.2", b: "go1.24.2", want: 0, wantOK: true},
+		{a: "go1.25rc0", b: "go1.25.0", wantOK: false},
+		{a: "devel", b: "go1.25.0", wantOK: false},
+	}
+
+	for _, tc := range tests {
+		got, ok := CompareVersions(tc.a, tc.b)
+		if ok != tc.wantOK || got != tc.want {
+			t.Fatalf("CompareVersions(%q, %q): expected (%d, %v), got (%d, %v)", tc.a, tc.b, tc.want, tc.wantOK, got, ok)
+		}
+	}
+}
+
+func TestParsePrerelease(t *testing.T) {
+	t.Parallel()
+
+	if version, major, minor, ok := ParsePrerelease("1.25rc1"); !ok || version != "go1.25rc1" || major != 1 || minor != 25 {
+		t.Fatalf("unexpected parse (%q, %d, %d, %v)", version, major, minor, ok)
+	}
+	for _, input := range []string{"go1.25.0", "go1.24.x", "go1.25rc", "go1.25.1rc1"} {
+		if _, _, _, ok := ParsePrerelease(input); ok {
+			t.Fatalf("expected %q not to be a prerelease", input)
+		}
+	}
+}